@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// maxAuditResponseBytes caps how much of a response is kept to read its error and resource ID
+const maxAuditResponseBytes = 64 << 10
+
+// Audit query limits. Exports return more entries by default.
+const (
+	defaultAuditLimit = 100
+	exportAuditLimit  = 10000
+	maxAuditLimit     = 10000
+)
+
+// auditedKey marks a request whose route recorded its own audit entry
+const auditedKey = "audited"
+
+// auditRejections records writes that are rejected before their route's
+// audit middleware runs: unauthenticated callers and unknown tenants. It is
+// mounted ahead of authentication.
+func auditRejections(auditLog *services.AuditLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		if _, audited := c.Get(auditedKey); audited || !isWriteMethod(c.Request.Method) {
+			return
+		}
+		status := c.Writer.Status()
+		if status != http.StatusUnauthorized && status != http.StatusForbidden {
+			return
+		}
+		identity, _ := currentIdentity(c)
+		entry := types.AuditEntry{
+			Timestamp:  started,
+			Actor:      firstNonEmpty(identity.User(), "unauthenticated"),
+			AuthMethod: identity.Method,
+			TenantID:   identity.TenantID,
+			Action:     types.AuditAuthRejected,
+			Method:     c.Request.Method,
+			Endpoint:   c.Request.URL.Path,
+			ClientIP:   c.ClientIP(),
+			Outcome:    types.AuditOutcomeDenied,
+			StatusCode: status,
+			DurationMs: time.Since(started).Milliseconds(),
+		}
+		if last := c.Errors.Last(); last != nil {
+			entry.Error = last.Error()
+		}
+		auditLog.Record(entry)
+	}
+}
+
+// isWriteMethod reports whether an HTTP method changes state
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// audit records a write in the audit log once it finishes, including denied
+// and failed attempts. Turvo calls made through the tenant's service are
+// summarized on the entry. Writes are refused with 503 while earlier entries
+// cannot be written, so nothing changes without a record.
+func audit(auditLog *services.AuditLog, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(auditedKey, true)
+		if err := auditLog.Healthy(); err != nil {
+			fmt.Printf("DEBUG: ALERT: Refusing %s %s, the audit log is unavailable: %v\n", c.Request.Method, c.Request.URL.Path, err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"error":   "Audit log unavailable, try again later",
+			})
+			return
+		}
+
+		started := time.Now()
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to read body for audit: %v\n", err)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		trace := &services.TurvoTrace{}
+		if value, ok := c.Get(tenantKey); ok {
+			tenant := *value.(*services.TenantServices)
+			tenant.Turvo = tenant.Turvo.Traced(trace)
+			c.Set(tenantKey, &tenant)
+		}
+
+		writer := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		identity, _ := currentIdentity(c)
+		entry := types.AuditEntry{
+			Timestamp:  started,
+			Actor:      identity.User(),
+			AuthMethod: identity.Method,
+			Action:     action,
+			Method:     c.Request.Method,
+			Endpoint:   c.Request.URL.Path,
+			ClientIP:   c.ClientIP(),
+			ResourceID: c.Param("id"),
+			Request:    services.RedactPayload(body),
+			Turvo:      trace.Calls(),
+			StatusCode: writer.Status(),
+			DurationMs: time.Since(started).Milliseconds(),
+		}
+		if value, ok := c.Get(tenantKey); ok {
+			entry.TenantID = value.(*services.TenantServices).Tenant.ID
+		}
+
+		var response struct {
+			Error string `json:"error"`
+			Data  struct {
+				ID         string `json:"id"`
+				ShipmentID string `json:"externalTMSLoadID"`
+			} `json:"data"`
+		}
+		json.Unmarshal(writer.body.Bytes(), &response)
+		if entry.ResourceID == "" {
+			entry.ResourceID = firstNonEmpty(response.Data.ShipmentID, response.Data.ID)
+		}
+		switch status := entry.StatusCode; {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			entry.Outcome = types.AuditOutcomeDenied
+			entry.Error = response.Error
+		case status >= 400:
+			entry.Outcome = types.AuditOutcomeFailed
+			entry.Error = response.Error
+		default:
+			entry.Outcome = types.AuditOutcomeSuccess
+		}
+		auditLog.Record(entry)
+	}
+}
+
+// auditWriter keeps the start of a response while passing it through
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write passes the body through, keeping its start
+func (w *auditWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString passes the body through, keeping its start
+func (w *auditWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// keep buffers data up to maxAuditResponseBytes
+func (w *auditWriter) keep(data []byte) {
+	if room := maxAuditResponseBytes - w.body.Len(); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		w.body.Write(data)
+	}
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// getAuditLog lists the tenant's audit entries, newest first, filtered by
+// actor, action, outcome, resourceId and a from/to time range. format=csv or
+// format=jsonl downloads them instead.
+func getAuditLog(c *gin.Context, auditLog *services.AuditLog) {
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "format must be json, csv or jsonl",
+		})
+		return
+	}
+
+	filter, err := auditFilter(c, format != "json")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	entries, err := auditLog.Query(filter)
+	if err != nil {
+		fmt.Printf("DEBUG: Audit query failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to read audit log: " + err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102T150405Z"), format)
+	switch format {
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, "text/csv", auditCSV(entries))
+	case "jsonl":
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		for _, entry := range entries {
+			encoder.Encode(entry)
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Data(http.StatusOK, "application/x-ndjson", buf.Bytes())
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    entries,
+			"count":   len(entries),
+		})
+	}
+}
+
+// auditFilter reads the audit query parameters for the caller's tenant
+func auditFilter(c *gin.Context, export bool) (types.AuditFilter, error) {
+	filter := types.AuditFilter{
+		TenantID:   tenantFor(c).Tenant.ID,
+		Actor:      strings.TrimSpace(c.Query("actor")),
+		Action:     strings.TrimSpace(c.Query("action")),
+		Outcome:    strings.TrimSpace(c.Query("outcome")),
+		ResourceID: strings.TrimSpace(c.Query("resourceId")),
+		Limit:      defaultAuditLimit,
+	}
+	if export {
+		filter.Limit = exportAuditLimit
+	}
+
+	var err error
+	if filter.From, err = parseAuditTime(c.Query("from"), false); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To, err = parseAuditTime(c.Query("to"), true); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// parseAuditTime reads an RFC 3339 time or a YYYY-MM-DD date. A date used as
+// the end of a range includes the whole day.
+func parseAuditTime(value string, end bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 or YYYY-MM-DD, got %q", value)
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// auditCSV writes entries as CSV, one row per entry. Turvo calls are joined
+// into one column and the request is kept as JSON.
+func auditCSV(entries []types.AuditEntry) []byte {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"id", "timestamp", "actor", "authMethod", "tenantId", "action", "method", "endpoint",
+		"resourceId", "outcome", "statusCode", "error", "durationMs", "turvo", "request"})
+	for _, entry := range entries {
+		calls := make([]string, len(entry.Turvo))
+		for i, call := range entry.Turvo {
+			calls[i] = strings.TrimSpace(fmt.Sprintf("%s %s %d %s", call.Method, call.Path, call.StatusCode, firstNonEmpty(call.Error, call.Summary)))
+		}
+		writer.Write([]string{
+			entry.ID,
+			entry.Timestamp.UTC().Format(time.RFC3339),
+			entry.Actor,
+			entry.AuthMethod,
+			entry.TenantID,
+			entry.Action,
+			entry.Method,
+			entry.Endpoint,
+			entry.ResourceID,
+			entry.Outcome,
+			strconv.Itoa(entry.StatusCode),
+			entry.Error,
+			strconv.FormatInt(entry.DurationMs, 10),
+			strings.Join(calls, "; "),
+			string(entry.Request),
+		})
+	}
+	writer.Flush()
+	return buf.Bytes()
+}
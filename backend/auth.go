@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// identityKey is the Gin context key holding the caller's types.Identity
+const identityKey = "identity"
+
+// streamRoute may pass its bearer token as ?access_token= because
+// EventSource cannot set request headers
+const streamRoute = "/api/loads/stream"
+
+// requestLogger is Gin's request logger with any ?access_token= value
+// redacted, so stream tokens never reach the access log
+func requestLogger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		param.Path = redactAccessToken(param.Path)
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			param.Path,
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactAccessToken replaces the access_token query value in a request path
+func redactAccessToken(path string) string {
+	route, rawQuery, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return route + "?[unparsed query redacted]"
+	}
+	if _, found := query["access_token"]; !found {
+		return path
+	}
+	query.Set("access_token", "REDACTED")
+	return route + "?" + query.Encode()
+}
+
+// requireAuth authenticates each request by X-API-Key or Bearer token and
+// attaches the caller's identity to the context, answering 401 otherwise
+func requireAuth(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authService.Disabled() {
+			c.Set(identityKey, types.Identity{Subject: "anonymous", Method: types.AuthMethodNone, Roles: []string{types.RoleAdmin}})
+			c.Next()
+			return
+		}
+
+		identity, err := authenticate(c, authService)
+		if err != nil {
+			fmt.Printf("DEBUG: Rejected %s %s: %v\n", c.Request.Method, c.Request.URL.Path, err)
+			c.Error(err)
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.Set(identityKey, identity)
+		c.Next()
+	}
+}
+
+// authenticate reads the caller's credentials, preferring an API key
+func authenticate(c *gin.Context, authService *services.AuthService) (types.Identity, error) {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return authService.AuthenticateAPIKey(key)
+	}
+
+	authorization := c.GetHeader("Authorization")
+	if scheme, token, ok := strings.Cut(authorization, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return authService.AuthenticateBearer(token)
+	}
+	if token := c.Query("access_token"); token != "" && c.FullPath() == streamRoute {
+		return authService.AuthenticateBearer(token)
+	}
+	return types.Identity{}, services.ErrMissingCredentials
+}
+
+// currentIdentity returns the authenticated caller attached by requireAuth
+func currentIdentity(c *gin.Context) (types.Identity, bool) {
+	value, ok := c.Get(identityKey)
+	if !ok {
+		return types.Identity{}, false
+	}
+	identity, ok := value.(types.Identity)
+	return identity, ok
+}
+
+// getIdentity returns the authenticated caller, its tenant and its permissions
+func getIdentity(c *gin.Context, access *services.AccessControl) {
+	identity, _ := currentIdentity(c)
+	tenant := tenantFor(c).Tenant
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"data":        identity,
+		"tenant":      gin.H{"id": tenant.ID, "name": tenant.Name},
+		"permissions": access.Permissions(identity),
+	})
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// vetCarrier checks a carrier's MC/DOT numbers, authority, insurance and
+// safety rating without dispatching it
+func vetCarrier(c *gin.Context, vettingService *services.CarrierVettingService) {
+	var carrier types.Carrier
+	if err := c.ShouldBindJSON(&carrier); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    vettingService.Vet(carrier),
+	})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -21,6 +22,52 @@ type Config struct {
 	TurvoOAuthScope        string
 	TurvoOAuthType         string
 	TurvoXApiKey           string
+
+	EDIPartnersFile       string
+	EDIControlNumbersFile string
+	EDIOutboxFile         string
+
+	BOLTemplatesFile  string
+	RateConBrokersFile string
+
+	FSCSchedulesFile string
+	DieselPricesFile string
+
+	AccessorialsFile string
+	TurvoCodesFile   string
+
+	ZipCodesFile string
+
+	CarrierVettingProvider string
+	CarrierComplianceFile  string
+	VettingOverrideUsers   string
+
+	WebhookSubscriptionsFile  string
+	WebhookDeadLettersFile    string
+	WebhookQueueFile          string
+	WebhookMaxAttempts        int
+	WebhookRetryBaseSeconds   int
+	WebhookPollIntervalSeconds int
+
+	TurvoWebhookSecret string
+
+	AuthDisabled   bool
+	APIKeysFile    string
+	JWTHS256Secret string
+	JWTJWKSFile    string
+	JWTIssuer      string
+	JWTAudience    string
+
+	RolesFile   string
+	DefaultRole string
+
+	TenantsFile        string
+	DefaultTenant      string
+	DefaultTimezone    string
+	DefaultStatusKey   string
+	DefaultStatusValue string
+
+	AuditLogFile string
 }
 
 // LoadConfig loads configuration from environment variables
@@ -40,6 +87,52 @@ func LoadConfig() *Config {
 		TurvoOAuthScope:        getEnv("TURVO_OAUTH_SCOPE", ""),
 		TurvoOAuthType:         getEnv("TURVO_OAUTH_TYPE", ""),
 		TurvoXApiKey:           getEnv("TURVO_X_API_KEY", ""),
+
+		EDIPartnersFile:       getEnv("EDI_PARTNERS_FILE", ""),
+		EDIControlNumbersFile: getEnv("EDI_CONTROL_NUMBERS_FILE", ""),
+		EDIOutboxFile:         getEnv("EDI_OUTBOX_FILE", ""),
+
+		BOLTemplatesFile:  getEnv("BOL_TEMPLATES_FILE", ""),
+		RateConBrokersFile: getEnv("RATECON_BROKERS_FILE", ""),
+
+		FSCSchedulesFile: getEnv("FSC_SCHEDULES_FILE", ""),
+		DieselPricesFile: getEnv("DIESEL_PRICES_FILE", ""),
+
+		AccessorialsFile: getEnv("ACCESSORIALS_FILE", ""),
+		TurvoCodesFile:   getEnv("TURVO_CODES_FILE", ""),
+
+		ZipCodesFile: getEnv("ZIP_CODES_FILE", ""),
+
+		CarrierVettingProvider: getEnv("CARRIER_VETTING_PROVIDER", "file"),
+		CarrierComplianceFile:  getEnv("CARRIER_COMPLIANCE_FILE", ""),
+		VettingOverrideUsers:   getEnv("VETTING_OVERRIDE_USERS", ""),
+
+		WebhookSubscriptionsFile:  getEnv("WEBHOOK_SUBSCRIPTIONS_FILE", ""),
+		WebhookDeadLettersFile:    getEnv("WEBHOOK_DEAD_LETTERS_FILE", ""),
+		WebhookQueueFile:          getEnv("WEBHOOK_QUEUE_FILE", ""),
+		WebhookMaxAttempts:        getEnvInt("WEBHOOK_MAX_ATTEMPTS", 6),
+		WebhookRetryBaseSeconds:   getEnvInt("WEBHOOK_RETRY_BASE_SECONDS", 5),
+		WebhookPollIntervalSeconds: getEnvInt("WEBHOOK_POLL_INTERVAL_SECONDS", 0),
+
+		TurvoWebhookSecret: getEnv("TURVO_WEBHOOK_SECRET", ""),
+
+		AuthDisabled:   getEnv("AUTH_DISABLED", "") == "true",
+		APIKeysFile:    getEnv("API_KEYS_FILE", ""),
+		JWTHS256Secret: getEnv("JWT_HS256_SECRET", ""),
+		JWTJWKSFile:    getEnv("JWT_JWKS_FILE", ""),
+		JWTIssuer:      getEnv("JWT_ISSUER", ""),
+		JWTAudience:    getEnv("JWT_AUDIENCE", ""),
+
+		RolesFile:   getEnv("ROLES_FILE", ""),
+		DefaultRole: getEnv("DEFAULT_ROLE", "viewer"),
+
+		TenantsFile:        getEnv("TENANTS_FILE", ""),
+		DefaultTenant:      getEnv("DEFAULT_TENANT", ""),
+		DefaultTimezone:    getEnv("DEFAULT_TIMEZONE", ""),
+		DefaultStatusKey:   getEnv("DEFAULT_STATUS_KEY", "2102"),
+		DefaultStatusValue: getEnv("DEFAULT_STATUS_VALUE", "Covered"),
+
+		AuditLogFile: getEnv("AUDIT_LOG_FILE", ""),
 	}
 	
 	// Debug: Log the loaded config (without sensitive data)
@@ -58,4 +151,12 @@ func getEnv(key, fallback string) string {
 		return value
 	}
 	return fallback
-} 
\ No newline at end of file
+}
+
+// getEnvInt gets an integer environment variable with fallback
+func getEnvInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
\ No newline at end of file
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// getBOL renders the bill of lading PDF for a load
+func getBOL(c *gin.Context, turvoService *services.TurvoService, loadStore *services.LoadStore, documentService *services.DocumentService) {
+	load, _, err := resolveLoad(turvoService, loadStore, c.Param("id"))
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to resolve load for BOL: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch shipment from Turvo: " + err.Error(),
+		})
+		return
+	}
+
+	pdf, err := documentService.RenderBOL(load, c.Query("template"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to render BOL: " + err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", "BOL-"+c.Param("id")+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// getRateConfirmation renders the carrier rate confirmation PDF for a load
+func getRateConfirmation(c *gin.Context, turvoService *services.TurvoService, loadStore *services.LoadStore, documentService *services.DocumentService) {
+	load, _, err := resolveLoad(turvoService, loadStore, c.Param("id"))
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to resolve load for rate confirmation: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch shipment from Turvo: " + err.Error(),
+		})
+		return
+	}
+
+	pdf, err := documentService.RenderRateConfirmation(load, c.Query("broker"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to render rate confirmation: " + err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", "RateCon-"+c.Param("id")+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// recordRateConfirmationSent records when the rate confirmation first went
+// out to the carrier, on the Turvo carrier order and on the stored copy of
+// loads created through this API. Later sends keep the first time.
+func recordRateConfirmationSent(c *gin.Context, tenant *services.TenantServices, webhookService *services.WebhookService) {
+	turvoService, loadStore := tenant.Turvo, tenant.Loads
+
+	load, shipment, err := resolveLoad(turvoService, loadStore, c.Param("id"))
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to resolve load for rate confirmation: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch shipment from Turvo: " + err.Error(),
+		})
+		return
+	}
+	if len(shipment.CarrierOrder) > 0 && !services.ConfirmationSentFromTurvo(shipment.CarrierOrder[0], turvoService.Codes()).IsZero() {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    load,
+			"message": "Rate confirmation was already recorded as sent",
+		})
+		return
+	}
+
+	// A time recorded before sends were written to Turvo is kept as the first send
+	sentAt := load.Carrier.ConfirmationSentTime
+	if sentAt.IsZero() {
+		sentAt = time.Now()
+	}
+	if err := turvoService.RecordConfirmationSent(shipment, sentAt); err != nil {
+		fmt.Printf("DEBUG: Failed to record rate confirmation send: %v\n", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNoCarrierOrder) || errors.Is(err, services.ErrNoConfirmationSentCode) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   "Failed to record rate confirmation in Turvo: " + err.Error(),
+		})
+		return
+	}
+
+	load.Carrier.ConfirmationSentTime = sentAt
+	if _, stored := loadStore.Get(shipment.ShipmentID); stored {
+		loadStore.Save(load)
+	}
+	webhookService.Publish(services.LoadEvent(tenant.Tenant.ID, types.EventLoadUpdated, load))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    load,
+		"message": "Rate confirmation recorded as sent in Turvo",
+	})
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// getEDIPartners returns the configured EDI trading partners
+func getEDIPartners(c *gin.Context, ediService *services.EDIService) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ediService.Partners(),
+	})
+}
+
+// generateEDI990 builds a 990 accept or decline response for a load
+func generateEDI990(c *gin.Context, turvoService *services.TurvoService, loadStore *services.LoadStore, ediService *services.EDIService) {
+	var req types.EDI990Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	load, _, err := resolveLoad(turvoService, loadStore, c.Param("id"))
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to resolve load for 990: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch shipment from Turvo: " + err.Error(),
+		})
+		return
+	}
+
+	message, err := ediService.Generate990(req.PartnerID, load, req.Accept, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to generate 990: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    message,
+	})
+}
+
+// generateEDI214 refreshes a shipment from Turvo and builds 214 status
+// messages for every tracked change since the partner's previous 214s
+func generateEDI214(c *gin.Context, turvoService *services.TurvoService, loadStore *services.LoadStore, tracker *services.ShipmentTracker, ediService *services.EDIService) {
+	var req types.EDI214Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := ediService.Partner(req.PartnerID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	load, shipment, err := resolveLoad(turvoService, loadStore, c.Param("id"))
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to resolve load for 214: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch shipment from Turvo: " + err.Error(),
+		})
+		return
+	}
+
+	messages, changes, err := ediService.Report214(req.PartnerID, load, *shipment, tracker)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to generate 214: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    messages,
+		"changes": changes,
+	})
+}
+
+// getEDI214s lists the 214s sent for a load, optionally for one partner
+func getEDI214s(c *gin.Context, ediService *services.EDIService) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ediService.Messages(c.Param("id"), c.Query("partner"), "214"),
+	})
+}
+
+// reportEDIStatus generates 214s for a changed shipment to every partner
+// whose tender of it was accepted. Turvo callbacks and polling call it, so
+// partners receive status messages as the shipment moves.
+func reportEDIStatus(tenant *services.TenantServices, shipment types.TurvoShipment, auditLog *services.AuditLog) {
+	partners := tenant.EDI.TenderedPartners(shipment.ShipmentID)
+	if len(partners) == 0 {
+		return
+	}
+	load, err := loadFromShipment(tenant.Turvo, tenant.Loads, shipment)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to build load %s for 214s: %v\n", shipment.ShipmentID, err)
+		return
+	}
+
+	for _, partnerID := range partners {
+		messages, _, err := tenant.EDI.Report214(partnerID, load, shipment, tenant.Tracker)
+		entry := types.AuditEntry{
+			Actor:      "turvo",
+			TenantID:   tenant.Tenant.ID,
+			Action:     types.AuditEDI214,
+			Method:     http.MethodPost,
+			Endpoint:   "/api/loads/" + shipment.ShipmentID + "/edi/214",
+			ResourceID: shipment.ShipmentID,
+			Outcome:    types.AuditOutcomeSuccess,
+			StatusCode: http.StatusOK,
+		}
+		entry.Request, _ = json.Marshal(gin.H{"partnerId": partnerID})
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to generate 214s for %s to %s: %v\n", shipment.ShipmentID, partnerID, err)
+			entry.Outcome = types.AuditOutcomeFailed
+			entry.StatusCode = http.StatusInternalServerError
+			entry.Error = err.Error()
+		} else if len(messages) == 0 {
+			continue
+		}
+		auditLog.Record(entry)
+	}
+}
+
+// previewEDI210 builds a 210 freight invoice for review without consuming a control number
+func previewEDI210(c *gin.Context, turvoService *services.TurvoService, loadStore *services.LoadStore, ediService *services.EDIService) {
+	invoice, ok := buildEDI210(c, turvoService, loadStore, ediService, true)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    invoice,
+	})
+}
+
+// downloadEDI210 builds a 210 freight invoice and returns it as an X12 file.
+// Invoices that do not reconcile with Turvo are refused.
+func downloadEDI210(c *gin.Context, turvoService *services.TurvoService, loadStore *services.LoadStore, ediService *services.EDIService) {
+	invoice, ok := buildEDI210(c, turvoService, loadStore, ediService, false)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("210-%s.edi", invoice.InvoiceNumber)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/edi-x12", []byte(invoice.Message.Content))
+}
+
+// buildEDI210 resolves the load and generates its 210, writing an error response on failure
+func buildEDI210(c *gin.Context, turvoService *services.TurvoService, loadStore *services.LoadStore, ediService *services.EDIService, preview bool) (*types.EDIInvoice, bool) {
+	partnerID := c.Query("partner")
+	if partnerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "partner query parameter is required",
+		})
+		return nil, false
+	}
+
+	load, shipment, err := resolveLoad(turvoService, loadStore, c.Param("id"))
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to resolve load for 210: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch shipment from Turvo: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	if !preview {
+		// Validate before consuming a control number
+		check, err := ediService.Generate210(partnerID, load, *shipment, turvoService.Accessorials(), turvoService.Codes(), true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Failed to generate 210: " + err.Error(),
+			})
+			return nil, false
+		}
+		if !check.Valid {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"error":   "Invoice does not reconcile with Turvo customer order costs",
+				"issues":  check.Issues,
+			})
+			return nil, false
+		}
+	}
+
+	invoice, err := ediService.Generate210(partnerID, load, *shipment, turvoService.Accessorials(), turvoService.Codes(), preview)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to generate 210: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	return invoice, true
+}
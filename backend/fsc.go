@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+)
+
+// getFSC previews the fuel surcharge for a customer on a date (defaults to today)
+func getFSC(c *gin.Context, fscService *services.FuelSurchargeService) {
+	date, err := fscDate(c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	quote, err := fscService.Quote(c.Query("customer"), date)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    quote,
+	})
+}
+
+// fscDate parses a YYYY-MM-DD date, defaulting to today when empty
+func fscDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return date, nil
+}
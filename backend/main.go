@@ -14,13 +14,49 @@ import (
 )
 
 func main() {
-	r := gin.Default()
+	// Log requests with stream tokens redacted, and recover from panics
+	r := gin.New()
+	r.Use(requestLogger(), gin.Recovery())
 
 	// Load configuration
 	cfg := config.LoadConfig()
 
-	// Initialize Turvo service
-	turvoService := services.NewTurvoService(cfg)
+	// Load the zip5 dataset used to fill in cities, states and positions
+	if cfg.ZipCodesFile != "" {
+		if err := services.LoadZipCodes(cfg.ZipCodesFile); err != nil {
+			fmt.Printf("DEBUG: %v\n", err)
+		}
+	}
+
+	// Initialize each tenant's Turvo service, load state and callback receiver
+	tenants := services.NewTenantRegistry(cfg)
+
+	// Authenticate API callers by API key or JWT and authorize them by role
+	authService := services.NewAuthService(cfg)
+	access := services.NewAccessControl(cfg)
+	allow := func(permission string) gin.HandlerFunc {
+		return requirePermission(access, permission)
+	}
+
+	// Record every write, and the Turvo calls it makes, in the audit log
+	auditLog := services.NewAuditLog(cfg)
+	audited := func(action string) gin.HandlerFunc {
+		return audit(auditLog, action)
+	}
+
+	// Initialize webhook delivery and the live load stream
+	webhookService := services.NewWebhookService(cfg)
+	go webhookService.Run(nil)
+	loadStream := services.NewLoadStream()
+	webhookService.Listen(loadStream.Publish)
+	for _, tenant := range tenants.All() {
+		listenForTurvoEvents(tenant, webhookService, auditLog)
+
+		// Poll Turvo for changes made outside this API when an interval is configured
+		if cfg.WebhookPollIntervalSeconds > 0 {
+			go pollTurvoChanges(tenant, webhookService, auditLog, time.Duration(cfg.WebhookPollIntervalSeconds)*time.Second)
+		}
+	}
 
 	// Configure CORS
 	corsConfig := cors.DefaultConfig()
@@ -31,39 +67,143 @@ func main() {
 		"https://*.amplifyapp.net",  // Alternative Amplify domain
 	}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "Last-Event-ID"}
 	corsConfig.AllowCredentials = true
 	r.Use(cors.New(corsConfig))
 
+	// Shipment event callbacks from Turvo are authenticated by their signature.
+	// Each tenant's Turvo account posts to its own path; the bare path is the default tenant's.
+	r.POST("/api/webhooks/turvo", func(c *gin.Context) {
+		receiveTenantTurvoWebhook(c, tenants)
+	})
+	r.POST("/api/webhooks/turvo/:tenant", func(c *gin.Context) {
+		receiveTenantTurvoWebhook(c, tenants)
+	})
+
 	// API routes
-	api := r.Group("/api")
+	// Rejected writes are audited ahead of authentication so 401s are recorded too
+	api := r.Group("/api", auditRejections(auditLog), requireAuth(authService), resolveTenant(tenants), maskRates(access))
 	{
+		// The authenticated caller
+		api.GET("/me", func(c *gin.Context) {
+			getIdentity(c, access)
+		})
+
 		// Get all loads
-		api.GET("/loads", func(c *gin.Context) {
-			getLoads(c, turvoService)
+		api.GET("/loads", allow(types.PermLoadsRead), func(c *gin.Context) {
+			getLoads(c, tenantFor(c), webhookService)
 		})
 		
+		// Stream live load events
+		api.GET("/loads/stream", allow(types.PermLoadsRead), func(c *gin.Context) {
+			streamLoads(c, loadStream, access)
+		})
+
 		// Create a new load
-		api.POST("/loads", func(c *gin.Context) {
-			createLoad(c, turvoService)
+		api.POST("/loads", audited(types.AuditLoadCreate), allow(types.PermLoadsWrite), restrictFields(access), func(c *gin.Context) {
+			createLoad(c, tenantFor(c), webhookService)
+		})
+
+		// Price a load without creating it
+		api.POST("/pricing", allow(types.PermRatesRead), func(c *gin.Context) {
+			tenant := tenantFor(c)
+			previewPricing(c, tenant.FSC, tenant.Turvo.Accessorials())
+		})
+
+		// Preview the fuel surcharge for a customer and date
+		api.GET("/fsc", allow(types.PermLoadsRead), func(c *gin.Context) {
+			getFSC(c, tenantFor(c).FSC)
+		})
+
+		// EDI trading partners and outbound documents
+		api.GET("/edi/partners", allow(types.PermLoadsRead), func(c *gin.Context) {
+			getEDIPartners(c, tenantFor(c).EDI)
+		})
+		api.POST("/loads/:id/edi/990", audited(types.AuditEDI990), allow(types.PermEDISend), func(c *gin.Context) {
+			tenant := tenantFor(c)
+			generateEDI990(c, tenant.Turvo, tenant.Loads, tenant.EDI)
+		})
+		api.POST("/loads/:id/edi/214", audited(types.AuditEDI214), allow(types.PermEDISend), func(c *gin.Context) {
+			tenant := tenantFor(c)
+			generateEDI214(c, tenant.Turvo, tenant.Loads, tenant.Tracker, tenant.EDI)
+		})
+		api.GET("/loads/:id/edi/214", allow(types.PermLoadsRead), func(c *gin.Context) {
+			getEDI214s(c, tenantFor(c).EDI)
+		})
+		api.GET("/loads/:id/edi/210", allow(types.PermLoadsRead), func(c *gin.Context) {
+			tenant := tenantFor(c)
+			previewEDI210(c, tenant.Turvo, tenant.Loads, tenant.EDI)
+		})
+		api.GET("/loads/:id/edi/210/download", audited(types.AuditEDI210), allow(types.PermEDISend), func(c *gin.Context) {
+			tenant := tenantFor(c)
+			downloadEDI210(c, tenant.Turvo, tenant.Loads, tenant.EDI)
+		})
+
+		// Vet a carrier and assign a carrier, drivers and equipment to a load
+		api.POST("/carriers/vet", allow(types.PermLoadsDispatch), func(c *gin.Context) {
+			vetCarrier(c, tenantFor(c).Vetting)
+		})
+		api.POST("/loads/:id/dispatch", audited(types.AuditLoadDispatch), allow(types.PermLoadsDispatch), restrictFields(access), func(c *gin.Context) {
+			dispatchLoad(c, tenantFor(c), webhookService, access)
+		})
+
+		// Outbound webhook subscriptions and failed deliveries
+		api.GET("/webhooks/subscriptions", allow(types.PermWebhooksManage), func(c *gin.Context) {
+			getWebhookSubscriptions(c, webhookService)
+		})
+		api.POST("/webhooks/subscriptions", audited(types.AuditWebhookSubscribe), allow(types.PermWebhooksManage), func(c *gin.Context) {
+			createWebhookSubscription(c, webhookService)
+		})
+		api.DELETE("/webhooks/subscriptions/:id", audited(types.AuditWebhookUnsubscribe), allow(types.PermWebhooksManage), func(c *gin.Context) {
+			deleteWebhookSubscription(c, webhookService)
+		})
+		api.GET("/webhooks/dead-letters", allow(types.PermWebhooksManage), func(c *gin.Context) {
+			getWebhookDeadLetters(c, webhookService)
+		})
+		api.POST("/webhooks/dead-letters/:id/replay", audited(types.AuditWebhookReplay), allow(types.PermWebhooksManage), func(c *gin.Context) {
+			replayWebhookDeadLetter(c, webhookService)
+		})
+
+		// Audit log of writes
+		api.GET("/audit", allow(types.PermAuditRead), func(c *gin.Context) {
+			getAuditLog(c, auditLog)
+		})
+
+		// Shipping documents
+		api.GET("/loads/:id/bol.pdf", allow(types.PermLoadsRead), func(c *gin.Context) {
+			tenant := tenantFor(c)
+			getBOL(c, tenant.Turvo, tenant.Loads, tenant.Documents)
+		})
+		api.GET("/loads/:id/ratecon.pdf", allow(types.PermRatesRead), func(c *gin.Context) {
+			tenant := tenantFor(c)
+			getRateConfirmation(c, tenant.Turvo, tenant.Loads, tenant.Documents)
+		})
+		api.POST("/loads/:id/ratecon/sent", audited(types.AuditRateConSent), allow(types.PermLoadsDispatch), func(c *gin.Context) {
+			recordRateConfirmationSent(c, tenantFor(c), webhookService)
 		})
 
 		// Get shipment details
-		api.GET("/shipments/:id", func(c *gin.Context) {
-			getShipmentDetails(c, turvoService)
+		api.GET("/shipments/:id", allow(types.PermLoadsRead), func(c *gin.Context) {
+			getShipmentDetails(c, tenantFor(c).Turvo)
 		})
 	}
 
-	// Health check
+	// Health check, degraded while audit entries cannot be written
 	r.GET("/health", func(c *gin.Context) {
+		if err := auditLog.Healthy(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "degraded", "error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
 	r.Run(":8080")
 }
 
-// getLoads returns all loads from Turvo
-func getLoads(c *gin.Context, turvoService *services.TurvoService) {
+// getLoads returns all of a tenant's loads from Turvo, publishing webhook
+// events for any that changed since they were last seen
+func getLoads(c *gin.Context, tenant *services.TenantServices, webhookService *services.WebhookService) {
+	turvoService := tenant.Turvo
 	fmt.Printf("DEBUG: Fetching loads from Turvo\n")
 	
 	// Get page parameter
@@ -89,7 +229,8 @@ func getLoads(c *gin.Context, turvoService *services.TurvoService) {
 	// Convert Turvo shipments to Drumkit format
 	loads := []types.Load{}
 	for _, shipment := range turvoShipments {
-		load := convertTurvoToDrumkit(shipment)
+		load := convertTurvoToDrumkit(shipment, turvoService)
+		webhookService.ObserveShipment(tenant.Tenant.ID, shipment, load)
 		loads = append(loads, load)
 	}
 
@@ -138,28 +279,37 @@ func getShipmentDetails(c *gin.Context, turvoService *services.TurvoService) {
 }
 
 // convertTurvoToDrumkit converts a Turvo shipment to Drumkit load format
-func convertTurvoToDrumkit(shipment types.TurvoShipment) types.Load {
+func convertTurvoToDrumkit(shipment types.TurvoShipment, turvoService *services.TurvoService) types.Load {
+	codes, accessorials := turvoService.Codes(), turvoService.Accessorials()
 	// Extract pickup and delivery locations from global route
 	var pickup, delivery *types.TurvoGlobalRoute
-	for _, route := range shipment.GlobalRoute {
+	for i := range shipment.GlobalRoute {
+		route := &shipment.GlobalRoute[i]
 		if route.StopType.Key == "1500" { // Pickup
-			pickup = &route
+			pickup = route
 		} else if route.StopType.Key == "1501" { // Delivery
-			delivery = &route
+			delivery = route
 		}
 	}
 
 	// Extract customer info from customer order
 	var customerName string
+	customerID := "N/A"
 	if len(shipment.CustomerOrder) > 0 {
 		customerName = shipment.CustomerOrder[0].Customer.Name
+		if id := shipment.CustomerOrder[0].Customer.ID; id > 0 {
+			customerID = strconv.Itoa(id)
+		}
 	}
 
 	// Extract total weight from customer order items
 	var totalWeight float64
-	if len(shipment.CustomerOrder) > 0 && len(shipment.CustomerOrder[0].Items) > 0 {
-		// Sum up all item weights or use a default
-		totalWeight = 5000.0 // Default weight
+	var items []types.TurvoItem
+	if len(shipment.CustomerOrder) > 0 {
+		items = shipment.CustomerOrder[0].Items
+	}
+	for _, item := range items {
+		totalWeight += item.GrossWeight
 	}
 
 	load := types.Load{
@@ -167,7 +317,7 @@ func convertTurvoToDrumkit(shipment types.TurvoShipment) types.Load {
 		FreightLoadID:     shipment.ShipmentID,
 		Status:            shipment.Status.Code.Value,
 		Customer: types.Customer{
-			ExternalTMSId: fmt.Sprintf("CUST-%s", shipment.ShipmentID),
+			ExternalTMSId: customerID,
 			Name:          customerName,
 			AddressLine1:  "N/A",
 			City:          "N/A",
@@ -225,7 +375,7 @@ func convertTurvoToDrumkit(shipment types.TurvoShipment) types.Load {
 			BillableWeight:     totalWeight,
 			PONums:             "N/A",
 			Operator:           "N/A",
-			RouteMiles:         0.0,
+			RouteMiles:         routeMiles(delivery),
 			LiftgatePickup:     false,
 			LiftgateDelivery:   false,
 			InsidePickup:       false,
@@ -242,9 +392,100 @@ func convertTurvoToDrumkit(shipment types.TurvoShipment) types.Load {
 		},
 	}
 
+	// Read appointment windows back from the stops
+	if pickup != nil {
+		appt := services.AppointmentFromTurvo(*pickup, codes)
+		load.Pickup.ApptTime = appt.From
+		load.Pickup.ApptWindowStart = appt.From
+		load.Pickup.ApptWindowEnd = appt.To
+		load.Pickup.SchedulingType = appt.Scheduling
+		load.Pickup.ApptFlexMinutes = appt.FlexSeconds / 60
+	}
+	if delivery != nil {
+		appt := services.AppointmentFromTurvo(*delivery, codes)
+		load.Consignee.ApptTime = appt.From
+		load.Consignee.ApptWindowStart = appt.From
+		load.Consignee.ApptWindowEnd = appt.To
+		load.Consignee.SchedulingType = appt.Scheduling
+		load.Consignee.ApptFlexMinutes = appt.FlexSeconds / 60
+	}
+
+	// Read the carrier assigned on the carrier order
+	if len(shipment.CarrierOrder) > 0 {
+		order := shipment.CarrierOrder[0]
+		load.Carrier.Name = order.Carrier.Name
+		if order.Carrier.ID > 0 {
+			load.Carrier.ExternalTMSId = strconv.Itoa(order.Carrier.ID)
+		}
+		load.Carrier.ExternalTMSTruckID = order.TractorNumber
+		load.Carrier.ExternalTMSTrailerID = order.TrailerNumber
+		load.Carrier.ConfirmationSentTime = services.ConfirmationSentFromTurvo(order, codes)
+	}
+
+	// Read the transportation mode and service type
+	load.Mode, load.ServiceType = services.ModeFromTurvo(shipment, codes)
+
+	// Read commodities back from the customer order items
+	load.Commodities = services.CommoditiesFromTurvo(items, codes)
+	services.ApplyCommodities(&load.Specifications, load.Commodities)
+
+	// Read equipment and the reefer temperature range
+	load.Equipment = services.EquipmentFromTurvo(shipment, &load.Specifications, codes)
+
+	// Map stop services and accessorial charges back to the specification flags
+	var lineItems []types.TurvoLineItem
+	if len(shipment.CustomerOrder) > 0 {
+		lineItems = shipment.CustomerOrder[0].Costs.LineItem
+	}
+	if pickup != nil {
+		accessorials.ApplyFromTurvo(&load.Specifications, types.AccessorialStopPickup, pickup.Services, lineItems)
+	}
+	if delivery != nil {
+		accessorials.ApplyFromTurvo(&load.Specifications, types.AccessorialStopDelivery, delivery.Services, lineItems)
+	}
+
+	// Fall back to an offline estimate when Turvo didn't return the route distance
+	if load.Specifications.RouteMiles <= 0 {
+		if miles, err := services.LoadRouteMiles(load); err == nil {
+			load.Specifications.RouteMiles = miles
+		}
+	}
+
 	return load
 }
 
+// routeMiles returns the cumulative route distance Turvo reports at the delivery stop
+func routeMiles(delivery *types.TurvoGlobalRoute) float64 {
+	if delivery == nil {
+		return 0
+	}
+	return float64(delivery.Distance.Value)
+}
+
+// resolveLoad fetches a shipment from Turvo and returns its Drumkit view
+func resolveLoad(turvoService *services.TurvoService, loadStore *services.LoadStore, shipmentID string) (types.Load, *types.TurvoShipment, error) {
+	shipment, err := turvoService.GetShipment(shipmentID)
+	if err != nil {
+		return types.Load{}, nil, err
+	}
+
+	load, err := loadFromShipment(turvoService, loadStore, *shipment)
+	if err != nil {
+		return types.Load{}, nil, err
+	}
+	return load, shipment, nil
+}
+
+// loadFromShipment converts a Turvo shipment to its Drumkit view, filling the
+// fields Turvo does not return from the stored copy
+func loadFromShipment(turvoService *services.TurvoService, loadStore *services.LoadStore, shipment types.TurvoShipment) (types.Load, error) {
+	load := convertTurvoToDrumkit(shipment, turvoService)
+	if stored, ok := loadStore.Get(shipment.ShipmentID); ok {
+		return services.MergeStoredLoad(load, stored)
+	}
+	return load, nil
+}
+
 // getPickupField safely extracts a field from pickup route
 func getPickupField(pickup *types.TurvoGlobalRoute, extractor func(*types.TurvoGlobalRoute) string) string {
 	if pickup != nil {
@@ -261,8 +502,9 @@ func getDeliveryField(delivery *types.TurvoGlobalRoute, extractor func(*types.Tu
 	return "N/A"
 }
 
-// createLoad creates a new load in Turvo
-func createLoad(c *gin.Context, turvoService *services.TurvoService) {
+// createLoad creates a new load in the tenant's Turvo account
+func createLoad(c *gin.Context, tenant *services.TenantServices, webhookService *services.WebhookService) {
+	turvoService, loadStore := tenant.Turvo, tenant.Loads
 	var req types.CreateLoadRequest
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -288,11 +530,84 @@ func createLoad(c *gin.Context, turvoService *services.TurvoService) {
 		Carrier:           req.Carrier,
 		RateData:          req.RateData,
 		Specifications:    req.Specifications,
+		Mode:              req.Mode,
+		ServiceType:       req.ServiceType,
+		Equipment:         req.Equipment,
+		Commodities:       req.Commodities,
+	}
+
+	// Standardize addresses, and take totals from the commodity list when one
+	// is given, so validation sees what will be sent to Turvo
+	services.NormalizeLoadAddresses(&newLoad)
+	services.ApplyCommodities(&newLoad.Specifications, newLoad.Commodities)
+
+	// Record the canonical mode and service type; an unknown one is reported by ValidateLoad
+	if mode, serviceType, err := services.ResolveMode(newLoad); err == nil {
+		newLoad.Mode, newLoad.ServiceType = mode, serviceType
+	}
+
+	// Fill the fuel surcharge from the customer's schedule when none was entered,
+	// so validation checks the rates that will be billed
+	newLoad, fsc, err := tenant.FSC.ApplyToLoad(newLoad)
+	if err != nil {
+		fmt.Printf("DEBUG: Fuel surcharge lookup failed: %v\n", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error":   "Failed to compute fuel surcharge: " + err.Error(),
+		})
+		return
+	}
+
+	if errs := services.ValidateLoad(newLoad); len(errs) > 0 {
+		fmt.Printf("DEBUG: Load validation failed: %v\n", errs)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error":   "Invalid load: " + errs.Error(),
+			"errors":  errs,
+		})
+		return
+	}
+
+	// Estimate route miles from the stop zip codes when the client didn't provide them
+	if newLoad.Specifications.RouteMiles <= 0 {
+		if miles, err := services.LoadRouteMiles(newLoad); err == nil {
+			newLoad.Specifications.RouteMiles = miles
+		} else {
+			fmt.Printf("DEBUG: Route mileage unavailable: %v\n", err)
+		}
+	}
+
+	// Record the resolved stop timezones so reads and documents agree with Turvo
+	newLoad.Pickup.Timezone = turvoService.StopTimezone(newLoad.Pickup.Timezone, newLoad.Pickup.Zipcode, newLoad.Pickup.State, newLoad.Pickup.Country).String()
+	newLoad.Consignee.Timezone = turvoService.StopTimezone(newLoad.Consignee.Timezone, newLoad.Consignee.Zipcode, newLoad.Consignee.State, newLoad.Consignee.Country).String()
+
+	// Compute totals and profit rather than trusting the client's figures
+	pricing, err := services.PriceLoad(newLoad.RateData, newLoad.Specifications.RouteMiles)
+	if err != nil {
+		fmt.Printf("DEBUG: Pricing failed: %v\n", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error":   "Invalid rate data: " + err.Error(),
+		})
+		return
+	}
+	services.AddAccessorials(pricing, turvoService.Accessorials().Charges(newLoad.Specifications))
+	newLoad.RateData = services.ApplyPricing(newLoad.RateData, pricing)
+
+	// Reject loads that need a Turvo code this account has not configured
+	if errs := turvoService.Codes().Check(newLoad, pricing); len(errs) > 0 {
+		fmt.Printf("DEBUG: Load needs unconfigured Turvo codes: %v\n", errs)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error":   "Invalid load: " + errs.Error(),
+			"errors":  errs,
+		})
+		return
 	}
 
 	// Create shipment in Turvo
 	fmt.Printf("DEBUG: Calling Turvo service to create shipment\n")
-	turvoResponse, err := turvoService.CreateShipment(newLoad)
+	turvoResponse, err := turvoService.CreateShipment(newLoad, pricing)
 	if err != nil {
 		fmt.Printf("DEBUG: Turvo service error: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -304,10 +619,14 @@ func createLoad(c *gin.Context, turvoService *services.TurvoService) {
 
 	// Update load with Turvo shipment ID
 	newLoad.ExternalTMSLoadID = turvoResponse.ShipmentID
+	loadStore.Save(newLoad)
+	webhookService.Publish(services.LoadEvent(tenant.Tenant.ID, types.EventLoadCreated, newLoad))
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"data":    newLoad,
+		"pricing": pricing,
+		"fsc":     fsc,
 		"message": "Load created successfully in Turvo",
 		"turvo_response": turvoResponse,
 	})
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// previewPricing computes customer and carrier totals for rate data without creating a load.
+// It fills the fuel surcharge from the customer's schedule when none is given and adds
+// accessorial charges when specifications are sent.
+func previewPricing(c *gin.Context, fscService *services.FuelSurchargeService, accessorials *services.AccessorialTable) {
+	var req types.PricingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	date, err := fscDate(req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	fsc, err := fscService.ApplyToRates(&req.RateData, req.Customer, date)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error":   "Failed to compute fuel surcharge: " + err.Error(),
+		})
+		return
+	}
+
+	pricing, err := services.PriceLoad(req.RateData, req.RouteMiles)
+	if pricing != nil && req.Specifications != nil {
+		services.AddAccessorials(pricing, accessorials.Charges(*req.Specifications))
+	}
+	if err != nil {
+		response := gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"fsc":     fsc,
+		}
+		// Over-max pricing is still returned so the user can see by how much
+		if errors.Is(err, services.ErrCarrierOverMaxRate) {
+			response["data"] = pricing
+		}
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    pricing,
+		"fsc":     fsc,
+	})
+}
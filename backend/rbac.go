@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// requirePermission answers 403 unless the caller's roles grant permission
+func requirePermission(access *services.AccessControl, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, _ := currentIdentity(c)
+		if !access.Can(identity, permission) {
+			fmt.Printf("DEBUG: %s denied %s on %s %s\n", identity.User(), permission, c.Request.Method, c.Request.URL.Path)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Forbidden: requires the " + permission + " permission",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// restrictFields answers 403 when the request body sets fields the caller
+// may not write, such as customer rates without rates:write
+func restrictFields(access *services.AccessControl) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Failed to read request body: " + err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		identity, _ := currentIdentity(c)
+		if denied := access.RestrictedFields(identity, body); len(denied) > 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Forbidden: not permitted to set " + strings.Join(denied, ", "),
+				"fields":  denied,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// maskRates strips carrier cost and margin from JSON responses for callers
+// without rates:read. The load stream masks its own events.
+func maskRates(access *services.AccessControl) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, _ := currentIdentity(c)
+		if access.Can(identity, types.PermRatesRead) || c.FullPath() == streamRoute {
+			c.Next()
+			return
+		}
+
+		writer := &maskingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if strings.HasPrefix(writer.Header().Get("Content-Type"), "application/json") {
+			masked, err := services.MaskRates(body)
+			if err != nil {
+				fmt.Printf("DEBUG: Failed to mask response: %v\n", err)
+				c.Writer.WriteHeader(http.StatusInternalServerError)
+				c.Writer.Write([]byte(`{"success":false,"error":"Failed to prepare response"}`))
+				return
+			}
+			body = masked
+		}
+		c.Writer.Write(body)
+	}
+}
+
+// maskingWriter buffers a response body so it can be masked before sending
+type maskingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write buffers the body
+func (w *maskingWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+// WriteString buffers the body
+func (w *maskingWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// canSeeRates reports whether the caller may see carrier cost and margin
+func canSeeRates(c *gin.Context, access *services.AccessControl) bool {
+	identity, _ := currentIdentity(c)
+	return access.Can(identity, types.PermRatesRead)
+}
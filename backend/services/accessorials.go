@@ -0,0 +1,195 @@
+package services
+
+import (
+	"fmt"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// AccessorialTable maps Specifications accessorial flags to Turvo codes
+type AccessorialTable struct {
+	codes []types.AccessorialCode
+}
+
+// NewAccessorialTable creates the accessorial table from the configured file.
+// Turvo service and charge codes differ between accounts, so without a file
+// no services are sent and nothing is billed for accessorial flags.
+func NewAccessorialTable(cfg *config.Config) *AccessorialTable {
+	table := &AccessorialTable{codes: []types.AccessorialCode{}}
+	if cfg.AccessorialsFile == "" {
+		fmt.Printf("DEBUG: ACCESSORIALS_FILE is not set, accessorial flags are not sent to Turvo or billed\n")
+		return table
+	}
+
+	var codes []types.AccessorialCode
+	if err := readJSONFile(cfg.AccessorialsFile, &codes); err != nil {
+		fmt.Printf("DEBUG: Failed to load accessorials from %s, accessorial flags are not sent to Turvo or billed: %v\n", cfg.AccessorialsFile, err)
+		return table
+	}
+	for _, code := range codes {
+		if err := validateAccessorialCode(code); err != nil {
+			fmt.Printf("DEBUG: Ignoring accessorial %q: %v\n", code.Flag, err)
+			continue
+		}
+		table.codes = append(table.codes, code)
+	}
+	fmt.Printf("DEBUG: Loaded %d accessorial codes\n", len(table.codes))
+	return table
+}
+
+// StopServices returns the Turvo services for the flags set on specs that apply to the given stop
+func (t *AccessorialTable) StopServices(specs types.Specifications, stop string) []types.TurvoCode {
+	flags := accessorialFlags(&specs)
+	services := []types.TurvoCode{}
+	for _, code := range t.codes {
+		if *flags[code.Flag] && code.Service.Key != "" && appliesToStop(code.Stop, stop) {
+			services = append(services, code.Service)
+		}
+	}
+	return services
+}
+
+// Charges returns the billable accessorial charges for the flags set on specs
+func (t *AccessorialTable) Charges(specs types.Specifications) []types.AccessorialCharge {
+	flags := accessorialFlags(&specs)
+	charges := []types.AccessorialCharge{}
+	for _, code := range t.codes {
+		if *flags[code.Flag] && code.ChargeUsd > 0 {
+			charges = append(charges, types.AccessorialCharge{
+				Flag:      code.Flag,
+				Code:      code.Charge,
+				AmountUsd: roundUsd(code.ChargeUsd),
+			})
+		}
+	}
+	return charges
+}
+
+// ApplyFromTurvo sets the Specifications flags for services found on a Turvo
+// stop and for accessorial line items on the customer order
+func (t *AccessorialTable) ApplyFromTurvo(specs *types.Specifications, stop string, services []types.TurvoCode, lineItems []types.TurvoLineItem) {
+	flags := accessorialFlags(specs)
+	for _, code := range t.codes {
+		if !appliesToStop(code.Stop, stop) {
+			continue
+		}
+		for _, service := range services {
+			if code.Service.Key != "" && service.Key == code.Service.Key {
+				*flags[code.Flag] = true
+			}
+		}
+		for _, item := range lineItems {
+			if code.Charge.Key != "" && item.Code.Key == code.Charge.Key {
+				*flags[code.Flag] = true
+			}
+		}
+	}
+}
+
+// X12ChargeCode returns the EDI 210 charge code for a customer order line
+// item billed for an accessorial, matched by its Turvo charge key
+func (t *AccessorialTable) X12ChargeCode(item types.TurvoLineItem) (string, bool) {
+	for _, code := range t.codes {
+		if code.Charge.Key != "" && code.Charge.Key == item.Code.Key {
+			return accessorialX12Code(code)
+		}
+	}
+	return "", false
+}
+
+// accessorialX12Code returns the EDI 210 charge code for an accessorial code
+func accessorialX12Code(code types.AccessorialCode) (string, bool) {
+	if code.X12Code != "" {
+		return code.X12Code, true
+	}
+	x12, ok := accessorialX12Codes[code.Flag]
+	return x12, ok
+}
+
+// accessorialX12Codes are the default X12 special charge codes (element 150)
+// for accessorial flags. Flags without a standard code need an x12Code.
+var accessorialX12Codes = map[string]string{
+	"liftgatePickup":        "LFT",
+	"liftgateDelivery":      "LFT",
+	"insideDelivery":        "IDL",
+	"tarps":                 "TAR",
+	"straps":                "STR",
+	"permits":               "PMT",
+	"escorts":               "ESC",
+	"hazmat":                "HAZ",
+	"labor":                 "LBR",
+	"residentialPickup":     "RES",
+	"residentialDelivery":   "RES",
+	"limitedAccessPickup":   "LAD",
+	"limitedAccessDelivery": "LAD",
+	"deliveryNotification":  "NTF",
+}
+
+// AddAccessorials adds accessorial charges to the customer total and recomputes profit
+func AddAccessorials(pricing *types.PricingResult, charges []types.AccessorialCharge) {
+	if pricing == nil {
+		return
+	}
+	pricing.Accessorials = charges
+	pricing.AccessorialsUsd = 0
+	for _, charge := range charges {
+		pricing.AccessorialsUsd += charge.AmountUsd
+	}
+	pricing.AccessorialsUsd = roundUsd(pricing.AccessorialsUsd)
+	pricing.CustomerTotalUsd = roundUsd(pricing.CustomerLinehaulUsd + pricing.FuelSurchargeUsd + pricing.AccessorialsUsd)
+	updateProfit(pricing)
+}
+
+// accessorialFlags returns pointers to the accessorial flags on specs, keyed by JSON field name
+func accessorialFlags(specs *types.Specifications) map[string]*bool {
+	return map[string]*bool{
+		"liftgatePickup":   &specs.LiftgatePickup,
+		"liftgateDelivery": &specs.LiftgateDelivery,
+		"insidePickup":     &specs.InsidePickup,
+		"insideDelivery":   &specs.InsideDelivery,
+		"tarps":            &specs.Tarps,
+		"oversized":        &specs.Oversized,
+		"hazmat":           &specs.Hazmat,
+		"straps":           &specs.Straps,
+		"permits":          &specs.Permits,
+		"escorts":          &specs.Escorts,
+		"seal":             &specs.Seal,
+		"customBonded":     &specs.CustomBonded,
+		"labor":            &specs.Labor,
+
+		"residentialPickup":     &specs.ResidentialPickup,
+		"residentialDelivery":   &specs.ResidentialDelivery,
+		"limitedAccessPickup":   &specs.LimitedAccessPickup,
+		"limitedAccessDelivery": &specs.LimitedAccessDelivery,
+		"deliveryNotification":  &specs.DeliveryNotification,
+	}
+}
+
+// appliesToStop reports whether an accessorial configured for codeStop belongs on stop
+func appliesToStop(codeStop, stop string) bool {
+	return codeStop == stop || codeStop == types.AccessorialStopBoth
+}
+
+// validateAccessorialCode checks a configured accessorial code
+func validateAccessorialCode(code types.AccessorialCode) error {
+	var specs types.Specifications
+	if _, ok := accessorialFlags(&specs)[code.Flag]; !ok {
+		return fmt.Errorf("unknown specifications flag")
+	}
+	switch code.Stop {
+	case types.AccessorialStopPickup, types.AccessorialStopDelivery, types.AccessorialStopBoth:
+	default:
+		return fmt.Errorf("stop must be pickup, delivery or both")
+	}
+	if code.Service.Key == "" && code.Charge.Key == "" {
+		return fmt.Errorf("a Turvo service or charge key is required")
+	}
+	if code.ChargeUsd < 0 {
+		return fmt.Errorf("charge cannot be negative")
+	}
+	if code.ChargeUsd > 0 && code.Charge.Key == "" {
+		return fmt.Errorf("charge amount requires a charge code")
+	}
+	return nil
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"turvo-app/types"
+)
+
+// Countries whose addresses are validated
+const (
+	CountryUS = "US"
+	CountryCA = "CA"
+	CountryMX = "MX"
+)
+
+// countryAliases maps accepted country spellings to ISO codes
+var countryAliases = map[string]string{
+	"":                         CountryUS,
+	"us":                       CountryUS,
+	"usa":                      CountryUS,
+	"united states":            CountryUS,
+	"united states of america": CountryUS,
+	"ca":                       CountryCA,
+	"can":                      CountryCA,
+	"canada":                   CountryCA,
+	"mx":                       CountryMX,
+	"mex":                      CountryMX,
+	"mexico":                   CountryMX,
+	"méxico":                   CountryMX,
+}
+
+// regionCodes are the state, province and territory codes of each country.
+// Mexican states use their ISO 3166-2 codes.
+var regionCodes = map[string]map[string]bool{
+	CountryUS: codeSet("AL AK AZ AR CA CO CT DE DC FL GA HI ID IL IN IA KS KY LA ME MD MA MI MN MS MO MT NE NV NH NJ NM NY NC ND OH OK OR PA RI SC SD TN TX UT VT VA WA WV WI WY PR VI GU AS MP"),
+	CountryCA: codeSet("AB BC MB NB NL NS NT NU ON PE QC SK YT"),
+	CountryMX: codeSet("AGU BCN BCS CAM CHP CHH CMX COA COL DUR GUA GRO HID JAL MEX MIC MOR NAY NLE OAX PUE QUE ROO SLP SIN SON TAB TAM TLA VER YUC ZAC"),
+}
+
+// postalCodePatterns are the postal code formats of each country
+var postalCodePatterns = map[string]*regexp.Regexp{
+	CountryUS: regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	CountryCA: regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] ?\d[ABCEGHJ-NPRSTV-Z]\d$`),
+	CountryMX: regexp.MustCompile(`^\d{5}$`),
+}
+
+// postalCodeExamples illustrate each country's postal code format in errors
+var postalCodeExamples = map[string]string{
+	CountryUS: "12345 or 12345-6789",
+	CountryCA: "A1A 1A1",
+	CountryMX: "12345",
+}
+
+// codeSet builds a lookup from space-separated codes
+func codeSet(codes string) map[string]bool {
+	set := make(map[string]bool)
+	for _, code := range strings.Fields(codes) {
+		set[code] = true
+	}
+	return set
+}
+
+// normalizeCountry returns the ISO code for a supported country. An empty
+// country is the United States.
+func normalizeCountry(country string) (string, bool) {
+	code, ok := countryAliases[strings.ToLower(knownValue(country))]
+	return code, ok
+}
+
+// regionNames maps lower-cased, unaccented state and province names and their
+// common abbreviations to codes, by country
+var regionNames = map[string]map[string]string{
+	CountryUS: {
+		"alabama": "AL", "alaska": "AK", "arizona": "AZ", "arkansas": "AR", "california": "CA",
+		"colorado": "CO", "connecticut": "CT", "delaware": "DE", "district of columbia": "DC",
+		"washington dc": "DC", "florida": "FL", "georgia": "GA", "hawaii": "HI", "idaho": "ID",
+		"illinois": "IL", "indiana": "IN", "iowa": "IA", "kansas": "KS", "kentucky": "KY",
+		"louisiana": "LA", "maine": "ME", "maryland": "MD", "massachusetts": "MA", "michigan": "MI",
+		"minnesota": "MN", "mississippi": "MS", "missouri": "MO", "montana": "MT", "nebraska": "NE",
+		"nevada": "NV", "new hampshire": "NH", "new jersey": "NJ", "new mexico": "NM", "new york": "NY",
+		"north carolina": "NC", "north dakota": "ND", "ohio": "OH", "oklahoma": "OK", "oregon": "OR",
+		"pennsylvania": "PA", "rhode island": "RI", "south carolina": "SC", "south dakota": "SD",
+		"tennessee": "TN", "texas": "TX", "utah": "UT", "vermont": "VT", "virginia": "VA",
+		"washington": "WA", "west virginia": "WV", "wisconsin": "WI", "wyoming": "WY",
+		"puerto rico": "PR", "virgin islands": "VI", "us virgin islands": "VI", "guam": "GU",
+		"american samoa": "AS", "northern mariana islands": "MP",
+	},
+	CountryCA: {
+		"alberta": "AB", "british columbia": "BC", "colombie-britannique": "BC", "manitoba": "MB",
+		"new brunswick": "NB", "nouveau-brunswick": "NB", "newfoundland and labrador": "NL",
+		"newfoundland": "NL", "nf": "NL", "nova scotia": "NS", "nouvelle-ecosse": "NS",
+		"northwest territories": "NT", "nunavut": "NU", "ontario": "ON",
+		"prince edward island": "PE", "ile-du-prince-edouard": "PE", "quebec": "QC", "pq": "QC",
+		"saskatchewan": "SK", "yukon": "YT",
+	},
+	CountryMX: {
+		"aguascalientes": "AGU", "ags": "AGU", "baja california": "BCN", "bc": "BCN",
+		"baja california sur": "BCS", "campeche": "CAM", "chiapas": "CHP", "chihuahua": "CHH",
+		"ciudad de mexico": "CMX", "cdmx": "CMX", "distrito federal": "CMX", "df": "CMX",
+		"coahuila": "COA", "coahuila de zaragoza": "COA", "colima": "COL", "durango": "DUR",
+		"guanajuato": "GUA", "gto": "GUA", "guerrero": "GRO", "hidalgo": "HID", "jalisco": "JAL",
+		"mexico": "MEX", "estado de mexico": "MEX", "edomex": "MEX", "michoacan": "MIC",
+		"michoacan de ocampo": "MIC", "morelos": "MOR", "nayarit": "NAY", "nuevo leon": "NLE",
+		"nl": "NLE", "oaxaca": "OAX", "puebla": "PUE", "queretaro": "QUE", "qro": "QUE",
+		"quintana roo": "ROO", "san luis potosi": "SLP", "sinaloa": "SIN", "sonora": "SON",
+		"tabasco": "TAB", "tamaulipas": "TAM", "tlaxcala": "TLA", "veracruz": "VER",
+		"veracruz de ignacio de la llave": "VER", "yucatan": "YUC", "zacatecas": "ZAC",
+	},
+}
+
+// accentFolder strips the accents used in Canadian and Mexican place names
+var accentFolder = strings.NewReplacer("á", "a", "à", "a", "â", "a", "é", "e", "è", "e", "ê", "e",
+	"í", "i", "î", "i", "ó", "o", "ô", "o", "ú", "u", "ü", "u", "ñ", "n", "ç", "c")
+
+// upperCaseWords stay capitalized when an address is re-cased
+var upperCaseWords = codeSet("N S E W NE NW SE SW PO US")
+
+// ordinalPattern matches street ordinals such as 1st and 42nd
+var ordinalPattern = regexp.MustCompile(`^\d+(st|nd|rd|th)$`)
+
+// addressFields points at the address fields of a customer, bill-to or stop
+type addressFields struct {
+	AddressLine1, AddressLine2, City, State, Zipcode, Country *string
+	Geo                                                       **types.GeoPoint
+}
+
+// NormalizeLoadAddresses standardizes the customer, bill-to and stop
+// addresses of a load in place
+func NormalizeLoadAddresses(load *types.Load) {
+	customer, billTo, pickup, consignee := &load.Customer, &load.BillTo, &load.Pickup, &load.Consignee
+	for _, addr := range []addressFields{
+		{&customer.AddressLine1, &customer.AddressLine2, &customer.City, &customer.State, &customer.Zipcode, &customer.Country, &customer.Geo},
+		{&billTo.AddressLine1, &billTo.AddressLine2, &billTo.City, &billTo.State, &billTo.Zipcode, &billTo.Country, &billTo.Geo},
+		{&pickup.AddressLine1, &pickup.AddressLine2, &pickup.City, &pickup.State, &pickup.Zipcode, &pickup.Country, &pickup.Geo},
+		{&consignee.AddressLine1, &consignee.AddressLine2, &consignee.City, &consignee.State, &consignee.Zipcode, &consignee.Country, &consignee.Geo},
+	} {
+		normalizeAddress(addr)
+	}
+}
+
+// normalizeAddress standardizes street and city casing, the country, the state
+// or province code and the postal code format. A US address with a zip in the
+// zip5 dataset gets its missing city and state and, unless the client sent
+// one, its position; without the dataset only the state is inferred, from the
+// zip prefix. Values that cannot be recognized are left for validation to report.
+func normalizeAddress(addr addressFields) {
+	for _, text := range []*string{addr.AddressLine1, addr.AddressLine2, addr.City} {
+		if knownValue(*text) != "" {
+			*text = normalizeCase(*text)
+		}
+	}
+
+	country, ok := normalizeCountry(*addr.Country)
+	if knownValue(*addr.Country) == "" {
+		country = inferCountry(*addr.State, *addr.Zipcode)
+	} else if !ok {
+		return
+	}
+	*addr.Country = country
+	if state, ok := normalizeRegion(*addr.State, country); ok {
+		*addr.State = state
+	}
+	if knownValue(*addr.Zipcode) != "" {
+		*addr.Zipcode = normalizePostalCode(*addr.Zipcode, country)
+	}
+
+	if country != CountryUS || !postalCodePatterns[CountryUS].MatchString(*addr.Zipcode) {
+		return
+	}
+	if knownValue(*addr.State) == "" {
+		if state := stateForZip(*addr.Zipcode); state != "" {
+			*addr.State = state
+		}
+	}
+	place, ok := lookupZip(*addr.Zipcode)
+	if !ok || place.State != *addr.State {
+		return
+	}
+	if knownValue(*addr.City) == "" {
+		*addr.City = place.City
+	}
+	if *addr.Geo == nil {
+		geo := place.Geo
+		*addr.Geo = &geo
+	}
+}
+
+// inferCountry picks the country of an address that names none: Canada for a
+// Canadian postal code or province, Mexico for a Mexican state, otherwise the
+// United States
+func inferCountry(state, zip string) string {
+	compact := strings.ToUpper(strings.Join(strings.Fields(zip), ""))
+	if len(compact) == 6 && postalCodePatterns[CountryCA].MatchString(compact) {
+		return CountryCA
+	}
+	if _, ok := normalizeRegion(state, CountryUS); ok {
+		return CountryUS
+	}
+	for _, country := range []string{CountryCA, CountryMX} {
+		if _, ok := normalizeRegion(state, country); ok {
+			return country
+		}
+	}
+	return CountryUS
+}
+
+// normalizeRegion returns the code of a state or province given as a code
+// (with or without periods) or by name
+func normalizeRegion(state, country string) (string, bool) {
+	key := strings.Join(strings.Fields(strings.ReplaceAll(knownValue(state), ".", "")), " ")
+	key = accentFolder.Replace(strings.ToLower(key))
+	if code := strings.ToUpper(key); regionCodes[country][code] {
+		return code, true
+	}
+	code, ok := regionNames[country][key]
+	return code, ok
+}
+
+// normalizePostalCode formats a postal code for its country: US zips as 12345
+// or ZIP+4 12345-6789, Canadian codes as A1A 1A1 and Mexican codes as 5
+// digits. Zips that lost a leading zero in a spreadsheet get it back. Codes
+// that do not fit the country's format are returned trimmed.
+func normalizePostalCode(zip, country string) string {
+	zip = strings.TrimSpace(zip)
+	compact := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(zip))
+	digits := strings.IndexFunc(compact, func(r rune) bool { return r < '0' || r > '9' }) < 0
+	switch {
+	case country == CountryCA && len(compact) == 6:
+		return compact[:3] + " " + compact[3:]
+	case country == CountryCA || !digits:
+		return zip
+	case len(compact) == 4:
+		return "0" + compact
+	case len(compact) == 5:
+		return compact
+	case country == CountryUS && len(compact) == 9:
+		return compact[:5] + "-" + compact[5:]
+	}
+	return zip
+}
+
+// normalizeCase collapses whitespace and title-cases text typed in all
+// capitals or all lower case, keeping directions and unit numbers upper case
+// and ordinals lower case. Text already in mixed case is kept as typed.
+func normalizeCase(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text != strings.ToUpper(text) && text != strings.ToLower(text) {
+		return text
+	}
+	words := strings.Fields(text)
+	for i, word := range words {
+		parts := strings.Split(word, "-")
+		for j, part := range parts {
+			parts[j] = titleWord(part)
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+	return strings.Join(words, " ")
+}
+
+// titleWord capitalizes one word of an address
+func titleWord(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case lower == "":
+		return lower
+	case upperCaseWords[strings.ToUpper(strings.Trim(word, ".,"))]:
+		return strings.ToUpper(word)
+	case ordinalPattern.MatchString(lower):
+		return lower
+	case unicode.IsDigit(rune(lower[0])):
+		return strings.ToUpper(word)
+	}
+	first, size := utf8.DecodeRuneInString(lower)
+	return string(unicode.ToUpper(first)) + lower[size:]
+}
+
+// address is the part of a customer, bill-to or stop that is validated
+type address struct {
+	Name, AddressLine1, City, State, Zipcode, Country, Phone, Email string
+}
+
+// validateAddress checks a party's name, address, phone and email. With
+// required set, the street, city, state and postal code must be present;
+// otherwise only the fields given are checked.
+func validateAddress(prefix string, addr address, required bool, add func(field, format string, args ...interface{})) {
+	field := func(name string) string {
+		return prefix + "." + name
+	}
+
+	if knownValue(addr.Name) == "" {
+		add(field("name"), "is required")
+	}
+	if required {
+		for _, part := range []struct{ name, value string }{
+			{"addressLine1", addr.AddressLine1},
+			{"city", addr.City},
+			{"state", addr.State},
+			{"zipcode", addr.Zipcode},
+		} {
+			if knownValue(part.value) == "" {
+				add(field(part.name), "is required")
+			}
+		}
+	}
+
+	country, ok := normalizeCountry(addr.Country)
+	if !ok {
+		add(field("country"), "unsupported country %q (expected US, CA or MX)", addr.Country)
+	} else {
+		state := strings.ToUpper(knownValue(addr.State))
+		if state != "" && !regionCodes[country][state] {
+			add(field("state"), "unknown %s state code %q", country, addr.State)
+		}
+		zip := strings.ToUpper(knownValue(addr.Zipcode))
+		if zip != "" && !postalCodePatterns[country].MatchString(zip) {
+			add(field("zipcode"), "invalid %s postal code %q (expected %s)", country, addr.Zipcode, postalCodeExamples[country])
+		} else if zipState := stateForZip(zip); country == CountryUS && zipState != "" && state != "" && regionCodes[country][state] && zipState != state {
+			add(field("zipcode"), "%s is in %s, not %s", zip, zipState, state)
+		}
+		if err := validatePhone(addr.Phone, country); err != nil {
+			add(field("phone"), "%v", err)
+		}
+	}
+	if err := validateEmail(addr.Email); err != nil {
+		add(field("email"), "%v", err)
+	}
+}
+
+// validatePhone accepts an empty phone, a 10-digit North American or Mexican
+// number with an optional +1 or +52 country code and an x extension, or
+// another international number written with a leading + and 8 to 15 digits
+func validatePhone(phone, country string) error {
+	phone = knownValue(phone)
+	if phone == "" {
+		return nil
+	}
+	number := strings.ToLower(phone)
+	if before, _, found := strings.Cut(number, "x"); found {
+		number = strings.TrimSpace(before)
+	}
+	if strings.IndexFunc(number, func(r rune) bool { return !strings.ContainsRune("0123456789+-.() ", r) }) >= 0 {
+		return fmt.Errorf("invalid phone number %q", phone)
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	switch {
+	case strings.HasPrefix(number, "+1"):
+		digits = digits[1:]
+	case strings.HasPrefix(number, "+52"):
+		digits, country = digits[2:], CountryMX
+	case strings.HasPrefix(number, "+"):
+		if len(digits) < 8 || len(digits) > 15 {
+			return fmt.Errorf("invalid international phone number %q", phone)
+		}
+		return nil
+	case country != CountryMX && len(digits) == 11 && digits[0] == '1':
+		digits = digits[1:]
+	case country == CountryMX && len(digits) == 12 && strings.HasPrefix(digits, "52"):
+		digits = digits[2:]
+	}
+	if len(digits) != 10 {
+		return fmt.Errorf("invalid phone number %q (expected 10 digits)", phone)
+	}
+	if country != CountryMX && digits[0] < '2' {
+		return fmt.Errorf("invalid phone number %q (area code cannot start with %c)", phone, digits[0])
+	}
+	return nil
+}
+
+// validateEmail accepts an empty email or a bare address with a dotted domain
+func validateEmail(email string) error {
+	email = knownValue(email)
+	if email == "" {
+		return nil
+	}
+	parsed, err := mail.ParseAddress(email)
+	if err != nil || parsed.Address != email {
+		return fmt.Errorf("invalid email address %q", email)
+	}
+	_, domain, _ := strings.Cut(parsed.Address, "@")
+	if !strings.Contains(strings.Trim(domain, "."), ".") {
+		return fmt.Errorf("invalid email address %q", email)
+	}
+	return nil
+}
+
+// validateParties checks the customer, bill-to and stop addresses and contacts,
+// and the carrier's contacts when a carrier is given
+func validateParties(load types.Load, add func(field, format string, args ...interface{})) {
+	customer, billTo, pickup, consignee := load.Customer, load.BillTo, load.Pickup, load.Consignee
+	validateAddress("customer", address{customer.Name, customer.AddressLine1, customer.City, customer.State, customer.Zipcode, customer.Country, customer.Phone, customer.Email}, false, add)
+	validateAddress("billTo", address{billTo.Name, billTo.AddressLine1, billTo.City, billTo.State, billTo.Zipcode, billTo.Country, billTo.Phone, billTo.Email}, false, add)
+	validateAddress("pickup", address{pickup.Name, pickup.AddressLine1, pickup.City, pickup.State, pickup.Zipcode, pickup.Country, pickup.Phone, pickup.Email}, true, add)
+	validateAddress("consignee", address{consignee.Name, consignee.AddressLine1, consignee.City, consignee.State, consignee.Zipcode, consignee.Country, consignee.Phone, consignee.Email}, true, add)
+
+	carrier := load.Carrier
+	for _, phone := range []struct{ name, value string }{
+		{"phone", carrier.Phone},
+		{"firstDriverPhone", carrier.FirstDriverPhone},
+		{"secondDriverPhone", carrier.SecondDriverPhone},
+	} {
+		if err := validatePhone(phone.value, CountryUS); err != nil {
+			add("carrier."+phone.name, "%v", err)
+		}
+	}
+	if err := validateEmail(carrier.Email); err != nil {
+		add("carrier.email", "%v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"turvo-app/types"
+)
+
+// testZipCodes are GeoNames rows for the zips used in address tests
+const testZipCodes = "US\t60601\tChicago\tIllinois\tIL\tCook\t031\t\t\t41.8858\t-87.6181\t4\n" +
+	"US\t02108\tBoston\tMassachusetts\tMA\tSuffolk\t025\t\t\t42.3576\t-71.0684\t4\n" +
+	"US\t02108\tBeacon Hill\tMassachusetts\tMA\tSuffolk\t025\t\t\t42.3580\t-71.0670\t4\n" +
+	"CA\tM5V\tToronto\tOntario\tON\t\t\t\t\t43.6426\t-79.3871\t4\n"
+
+// useZipCodes loads rows as the zip5 dataset for the rest of a test
+func useZipCodes(t *testing.T, rows string) {
+	t.Helper()
+	places, err := parseZipCodes(bufio.NewScanner(strings.NewReader(rows)))
+	if err != nil {
+		t.Fatalf("failed to parse zip codes: %v", err)
+	}
+	zipPlacesMu.Lock()
+	previous := zipPlaces
+	zipPlaces = places
+	zipPlacesMu.Unlock()
+	t.Cleanup(func() {
+		zipPlacesMu.Lock()
+		zipPlaces = previous
+		zipPlacesMu.Unlock()
+	})
+}
+
+func TestParseZipCodesKeepsFirstUSRow(t *testing.T) {
+	useZipCodes(t, testZipCodes)
+
+	if _, ok := lookupZip("M5V"); ok {
+		t.Errorf("expected Canadian rows to be skipped")
+	}
+	place, ok := lookupZip("02108-1234")
+	if !ok {
+		t.Fatalf("expected 02108 to be found by its ZIP+4")
+	}
+	if place.City != "Boston" || place.State != "MA" || place.Geo.Lat != 42.3576 {
+		t.Errorf("expected the first row for 02108, got %+v", place)
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	useZipCodes(t, testZipCodes)
+	clientGeo := &types.GeoPoint{Lat: 41.9, Lng: -87.6}
+
+	tests := []struct {
+		name                                    string
+		line1, city, state, zip, country        string
+		geo                                     *types.GeoPoint
+		wantLine1, wantCity, wantState, wantZip string
+		wantCountry                             string
+		wantGeo                                 *types.GeoPoint
+	}{
+		{
+			name: "state name and casing", line1: "233 S WACKER DR", city: "CHICAGO", state: "Illinois", zip: "60601",
+			wantLine1: "233 S Wacker Dr", wantCity: "Chicago", wantState: "IL", wantZip: "60601", wantCountry: CountryUS,
+			wantGeo: &types.GeoPoint{Lat: 41.8858, Lng: -87.6181},
+		},
+		{
+			name: "city and state from the zip", line1: "1 Main St", zip: "2108",
+			wantLine1: "1 Main St", wantCity: "Boston", wantState: "MA", wantZip: "02108", wantCountry: CountryUS,
+			wantGeo: &types.GeoPoint{Lat: 42.3576, Lng: -71.0684},
+		},
+		{
+			name: "ZIP+4 without a dash", line1: "1 Main St", city: "Boston", state: "ma", zip: "021081234",
+			wantLine1: "1 Main St", wantCity: "Boston", wantState: "MA", wantZip: "02108-1234", wantCountry: CountryUS,
+			wantGeo: &types.GeoPoint{Lat: 42.3576, Lng: -71.0684},
+		},
+		{
+			name: "client position is kept", line1: "1 Main St", city: "Chicago", state: "IL", zip: "60601", geo: clientGeo,
+			wantLine1: "1 Main St", wantCity: "Chicago", wantState: "IL", wantZip: "60601", wantCountry: CountryUS,
+			wantGeo: clientGeo,
+		},
+		{
+			name: "zip in another state is left for validation", line1: "1 Main St", city: "Springfield", state: "IL", zip: "02108",
+			wantLine1: "1 Main St", wantCity: "Springfield", wantState: "IL", wantZip: "02108", wantCountry: CountryUS,
+		},
+		{
+			name: "zip missing from the dataset", line1: "1 Main St", zip: "10001",
+			wantLine1: "1 Main St", wantState: "NY", wantZip: "10001", wantCountry: CountryUS,
+		},
+		{
+			name: "Canadian postal code", line1: "290 Bremner Blvd", city: "Toronto", state: "Ontario", zip: "m5v3l9",
+			wantLine1: "290 Bremner Blvd", wantCity: "Toronto", wantState: "ON", wantZip: "M5V 3L9", wantCountry: CountryCA,
+		},
+		{
+			name: "Mexican state name", line1: "Av Constitucion 100", city: "Monterrey", state: "Nuevo León", zip: "64000", country: "México",
+			wantLine1: "Av Constitucion 100", wantCity: "Monterrey", wantState: "NLE", wantZip: "64000", wantCountry: CountryMX,
+		},
+		{
+			name: "unsupported country is left as sent", line1: "1 High St", city: "London", state: "", zip: "SW1A 1AA", country: "UK",
+			wantLine1: "1 High St", wantCity: "London", wantZip: "SW1A 1AA", wantCountry: "UK",
+		},
+	}
+	for _, test := range tests {
+		line1, line2, city, state, zip, country, geo := test.line1, "", test.city, test.state, test.zip, test.country, test.geo
+		normalizeAddress(addressFields{&line1, &line2, &city, &state, &zip, &country, &geo})
+
+		got := []string{line1, city, state, zip, country}
+		want := []string{test.wantLine1, test.wantCity, test.wantState, test.wantZip, test.wantCountry}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("%s: got %q, want %q", test.name, got, want)
+		}
+		switch {
+		case test.wantGeo == nil && geo != nil:
+			t.Errorf("%s: expected no position, got %+v", test.name, *geo)
+		case test.wantGeo != nil && (geo == nil || *geo != *test.wantGeo):
+			t.Errorf("%s: got position %+v, want %+v", test.name, geo, *test.wantGeo)
+		}
+	}
+}
+
+func TestNormalizeAddressWithoutDataset(t *testing.T) {
+	useZipCodes(t, "")
+
+	line1, line2, city, state, zip, country := "1 Main St", "", "", "", "60601", ""
+	var geo *types.GeoPoint
+	normalizeAddress(addressFields{&line1, &line2, &city, &state, &zip, &country, &geo})
+	if state != "IL" || city != "" || geo != nil {
+		t.Errorf("expected only the state from the zip prefix, got city %q state %q geo %+v", city, state, geo)
+	}
+}
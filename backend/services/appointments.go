@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"turvo-app/types"
+)
+
+// Stop scheduling types accepted on pickups and consignees
+const (
+	SchedulingAppointment = "appointment"
+	SchedulingFCFS        = "fcfs"
+	SchedulingOpen        = "open"
+)
+
+// schedulingAliases are the accepted spellings of each scheduling type
+var schedulingAliases = map[string]string{
+	"":                        SchedulingAppointment,
+	"appointment":             SchedulingAppointment,
+	"appt":                    SchedulingAppointment,
+	"by appointment":          SchedulingAppointment,
+	"fcfs":                    SchedulingFCFS,
+	"first come first serve":  SchedulingFCFS,
+	"first come, first serve": SchedulingFCFS,
+	"open":                    SchedulingOpen,
+	"open window":             SchedulingOpen,
+}
+
+// StopAppointment is a stop's resolved appointment window
+type StopAppointment struct {
+	Scheduling  string
+	From        time.Time
+	To          time.Time
+	FlexSeconds int
+}
+
+// resolveStopAppointment builds a stop's window from its explicit window,
+// falling back to the appointment time and then to fallback. A zero flex
+// uses defaultFlex seconds.
+func resolveStopAppointment(schedulingType string, windowStart, windowEnd, appt, fallback time.Time, flexMinutes, defaultFlex int) (StopAppointment, error) {
+	scheduling, err := normalizeSchedulingType(schedulingType)
+	if err != nil {
+		return StopAppointment{}, err
+	}
+	if flexMinutes < 0 {
+		return StopAppointment{}, fmt.Errorf("appointment flex cannot be negative")
+	}
+
+	appointment := StopAppointment{
+		Scheduling:  scheduling,
+		From:        windowStart,
+		To:          windowEnd,
+		FlexSeconds: flexMinutes * 60,
+	}
+	if appointment.From.IsZero() {
+		appointment.From = appt
+	}
+	if appointment.From.IsZero() {
+		appointment.From = fallback
+	}
+	if appointment.To.IsZero() {
+		appointment.To = appointment.From
+	}
+	if appointment.To.Before(appointment.From) {
+		return StopAppointment{}, fmt.Errorf("appointment window ends before it starts")
+	}
+	if appointment.FlexSeconds == 0 {
+		appointment.FlexSeconds = defaultFlex
+	}
+	return appointment, nil
+}
+
+// normalizeSchedulingType maps a scheduling type or its alias to its canonical name
+func normalizeSchedulingType(schedulingType string) (string, error) {
+	scheduling, ok := schedulingAliases[strings.ToLower(knownValue(schedulingType))]
+	if !ok {
+		return "", fmt.Errorf("unsupported scheduling type %q (expected %s, %s or %s)",
+			schedulingType, SchedulingAppointment, SchedulingFCFS, SchedulingOpen)
+	}
+	return scheduling, nil
+}
+
+// turvoAppointment converts the start of a window to a Turvo appointment
+func (a StopAppointment) turvoAppointment(location *time.Location) types.TurvoAppointment {
+	return types.TurvoAppointment{
+		Date:     turvoDateTime(a.From, location),
+		Timezone: location.String(),
+		Flex:     a.FlexSeconds,
+		HasTime:  true,
+	}
+}
+
+// turvoSchedulingType returns the Turvo code for the window's scheduling type
+func (a StopAppointment) turvoSchedulingType(codes *TurvoCodeTable) types.TurvoCode {
+	code, _ := codes.Scheduling(a.Scheduling)
+	return code
+}
+
+// turvoPlannedAppointment converts a window to a Turvo planned appointment
+func (a StopAppointment) turvoPlannedAppointment(location *time.Location, codes *TurvoCodeTable) types.TurvoPlannedAppointment {
+	to := a
+	to.From = a.To
+	return types.TurvoPlannedAppointment{
+		SchedulingType: a.turvoSchedulingType(codes),
+		Appointment: types.TurvoPlannedAppointmentDetail{
+			From: a.turvoAppointment(location),
+			To:   to.turvoAppointment(location),
+		},
+	}
+}
+
+// AppointmentFromTurvo reads a stop's appointment window back from Turvo.
+// Times that cannot be parsed are left zero.
+func AppointmentFromTurvo(route types.TurvoGlobalRoute, codes *TurvoCodeTable) StopAppointment {
+	planned := route.PlannedAppointmentDate
+	appointment := StopAppointment{
+		Scheduling:  SchedulingAppointment,
+		From:        parseTurvoTime(planned.Appointment.From.Date, planned.Appointment.From.Timezone),
+		To:          parseTurvoTime(planned.Appointment.To.Date, planned.Appointment.To.Timezone),
+		FlexSeconds: route.Appointment.Flex,
+	}
+
+	key := planned.SchedulingType.Key
+	if key == "" {
+		key = route.SchedulingType.Key
+	}
+	if scheduling, ok := codeName(codes.codes.Scheduling, key); ok {
+		appointment.Scheduling = scheduling
+	}
+
+	if appointment.From.IsZero() {
+		appointment.From = parseTurvoTime(route.Appointment.Date, route.Appointment.Timezone)
+	}
+	if appointment.To.IsZero() {
+		appointment.To = appointment.From
+	}
+	return appointment
+}
+
+// parseTurvoTime parses a Turvo date, reading dates without an offset in the given zone
+func parseTurvoTime(value, timezone string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, location); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// validateSchedule checks each stop's scheduling type and window, that the
+// pickup appointment is not before the ready time, that delivery comes after
+// pickup, and that the carrier's pickup and delivery windows are in order
+func validateSchedule(load types.Load, add func(field, format string, args ...interface{})) {
+	pickup, consignee := load.Pickup, load.Consignee
+	validateStopWindow("pickup", pickup.SchedulingType, pickup.ApptWindowStart, pickup.ApptWindowEnd, pickup.ApptFlexMinutes, add)
+	validateStopWindow("consignee", consignee.SchedulingType, consignee.ApptWindowStart, consignee.ApptWindowEnd, consignee.ApptFlexMinutes, add)
+
+	pickupField, pickupStart := stopStart("pickup", pickup.ApptWindowStart, pickup.ApptTime)
+	if !pickup.ReadyTime.IsZero() && !pickupStart.IsZero() && pickupStart.Before(pickup.ReadyTime) {
+		add(pickupField, "must not be before pickup.readyTime")
+	}
+	consigneeField, consigneeStart := stopStart("consignee", consignee.ApptWindowStart, consignee.ApptTime)
+	if !pickupStart.IsZero() && !consigneeStart.IsZero() && !consigneeStart.After(pickupStart) {
+		add(consigneeField, "must be after %s", pickupField)
+	}
+
+	carrier := load.Carrier
+	if !carrier.PickupStart.IsZero() && !carrier.PickupEnd.IsZero() && carrier.PickupEnd.Before(carrier.PickupStart) {
+		add("carrier.pickupEnd", "must not be before carrier.pickupStart")
+	}
+	if !carrier.DeliveryStart.IsZero() && !carrier.DeliveryEnd.IsZero() && carrier.DeliveryEnd.Before(carrier.DeliveryStart) {
+		add("carrier.deliveryEnd", "must not be before carrier.deliveryStart")
+	}
+}
+
+// validateStopWindow checks a stop's scheduling type, window order and flex
+func validateStopWindow(prefix, schedulingType string, windowStart, windowEnd time.Time, flexMinutes int, add func(field, format string, args ...interface{})) {
+	if _, err := normalizeSchedulingType(schedulingType); err != nil {
+		add(prefix+".schedulingType", "%v", err)
+	}
+	if !windowStart.IsZero() && !windowEnd.IsZero() && windowEnd.Before(windowStart) {
+		add(prefix+".apptWindowEnd", "must not be before %s.apptWindowStart", prefix)
+	}
+	if flexMinutes < 0 {
+		add(prefix+".apptFlexMinutes", "cannot be negative")
+	}
+}
+
+// stopStart returns when a stop's appointment starts, preferring its window
+// over its appointment time, and the field it came from
+func stopStart(prefix string, windowStart, appt time.Time) (string, time.Time) {
+	if !windowStart.IsZero() {
+		return prefix + ".apptWindowStart", windowStart
+	}
+	return prefix + ".apptTime", appt
+}
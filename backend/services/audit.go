@@ -0,0 +1,340 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// maxAuditPayloadBytes caps the request payload kept on an audit entry
+const maxAuditPayloadBytes = 64 << 10
+
+// maxAuditMemoryEntries is how many entries are kept when no audit file is configured
+const maxAuditMemoryEntries = 10000
+
+// maxAuditSummaryBytes caps the Turvo response text kept for a failed call
+const maxAuditSummaryBytes = 300
+
+// auditRedacted replaces secret values in audited payloads
+const auditRedacted = "[REDACTED]"
+
+// auditSecretKeys are lower-cased key fragments whose values are redacted
+var auditSecretKeys = []string{"password", "secret", "token", "apikey", "api_key", "authorization", "credential"}
+
+// AuditLog is an append-only record of write operations. Entries are written
+// as JSON lines to the configured file, or kept in memory without one.
+// Entries that fail to write are held in memory and retried before the next
+// write, and the log reports itself unhealthy until they are written.
+type AuditLog struct {
+	mu      sync.Mutex
+	file    string
+	entries []types.AuditEntry
+	pending []types.AuditEntry
+	lastErr error
+}
+
+// NewAuditLog creates the audit log
+func NewAuditLog(cfg *config.Config) *AuditLog {
+	if cfg.AuditLogFile == "" {
+		fmt.Printf("DEBUG: AUDIT_LOG_FILE is not set, audit entries are kept in memory only\n")
+	}
+	return &AuditLog{file: cfg.AuditLogFile}
+}
+
+// Record appends an entry, assigning its ID and timestamp when missing. An
+// entry that cannot be written is kept to be retried and the error returned.
+func (a *AuditLog) Record(entry types.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = newWebhookID("aud")
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == "" {
+		a.entries = append(a.entries, entry)
+		if len(a.entries) > maxAuditMemoryEntries {
+			a.entries = a.entries[len(a.entries)-maxAuditMemoryEntries:]
+		}
+		return nil
+	}
+	a.pending = append(a.pending, entry)
+	if len(a.pending) > maxAuditMemoryEntries {
+		dropped := a.pending[0]
+		a.pending = a.pending[1:]
+		fmt.Printf("DEBUG: ALERT: Dropped unwritten audit entry %s (%s by %s), too many entries are pending\n", dropped.ID, dropped.Action, dropped.Actor)
+	}
+	if err := a.flush(); err != nil {
+		fmt.Printf("DEBUG: ALERT: Failed to write audit entry %s (%s by %s), %d entries pending: %v\n", entry.ID, entry.Action, entry.Actor, len(a.pending), err)
+		return err
+	}
+	return nil
+}
+
+// Healthy retries any pending entries and reports an error while some still
+// cannot be written
+func (a *AuditLog) Healthy() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.flush(); err != nil {
+		return fmt.Errorf("%d audit entries are not written: %w", len(a.pending), err)
+	}
+	return nil
+}
+
+// flush writes pending entries in order, stopping at the first failure. Callers hold the lock.
+func (a *AuditLog) flush() error {
+	for len(a.pending) > 0 {
+		if err := appendJSONLine(a.file, a.pending[0]); err != nil {
+			a.lastErr = err
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		a.pending = a.pending[1:]
+	}
+	if a.lastErr != nil {
+		fmt.Printf("DEBUG: Audit log is writable again\n")
+		a.lastErr = nil
+	}
+	return nil
+}
+
+// Query returns the entries matching a filter, newest first
+func (a *AuditLog) Query(filter types.AuditFilter) ([]types.AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := []types.AuditEntry{}
+	match := func(entry types.AuditEntry) {
+		if auditMatches(filter, entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if a.file == "" {
+		for _, entry := range a.entries {
+			match(entry)
+		}
+	} else if err := scanJSONLines(a.file, match); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	for _, entry := range a.pending {
+		match(entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+// auditMatches reports whether an entry passes a filter
+func auditMatches(filter types.AuditFilter, entry types.AuditEntry) bool {
+	switch {
+	case entry.TenantID != filter.TenantID:
+		return false
+	case filter.Actor != "" && !strings.EqualFold(entry.Actor, filter.Actor):
+		return false
+	case filter.Action != "" && entry.Action != filter.Action:
+		return false
+	case filter.Outcome != "" && entry.Outcome != filter.Outcome:
+		return false
+	case filter.ResourceID != "" && entry.ResourceID != filter.ResourceID:
+		return false
+	case !filter.From.IsZero() && entry.Timestamp.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && !entry.Timestamp.Before(filter.To):
+		return false
+	}
+	return true
+}
+
+// appendJSONLine appends a value to a file as one line of JSON
+func appendJSONLine(path string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// scanJSONLines decodes each line of a JSON lines file, skipping lines that
+// do not parse. A missing file has no entries.
+func scanJSONLines(path string, visit func(types.AuditEntry)) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 4*maxAuditPayloadBytes)
+	for scanner.Scan() {
+		var entry types.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		visit(entry)
+	}
+	return scanner.Err()
+}
+
+// RedactPayload returns a JSON request body with secret values replaced.
+// Bodies that are not JSON, or are too large, are recorded by size only.
+func RedactPayload(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var value interface{}
+	if len(body) > maxAuditPayloadBytes || json.Unmarshal(body, &value) != nil {
+		summary, _ := json.Marshal(map[string]interface{}{"omitted": true, "bytes": len(body)})
+		return summary
+	}
+	redactValue(value)
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+// redactValue replaces secret fields in place
+func redactValue(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			if isSecretKey(key) {
+				typed[key] = auditRedacted
+				continue
+			}
+			redactValue(field)
+		}
+	case []interface{}:
+		for _, item := range typed {
+			redactValue(item)
+		}
+	}
+}
+
+// isSecretKey reports whether a field name looks like it holds a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range auditSecretKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// TurvoTrace collects summaries of the Turvo calls made while handling one request
+type TurvoTrace struct {
+	mu    sync.Mutex
+	calls []types.AuditTurvoCall
+}
+
+// Calls returns the Turvo calls recorded so far
+func (t *TurvoTrace) Calls() []types.AuditTurvoCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]types.AuditTurvoCall{}, t.calls...)
+}
+
+// tracingTransport records a summary of every request it sends to a TurvoTrace
+type tracingTransport struct {
+	base  http.RoundTripper
+	trace *TurvoTrace
+}
+
+// RoundTrip sends a request and records its method, path, status and timing.
+// The OAuth token exchange is recorded without its response.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	call := types.AuditTurvoCall{
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		RequestBytes: req.ContentLength,
+	}
+	if err != nil {
+		call.Error = err.Error()
+	} else {
+		call.StatusCode = resp.StatusCode
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		call.ResponseBytes = len(body)
+		if readErr != nil {
+			call.Error = readErr.Error()
+		} else if !strings.HasSuffix(req.URL.Path, "/oauth/token") {
+			call.Summary = turvoResponseSummary(resp.StatusCode, body)
+		}
+	}
+	call.DurationMs = time.Since(started).Milliseconds()
+
+	t.trace.mu.Lock()
+	t.trace.calls = append(t.trace.calls, call)
+	t.trace.mu.Unlock()
+	return resp, err
+}
+
+// turvoResponseSummary keeps the start of a failed response, or the IDs and
+// status a successful one reports
+func turvoResponseSummary(status int, body []byte) string {
+	if status >= 400 {
+		text := strings.TrimSpace(string(body))
+		if len(text) > maxAuditSummaryBytes {
+			text = text[:maxAuditSummaryBytes] + "..."
+		}
+		return text
+	}
+
+	var response map[string]interface{}
+	if json.Unmarshal(body, &response) != nil {
+		return ""
+	}
+	if details, ok := response["details"].(map[string]interface{}); ok {
+		for key, value := range details {
+			if _, exists := response[key]; !exists {
+				response[key] = value
+			}
+		}
+	}
+	parts := []string{}
+	for _, key := range []string{"Status", "status", "shipmentId", "id", "customId", "message"} {
+		switch value := response[key].(type) {
+		case string:
+			if value != "" {
+				parts = append(parts, key+"="+value)
+			}
+		case float64:
+			parts = append(parts, fmt.Sprintf("%s=%.0f", key, value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
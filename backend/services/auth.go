@@ -0,0 +1,272 @@
+package services
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// jwtLeeway allows for clock skew when checking token expiry and not-before times
+const jwtLeeway = time.Minute
+
+// Errors returned when a caller cannot be authenticated
+var (
+	ErrMissingCredentials = errors.New("authentication required: send an X-API-Key header or a Bearer token")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// AuthService authenticates callers by hashed static API keys or by JWT
+// bearer tokens signed with HS256 or with RS256 keys from a JWKS file
+type AuthService struct {
+	disabled   bool
+	apiKeys    []types.APIKey
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey
+	issuer     string
+	audience   string
+}
+
+// jwtHeader is the decoded JOSE header of a token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the registered and identity claims read from a token
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Name      string          `json:"name"`
+	Email     string          `json:"email"`
+	Roles     []string        `json:"roles"`
+	TenantID  string          `json:"tenant_id"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+}
+
+// NewAuthService creates the auth service, loading API keys and JWKS signing keys from the configured files
+func NewAuthService(cfg *config.Config) *AuthService {
+	service := &AuthService{
+		disabled:   cfg.AuthDisabled,
+		hmacSecret: []byte(cfg.JWTHS256Secret),
+		rsaKeys:    make(map[string]*rsa.PublicKey),
+		issuer:     cfg.JWTIssuer,
+		audience:   cfg.JWTAudience,
+	}
+	if service.disabled {
+		fmt.Printf("DEBUG: AUTH_DISABLED is set, the API accepts unauthenticated requests\n")
+		return service
+	}
+
+	if cfg.APIKeysFile != "" {
+		if err := readJSONFile(cfg.APIKeysFile, &service.apiKeys); err != nil {
+			fmt.Printf("DEBUG: Failed to load API keys from %s: %v\n", cfg.APIKeysFile, err)
+		}
+		for i, key := range service.apiKeys {
+			service.apiKeys[i].KeyHash = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(key.KeyHash), "sha256:"))
+		}
+	}
+	if cfg.JWTJWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWTJWKSFile)
+		if err != nil {
+			fmt.Printf("DEBUG: %v\n", err)
+		}
+		service.rsaKeys = keys
+	}
+
+	fmt.Printf("DEBUG: Loaded %d API keys and %d JWKS keys (HS256 %t)\n", len(service.apiKeys), len(service.rsaKeys), len(service.hmacSecret) > 0)
+	return service
+}
+
+// Disabled reports whether authentication is turned off for local development
+func (s *AuthService) Disabled() bool {
+	return s.disabled
+}
+
+// HashAPIKey returns the hex SHA-256 stored for an API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuthenticateAPIKey matches a key against the configured key hashes
+func (s *AuthService) AuthenticateAPIKey(key string) (types.Identity, error) {
+	hash := []byte(HashAPIKey(strings.TrimSpace(key)))
+	for _, apiKey := range s.apiKeys {
+		if subtle.ConstantTimeCompare(hash, []byte(apiKey.KeyHash)) != 1 {
+			continue
+		}
+		if apiKey.Disabled {
+			return types.Identity{}, fmt.Errorf("%w: API key %s is disabled", ErrInvalidCredentials, apiKey.ID)
+		}
+		return types.Identity{
+			Subject:  "apikey:" + apiKey.ID,
+			Name:     apiKey.Name,
+			Method:   types.AuthMethodAPIKey,
+			Roles:    apiKey.Roles,
+			TenantID: apiKey.TenantID,
+		}, nil
+	}
+	return types.Identity{}, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+}
+
+// AuthenticateBearer verifies a JWT's signature, expiry, issuer and audience
+// and returns the identity in its claims
+func (s *AuthService) AuthenticateBearer(token string) (types.Identity, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return types.Identity{}, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return types.Identity{}, fmt.Errorf("%w: malformed token header", ErrInvalidCredentials)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return types.Identity{}, fmt.Errorf("%w: malformed token signature", ErrInvalidCredentials)
+	}
+	if err := s.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return types.Identity{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return types.Identity{}, fmt.Errorf("%w: malformed token claims", ErrInvalidCredentials)
+	}
+	if err := s.checkClaims(claims, time.Now()); err != nil {
+		return types.Identity{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	return types.Identity{
+		Subject:  claims.Subject,
+		Name:     claims.Name,
+		Email:    claims.Email,
+		Method:   types.AuthMethodJWT,
+		Roles:    claims.Roles,
+		TenantID: claims.TenantID,
+	}, nil
+}
+
+// verifySignature checks a token signature with the key for its algorithm.
+// Only HS256 and RS256 are accepted.
+func (s *AuthService) verifySignature(header jwtHeader, signingInput string, signature []byte) error {
+	switch header.Alg {
+	case "HS256":
+		if len(s.hmacSecret) == 0 {
+			return fmt.Errorf("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, s.hmacSecret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("bad signature")
+		}
+		return nil
+	case "RS256":
+		key, ok := s.rsaKeys[header.Kid]
+		if !ok && header.Kid == "" && len(s.rsaKeys) == 1 {
+			for _, only := range s.rsaKeys {
+				key, ok = only, true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("unknown signing key %q", header.Kid)
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("bad signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+}
+
+// checkClaims requires an unexpired token and, when configured, the expected issuer and audience
+func (s *AuthService) checkClaims(claims jwtClaims, now time.Time) error {
+	if claims.Subject == "" {
+		return fmt.Errorf("token has no subject")
+	}
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("token has no expiry")
+	}
+	if now.After(time.Unix(int64(*claims.ExpiresAt), 0).Add(jwtLeeway)) {
+		return fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(int64(*claims.NotBefore), 0)) {
+		return fmt.Errorf("token is not valid yet")
+	}
+	if s.issuer != "" && claims.Issuer != s.issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if s.audience != "" && !hasAudience(claims.Audience, s.audience) {
+		return fmt.Errorf("token is not for audience %q", s.audience)
+	}
+	return nil
+}
+
+// hasAudience reports whether an aud claim, a string or an array, includes audience
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, value := range list {
+			if value == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeJWTPart decodes a base64url JSON token segment
+func decodeJWTPart(part string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// loadJWKS reads the RSA signing keys from a JWKS file, keyed by key ID
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	keys := make(map[string]*rsa.PublicKey)
+	var set types.JSONWebKeySet
+	if err := readJSONFile(path, &set); err != nil {
+		return keys, fmt.Errorf("failed to load JWKS from %s: %w", path, err)
+	}
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != "RS256") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return keys, fmt.Errorf("failed to decode JWKS key %q modulus: %w", jwk.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return keys, fmt.Errorf("failed to decode JWKS key %q exponent: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+const testHS256Secret = "test-hs256-secret"
+
+// encodeJWTPart base64url-encodes a JSON token segment
+func encodeJWTPart(t *testing.T, value interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("failed to marshal token part: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signHS256 builds an HS256 token over claims with secret
+func signHS256(t *testing.T, header map[string]interface{}, claims map[string]interface{}, secret []byte) string {
+	t.Helper()
+	input := encodeJWTPart(t, header) + "." + encodeJWTPart(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 builds an RS256 token over claims with key
+func signRS256(t *testing.T, kid string, claims map[string]interface{}, key *rsa.PrivateKey) string {
+	t.Helper()
+	input := encodeJWTPart(t, map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + encodeJWTPart(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns claims that pass every check for the test service
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "user-1",
+		"roles": []string{types.RoleAdmin},
+		"iss":   "https://issuer.example.com",
+		"aud":   "turvo-app",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// newTestAuthService builds a service that accepts HS256 and, when key is
+// set, RS256 tokens signed by it under kid "key-1"
+func newTestAuthService(t *testing.T, hs256Secret string, key *rsa.PrivateKey) *AuthService {
+	t.Helper()
+	cfg := &config.Config{
+		JWTHS256Secret: hs256Secret,
+		JWTIssuer:      "https://issuer.example.com",
+		JWTAudience:    "turvo-app",
+	}
+	if key != nil {
+		set := types.JSONWebKeySet{Keys: []types.JSONWebKey{{
+			Kty: "RSA",
+			Kid: "key-1",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}}
+		data, err := json.Marshal(set)
+		if err != nil {
+			t.Fatalf("failed to marshal JWKS: %v", err)
+		}
+		cfg.JWTJWKSFile = filepath.Join(t.TempDir(), "jwks.json")
+		if err := os.WriteFile(cfg.JWTJWKSFile, data, 0600); err != nil {
+			t.Fatalf("failed to write JWKS: %v", err)
+		}
+	}
+	return NewAuthService(cfg)
+}
+
+func TestAuthenticateBearerAcceptsValidTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	service := newTestAuthService(t, testHS256Secret, key)
+
+	tokens := map[string]string{
+		"HS256": signHS256(t, map[string]interface{}{"alg": "HS256", "typ": "JWT"}, validClaims(), []byte(testHS256Secret)),
+		"RS256": signRS256(t, "key-1", validClaims(), key),
+	}
+	for name, token := range tokens {
+		identity, err := service.AuthenticateBearer(token)
+		if err != nil {
+			t.Errorf("%s: expected token to be accepted, got %v", name, err)
+			continue
+		}
+		if identity.Subject != "user-1" || identity.Method != types.AuthMethodJWT {
+			t.Errorf("%s: unexpected identity %+v", name, identity)
+		}
+	}
+}
+
+func TestAuthenticateBearerRejectsAlgorithmConfusion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	// No HS256 secret: only the RSA key may sign tokens
+	service := newTestAuthService(t, "", key)
+
+	publicKey := key.N.Bytes()
+	noneToken := encodeJWTPart(t, map[string]interface{}{"alg": "none", "typ": "JWT"}) + "." + encodeJWTPart(t, validClaims()) + "."
+	tokens := map[string]string{
+		"HS256 signed with the RSA public key": signHS256(t, map[string]interface{}{"alg": "HS256", "kid": "key-1"}, validClaims(), publicKey),
+		"HS256 signed with an empty secret":    signHS256(t, map[string]interface{}{"alg": "HS256"}, validClaims(), nil),
+		"alg none":                             noneToken,
+		"alg NONE":                             encodeJWTPart(t, map[string]interface{}{"alg": "NONE"}) + "." + encodeJWTPart(t, validClaims()) + ".",
+		"RS512 header":                         encodeJWTPart(t, map[string]interface{}{"alg": "RS512", "kid": "key-1"}) + "." + encodeJWTPart(t, validClaims()) + ".c2ln",
+	}
+	for name, token := range tokens {
+		if _, err := service.AuthenticateBearer(token); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", name, err)
+		}
+	}
+
+	// An RS256 token signed by a different key, or naming an unknown kid, is rejected
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	if _, err := service.AuthenticateBearer(signRS256(t, "key-1", validClaims(), other)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("foreign RS256 key: expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := service.AuthenticateBearer(signRS256(t, "key-2", validClaims(), key)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown kid: expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestAuthenticateBearerChecksExpiryAndNotBefore(t *testing.T) {
+	service := newTestAuthService(t, testHS256Secret, nil)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		edit   func(claims map[string]interface{})
+		accept bool
+	}{
+		{"missing exp", func(claims map[string]interface{}) { delete(claims, "exp") }, false},
+		{"expired", func(claims map[string]interface{}) { claims["exp"] = now.Add(-time.Hour).Unix() }, false},
+		{"expired within leeway", func(claims map[string]interface{}) { claims["exp"] = now.Add(-jwtLeeway / 2).Unix() }, true},
+		{"nbf in the future", func(claims map[string]interface{}) { claims["nbf"] = now.Add(time.Hour).Unix() }, false},
+		{"nbf within leeway", func(claims map[string]interface{}) { claims["nbf"] = now.Add(jwtLeeway / 2).Unix() }, true},
+		{"nbf in the past", func(claims map[string]interface{}) { claims["nbf"] = now.Add(-time.Hour).Unix() }, true},
+	}
+	for _, test := range tests {
+		claims := validClaims()
+		test.edit(claims)
+		token := signHS256(t, map[string]interface{}{"alg": "HS256"}, claims, []byte(testHS256Secret))
+		_, err := service.AuthenticateBearer(token)
+		if test.accept && err != nil {
+			t.Errorf("%s: expected token to be accepted, got %v", test.name, err)
+		}
+		if !test.accept && !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", test.name, err)
+		}
+	}
+}
+
+func TestAuthenticateBearerChecksAudience(t *testing.T) {
+	service := newTestAuthService(t, testHS256Secret, nil)
+
+	tests := []struct {
+		name     string
+		audience interface{}
+		accept   bool
+	}{
+		{"matching string", "turvo-app", true},
+		{"other string", "other-app", false},
+		{"array containing the audience", []string{"other-app", "turvo-app"}, true},
+		{"array without the audience", []string{"other-app", "third-app"}, false},
+		{"empty array", []string{}, false},
+		{"missing", nil, false},
+		{"wrong type", 42, false},
+	}
+	for _, test := range tests {
+		claims := validClaims()
+		if test.audience == nil {
+			delete(claims, "aud")
+		} else {
+			claims["aud"] = test.audience
+		}
+		token := signHS256(t, map[string]interface{}{"alg": "HS256"}, claims, []byte(testHS256Secret))
+		_, err := service.AuthenticateBearer(token)
+		if test.accept && err != nil {
+			t.Errorf("%s: expected token to be accepted, got %v", test.name, err)
+		}
+		if !test.accept && !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: expected ErrInvalidCredentials, got %v", test.name, err)
+		}
+	}
+}
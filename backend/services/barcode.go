@@ -0,0 +1,74 @@
+package services
+
+import "fmt"
+
+// code128Patterns holds the bar/space module widths for Code 128 symbol values 0-106
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code 128 control symbol values
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// code128Modules encodes a value with Code 128 subset B and returns the
+// alternating bar/space widths in modules, starting with a bar
+func code128Modules(value string) ([]int, error) {
+	symbols := []int{code128StartB}
+	checksum := code128StartB
+	for i, r := range value {
+		if r < 32 || r > 127 {
+			return nil, fmt.Errorf("character %q cannot be encoded in Code 128 subset B", r)
+		}
+		symbol := int(r) - 32
+		symbols = append(symbols, symbol)
+		checksum += symbol * (i + 1)
+	}
+	symbols = append(symbols, checksum%103, code128Stop)
+
+	modules := []int{}
+	for _, symbol := range symbols {
+		for _, width := range code128Patterns[symbol] {
+			modules = append(modules, int(width-'0'))
+		}
+	}
+	return modules, nil
+}
+
+// barcode draws a Code 128 barcode with its top-left corner at x, y,
+// scaled to the given width, with the value printed underneath
+func (p *pdfPage) barcode(x, y, width, height float64, value string) error {
+	modules, err := code128Modules(value)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, m := range modules {
+		total += m
+	}
+	moduleWidth := width / float64(total)
+
+	cursor := x
+	for i, m := range modules {
+		w := float64(m) * moduleWidth
+		if i%2 == 0 {
+			p.rect(cursor, y, w, height, true)
+		}
+		cursor += w
+	}
+	p.textCentered(x+width/2, y+height+9, 8, false, value)
+	return nil
+}
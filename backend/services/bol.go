@@ -0,0 +1,284 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"turvo-app/types"
+)
+
+// bolItem is one line in the carrier information table
+type bolItem struct {
+	HandlingQty  int
+	HandlingType string
+	PackageQty   int
+	PackageType  string
+	Weight       float64
+	Hazmat       bool
+	Description  string
+	NMFC         string
+	Class        string
+}
+
+// bolColumns are the carrier information table columns, summing to docWidth
+var bolColumns = []docColumn{
+	{Label: "HU Qty", Width: 40, Right: true},
+	{Label: "HU Type", Width: 50},
+	{Label: "Pkg Qty", Width: 40, Right: true},
+	{Label: "Pkg Type", Width: 50},
+	{Label: "Weight (lb)", Width: 60, Right: true},
+	{Label: "HM", Width: 24},
+	{Label: "Commodity Description", Width: 176},
+	{Label: "NMFC #", Width: 60},
+	{Label: "Class", Width: 40},
+}
+
+// RenderBOL renders a bill of lading PDF for a load using the template
+// matching its customer, or the template named by templateID when given
+func (s *DocumentService) RenderBOL(load types.Load, templateID string) ([]byte, error) {
+	template, err := s.bolTemplateFor(load, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := newPDFDocument()
+	page := doc.addPage()
+	bolNumber := firstKnown(load.ExternalTMSLoadID, load.FreightLoadID)
+	if bolNumber == "" {
+		return nil, fmt.Errorf("load has no shipment ID to print on the BOL")
+	}
+
+	// Header
+	page.textCentered(pdfPageWidth/2, 44, docHeaderSize, true, template.Title)
+	y := 66.0
+	if template.CompanyName != "" {
+		page.text(docMargin, y, 10, true, template.CompanyName)
+		y += docLineHeight
+		for _, line := range nonEmpty(template.CompanyAddress, template.CompanyPhone) {
+			page.text(docMargin, y, docBodySize, false, line)
+			y += docLineHeight
+		}
+	}
+	right := docMargin + docWidth
+	page.textRight(right, 66, 10, true, "BOL #: "+bolNumber)
+	page.textRight(right, 77, docBodySize, false, "Date: "+time.Now().Format("Jan 2, 2006"))
+	if err := page.barcode(right-190, 84, 190, 30, bolNumber); err != nil {
+		return nil, fmt.Errorf("failed to render barcode: %w", err)
+	}
+
+	// Parties
+	half := (docWidth - docGap) / 2
+	y = 134.0
+	shipFrom := append(addressLines(load.Pickup.Name, load.Pickup.AddressLine1, load.Pickup.AddressLine2,
+		load.Pickup.City, load.Pickup.State, load.Pickup.Zipcode, load.Pickup.Country),
+		contactLine(load.Pickup.Contact, load.Pickup.Phone, load.Pickup.Email))
+	shipFrom = append(shipFrom, labeled("Ready", documentTime(load.Pickup.ReadyTime)), labeled("Appt", documentTime(load.Pickup.ApptTime)))
+	shipTo := append(addressLines(load.Consignee.Name, load.Consignee.AddressLine1, load.Consignee.AddressLine2,
+		load.Consignee.City, load.Consignee.State, load.Consignee.Zipcode, load.Consignee.Country),
+		contactLine(load.Consignee.Contact, load.Consignee.Phone, load.Consignee.Email))
+	shipTo = append(shipTo, labeled("Appt", documentTime(load.Consignee.ApptTime)), labeled("Must deliver", knownValue(load.Consignee.MustDeliver)))
+	height := maxFloat(boxHeight(shipFrom), boxHeight(shipTo))
+	drawBox(page, docMargin, y, half, height, "SHIP FROM", shipFrom)
+	drawBox(page, docMargin+half+docGap, y, half, height, "SHIP TO", shipTo)
+	y += height + docGap
+
+	carrier := nonEmpty(
+		knownValue(load.Carrier.Name),
+		labeled("SCAC", knownValue(load.Carrier.SCAC)),
+		strings.Join(nonEmpty(labeled("MC", knownValue(load.Carrier.MCNumber)), labeled("DOT", knownValue(load.Carrier.DOTNumber))), "   "),
+		strings.Join(nonEmpty(labeled("Truck", knownValue(load.Carrier.ExternalTMSTruckID)), labeled("Trailer", knownValue(load.Carrier.ExternalTMSTrailerID))), "   "),
+		labeled("Seal #", knownValue(load.Carrier.SealNumber)),
+		labeled("Driver", knownValue(load.Carrier.FirstDriverName)),
+	)
+	if template.HideBillTo {
+		height = boxHeight(carrier)
+		drawBox(page, docMargin, y, docWidth, height, "CARRIER", carrier)
+	} else {
+		billTo := append(addressLines(load.BillTo.Name, load.BillTo.AddressLine1, load.BillTo.AddressLine2,
+			load.BillTo.City, load.BillTo.State, load.BillTo.Zipcode, load.BillTo.Country),
+			contactLine(load.BillTo.Contact, load.BillTo.Phone, load.BillTo.Email))
+		height = maxFloat(boxHeight(billTo), boxHeight(carrier))
+		drawBox(page, docMargin, y, half, height, "THIRD PARTY FREIGHT CHARGES BILL TO", billTo)
+		drawBox(page, docMargin+half+docGap, y, half, height, "CARRIER", carrier)
+	}
+	y += height + docGap
+
+	// References and instructions
+	references := nonEmpty(
+		labeled("PO #", knownValue(load.Specifications.PONums)),
+		labeled("Customer Ref #", knownValue(load.Customer.RefNumber)),
+		labeled("Load #", knownValue(load.FreightLoadID)),
+		labeled("Pickup #", knownValue(load.Pickup.RefNumber)),
+		labeled("Delivery #", knownValue(load.Consignee.RefNumber)),
+	)
+	y = drawWrappedBox(page, y, "REFERENCES", strings.Join(references, "  |  "))
+	y = drawWrappedBox(page, y, "SPECIAL INSTRUCTIONS", bolInstructions(load, template))
+
+	// Carrier information table
+	items := bolItems(load)
+	page, y = bolItemTable(doc, page, y, items)
+	if hazmat := bolHazmat(load); hazmat != "" {
+		lines := pdfWrap(hazmat, docWidth-8, docBodySize, false)
+		if y+14+float64(len(lines))*docBodySize*1.25+8 > docPageBottom {
+			page = doc.addPage()
+			y = docMargin
+		}
+		y = drawWrappedBox(page, y, "HAZARDOUS MATERIALS", hazmat)
+	}
+
+	// Terms and signatures
+	termsHeight := float64(len(pdfWrap(template.Terms, docWidth, 6.5, false)))*6.5*1.25 + 60
+	if y+termsHeight > docPageBottom {
+		page = doc.addPage()
+		y = docMargin
+	}
+	y = page.paragraph(docMargin, y+10, docWidth, 6.5, false, template.Terms)
+	drawSignatures(page, y+8, template.SignatureLabels)
+
+	return doc.bytes(), nil
+}
+
+// bolItems builds the carrier information rows for a load, one per commodity
+// or a single freight row for loads without commodities
+func bolItems(load types.Load) []bolItem {
+	specs := load.Specifications
+	if len(load.Commodities) > 0 {
+		items := make([]bolItem, 0, len(load.Commodities))
+		for _, commodity := range load.Commodities {
+			handlingUnit, _ := normalizeHandlingUnit(commodity.HandlingUnit)
+			description := firstKnown(commodity.Description, "Freight")
+			if commodity.Hazmat != nil {
+				description = hazmatDescription(*commodity.Hazmat)
+			}
+			items = append(items, bolItem{
+				HandlingQty:  commodity.HandlingUnitCount,
+				HandlingType: handlingUnitLabels[handlingUnit],
+				PackageQty:   commodity.PieceCount,
+				PackageType:  "Pieces",
+				Weight:       commodity.WeightLbs,
+				Hazmat:       commodity.Hazmat != nil,
+				Description:  description,
+				NMFC:         commodity.NMFC,
+				Class:        commodity.FreightClass,
+			})
+		}
+		return items
+	}
+
+	description := "Freight"
+	if specs.Hazmat {
+		description = "Freight - HAZARDOUS MATERIALS"
+	}
+	return []bolItem{
+		{
+			HandlingQty:  specs.InPalletCount,
+			HandlingType: "Pallets",
+			PackageQty:   specs.NumCommodities,
+			PackageType:  "Pieces",
+			Weight:       specs.TotalWeight,
+			Hazmat:       specs.Hazmat,
+			Description:  description,
+		},
+	}
+}
+
+// bolHazmat lists the shipping description, quantity, emergency contact and
+// placards for each hazmat commodity, or "" when the load has none
+func bolHazmat(load types.Load) string {
+	lines := []string{}
+	placards := []string{}
+	seen := map[string]bool{}
+	for _, commodity := range load.Commodities {
+		if commodity.Hazmat == nil {
+			continue
+		}
+		hazmat := *commodity.Hazmat
+		handlingUnit, _ := normalizeHandlingUnit(commodity.HandlingUnit)
+		line := fmt.Sprintf("%s - %d %s, %.0f lb", hazmatDescription(hazmat),
+			commodity.HandlingUnitCount, handlingUnitLabels[handlingUnit], commodity.WeightLbs)
+		if contact := strings.Join(nonEmpty(hazmat.EmergencyContact, hazmat.EmergencyPhone), " "); contact != "" {
+			line += " - Emergency contact: " + contact
+		}
+		lines = append(lines, line)
+		if hazmat.PlacardRequired && hazmat.Placard != "" && !seen[hazmat.Placard] {
+			seen[hazmat.Placard] = true
+			placards = append(placards, hazmat.Placard)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	if len(placards) > 0 {
+		lines = append(lines, "Placards required: "+strings.Join(placards, ", "))
+	} else {
+		lines = append(lines, "Placards required: None")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// bolInstructions combines appointment notes, services and template instructions
+func bolInstructions(load types.Load, template types.BOLTemplate) string {
+	parts := []string{}
+	if services := specialServices(load.Specifications); len(services) > 0 {
+		parts = append(parts, "Services: "+strings.Join(services, ", "))
+	}
+	specs := load.Specifications
+	if minTemp, maxTemp, ok := temperatureRange(specs); ok {
+		parts = append(parts, fmt.Sprintf("Temperature: %.0fF to %.0fF", minTemp, maxTemp))
+	}
+	if note := knownValue(load.Pickup.ApptNote); note != "" {
+		parts = append(parts, "Pickup: "+note)
+	}
+	if note := knownValue(load.Consignee.ApptNote); note != "" {
+		parts = append(parts, "Delivery: "+note)
+	}
+	if template.Instructions != "" {
+		parts = append(parts, template.Instructions)
+	}
+	if len(parts) == 0 {
+		return "None"
+	}
+	return strings.Join(parts, "\n")
+}
+
+// bolItemTable draws the carrier information table, continuing on new pages as needed
+func bolItemTable(doc *pdfDocument, page *pdfPage, y float64, items []bolItem) (*pdfPage, float64) {
+	y = drawTableHeader(page, y, "CARRIER INFORMATION", bolColumns)
+	totalHandling, totalPackages, totalWeight := 0, 0, 0.0
+	for _, item := range items {
+		if y+docLineHeight*3 > docPageBottom {
+			page = doc.addPage()
+			y = drawTableHeader(page, docMargin, "CARRIER INFORMATION", bolColumns)
+		}
+		hazmat := ""
+		if item.Hazmat {
+			hazmat = "X"
+		}
+		values := []string{
+			fmt.Sprintf("%d", item.HandlingQty), item.HandlingType,
+			fmt.Sprintf("%d", item.PackageQty), item.PackageType,
+			fmt.Sprintf("%.0f", item.Weight), hazmat, item.Description, item.NMFC, item.Class,
+		}
+		x := docMargin
+		for i, column := range bolColumns {
+			drawCell(page, x, y+docLineHeight, column, values[i], false)
+			x += column.Width
+		}
+		y += docLineHeight + 3
+		totalHandling += item.HandlingQty
+		totalPackages += item.PackageQty
+		totalWeight += item.Weight
+	}
+
+	page.line(docMargin, y+2, docMargin+docWidth, y+2, 0.75)
+	totals := []string{fmt.Sprintf("%d", totalHandling), "", fmt.Sprintf("%d", totalPackages), "", fmt.Sprintf("%.0f", totalWeight), "", "GRAND TOTAL", "", ""}
+	x := docMargin
+	for i, column := range bolColumns {
+		drawCell(page, x, y+docLineHeight+2, column, totals[i], true)
+		x += column.Width
+	}
+	y += docLineHeight + 6
+	page.rect(docMargin, y-docLineHeight-6, docWidth, docLineHeight+6, false)
+	return page, y
+}
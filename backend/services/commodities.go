@@ -0,0 +1,317 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"turvo-app/types"
+)
+
+// Handling unit types accepted on commodities
+const (
+	HandlingPallet = "pallet"
+	HandlingSkid   = "skid"
+	HandlingCrate  = "crate"
+	HandlingBox    = "box"
+	HandlingDrum   = "drum"
+	HandlingTote   = "tote"
+	HandlingBundle = "bundle"
+	HandlingPiece  = "piece"
+)
+
+// handlingUnitLabels are the printed names of each handling unit type
+var handlingUnitLabels = map[string]string{
+	HandlingBox:    "Boxes",
+	HandlingPiece:  "Pieces",
+	HandlingPallet: "Pallets",
+	HandlingSkid:   "Skids",
+	HandlingCrate:  "Crates",
+	HandlingDrum:   "Drums",
+	HandlingTote:   "Totes",
+	HandlingBundle: "Bundles",
+}
+
+// handlingUnitAliases are the accepted spellings of each handling unit type
+var handlingUnitAliases = map[string]string{
+	"":        HandlingPallet,
+	"pallet":  HandlingPallet,
+	"pallets": HandlingPallet,
+	"plt":     HandlingPallet,
+	"skid":    HandlingSkid,
+	"skids":   HandlingSkid,
+	"crate":   HandlingCrate,
+	"crates":  HandlingCrate,
+	"box":     HandlingBox,
+	"boxes":   HandlingBox,
+	"carton":  HandlingBox,
+	"cartons": HandlingBox,
+	"ctn":     HandlingBox,
+	"drum":    HandlingDrum,
+	"drums":   HandlingDrum,
+	"tote":    HandlingTote,
+	"totes":   HandlingTote,
+	"bundle":  HandlingBundle,
+	"bundles": HandlingBundle,
+	"piece":   HandlingPiece,
+	"pieces":  HandlingPiece,
+	"pcs":     HandlingPiece,
+}
+
+// freightClasses are the NMFC freight classes in order
+var freightClasses = []string{
+	"50", "55", "60", "65", "70", "77.5", "85", "92.5", "100",
+	"110", "125", "150", "175", "200", "250", "300", "400", "500",
+}
+
+// nmfcPattern matches an NMFC item number with an optional sub, such as 156600-03
+var nmfcPattern = regexp.MustCompile(`^(\d{1,6})(?:-(\d{1,2}))?$`)
+
+// Trailer interior dimensions used to estimate linear feet
+const (
+	trailerInteriorWidthIn  = 100.0
+	trailerInteriorHeightIn = 108.0
+	cubicInchesPerFoot      = 1728.0
+)
+
+// ApplyCommodities fills the load totals, cube, density and linear feet from
+// its commodity list, and replaces each commodity's hazmat details with a
+// normalized copy. Loads without commodities keep the totals that were entered.
+func ApplyCommodities(specs *types.Specifications, commodities []types.Commodity) {
+	if len(commodities) == 0 {
+		return
+	}
+	specs.NumCommodities = len(commodities)
+	specs.TotalWeight = 0
+	specs.CubicFeet = 0
+	specs.LinearFeet = 0
+	for i, commodity := range commodities {
+		specs.TotalWeight += commodity.WeightLbs
+		specs.CubicFeet += commodityCubicFeet(commodity)
+		specs.LinearFeet += commodityLinearFeet(commodity)
+		if commodity.Hazmat != nil {
+			hazmat := *commodity.Hazmat
+			normalizeHazmat(&hazmat)
+			commodities[i].Hazmat = &hazmat
+			specs.Hazmat = true
+		}
+	}
+	specs.TotalWeight = math.Round(specs.TotalWeight*100) / 100
+	specs.CubicFeet = math.Round(specs.CubicFeet*100) / 100
+	specs.LinearFeet = math.Round(specs.LinearFeet*10) / 10
+	specs.DensityPcf = 0
+	if specs.CubicFeet > 0 {
+		specs.DensityPcf = math.Round(specs.TotalWeight/specs.CubicFeet*100) / 100
+	}
+}
+
+// commodityCubicFeet returns the volume of a commodity's handling units
+func commodityCubicFeet(commodity types.Commodity) float64 {
+	units := commodity.HandlingUnitCount
+	if units <= 0 {
+		units = 1
+	}
+	return commodity.LengthIn * commodity.WidthIn * commodity.HeightIn / cubicInchesPerFoot * float64(units)
+}
+
+// commodityLinearFeet returns the trailer length a commodity's handling units
+// occupy when loaded side by side across the trailer and, if stackable,
+// stacked as high as the trailer allows
+func commodityLinearFeet(commodity types.Commodity) float64 {
+	if commodity.LengthIn <= 0 || commodity.WidthIn <= 0 || commodity.HeightIn <= 0 {
+		return 0
+	}
+	units := commodity.HandlingUnitCount
+	if units <= 0 {
+		units = 1
+	}
+	across := math.Max(1, math.Floor(trailerInteriorWidthIn/commodity.WidthIn))
+	stack := 1.0
+	if commodity.Stackable {
+		stack = math.Max(1, math.Floor(trailerInteriorHeightIn/commodity.HeightIn))
+	}
+	rows := math.Ceil(math.Ceil(float64(units)/stack) / across)
+	return rows * commodity.LengthIn / 12
+}
+
+// turvoItems converts a load's commodities to Turvo items. Loads without
+// commodities are sent as a single freight item sized by the pallet count,
+// weighed only when the account has a weight unit code.
+func turvoItems(load types.Load, codes *TurvoCodeTable) []types.TurvoItem {
+	specs := load.Specifications
+	minTemp, maxTemp := itemTemperatures(load, codes)
+	notes := fmt.Sprintf("PO: %s, Operator: %s", specs.PONums, specs.Operator)
+	pallets, _ := codes.HandlingUnit(HandlingPallet)
+	pieces, _ := codes.HandlingUnit(HandlingPiece)
+	pounds := codes.codes.WeightUnit
+
+	if len(load.Commodities) == 0 {
+		item := types.TurvoItem{
+			ItemCategory: types.TurvoCode{Key: "22300", Value: "Other"},
+			Qty:          specs.InPalletCount,
+			Unit:         pallets,
+			Name:         "Freight",
+			Notes:        notes,
+			Operation:    0,
+			IsHazmat:     specs.Hazmat,
+			MinTemp:      minTemp,
+			MaxTemp:      maxTemp,
+			Stackable:    true,
+			Value:        int(load.RateData.CustomerLhRateUsd * 100), // Convert to cents
+			TotalValue:   int(load.RateData.CustomerLhRateUsd * float64(specs.InPalletCount) * 100),
+			Currency:     types.TurvoCode{Key: "1550", Value: "USD"},
+		}
+		if pounds.Key != "" {
+			item.GrossWeight = specs.TotalWeight
+			item.WeightUnits = pounds
+		}
+		return []types.TurvoItem{item}
+	}
+
+	items := make([]types.TurvoItem, 0, len(load.Commodities))
+	for _, commodity := range load.Commodities {
+		handlingUnit, _ := normalizeHandlingUnit(commodity.HandlingUnit)
+		class, _ := codes.FreightClass(commodity.FreightClass)
+		handlingCode, _ := codes.HandlingUnit(handlingUnit)
+		nmfc, nmfcSub, _ := splitNMFC(commodity.NMFC)
+
+		item := types.TurvoItem{
+			ItemCategory: types.TurvoCode{Key: "22300", Value: "Other"},
+			Qty:          commodity.PieceCount,
+			Unit:         pieces,
+			HandlingQty:  commodity.HandlingUnitCount,
+			HandlingUnit: handlingCode,
+			Name:         firstKnown(commodity.Description, "Freight"),
+			Notes:        notes,
+			Operation:    0,
+			NMFC:         nmfc,
+			NMFCSub:      nmfcSub,
+			IsHazmat:     commodity.Hazmat != nil,
+			Stackable:    commodity.Stackable,
+			FreightClass: class,
+			MinTemp:      minTemp,
+			MaxTemp:      maxTemp,
+			GrossWeight:  commodity.WeightLbs,
+			WeightUnits:  pounds,
+		}
+		if commodity.LengthIn > 0 || commodity.WidthIn > 0 || commodity.HeightIn > 0 {
+			item.Dimensions = types.TurvoDimensions{
+				Length: int(math.Round(commodity.LengthIn)),
+				Width:  int(math.Round(commodity.WidthIn)),
+				Height: int(math.Round(commodity.HeightIn)),
+				Units:  codes.codes.DimensionUnit,
+			}
+		}
+		if commodity.Hazmat != nil {
+			item.Notes += "\n" + hazmatNote(*commodity.Hazmat)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// CommoditiesFromTurvo reads a load's commodities back from Turvo items.
+// The single freight item sent for loads without commodities is skipped.
+func CommoditiesFromTurvo(items []types.TurvoItem, codes *TurvoCodeTable) []types.Commodity {
+	commodities := []types.Commodity{}
+	for _, item := range items {
+		if item.HandlingUnit.Key == "" && item.FreightClass.Key == "" && item.Name == "Freight" {
+			continue
+		}
+
+		commodity := types.Commodity{
+			Description:       item.Name,
+			PieceCount:        item.Qty,
+			HandlingUnitCount: item.HandlingQty,
+			WeightLbs:         item.GrossWeight,
+			LengthIn:          float64(item.Dimensions.Length),
+			WidthIn:           float64(item.Dimensions.Width),
+			HeightIn:          float64(item.Dimensions.Height),
+			NMFC:              item.NMFC,
+			Stackable:         item.Stackable,
+		}
+		commodity.HandlingUnit, _ = codeName(codes.codes.HandlingUnits, item.HandlingUnit.Key)
+		commodity.FreightClass, _ = codeName(codes.codes.FreightClasses, item.FreightClass.Key)
+		if item.NMFCSub != "" {
+			commodity.NMFC += "-" + item.NMFCSub
+		}
+		if item.IsHazmat {
+			commodity.Hazmat = parseHazmatNote(item.Notes)
+		}
+		commodities = append(commodities, commodity)
+	}
+	return commodities
+}
+
+// normalizeHandlingUnit maps a handling unit type or its alias to its canonical
+// name. An empty type is a pallet.
+func normalizeHandlingUnit(handlingUnit string) (string, bool) {
+	canonical, ok := handlingUnitAliases[strings.ToLower(strings.TrimSpace(handlingUnit))]
+	return canonical, ok
+}
+
+// normalizeFreightClass maps a freight class such as "077.50" to its NMFC
+// spelling, "77.5". An empty class is valid and stays empty.
+func normalizeFreightClass(class string) (string, bool) {
+	class = strings.TrimSpace(class)
+	if class == "" {
+		return "", true
+	}
+	value, err := strconv.ParseFloat(class, 64)
+	if err != nil {
+		return "", false
+	}
+	for _, known := range freightClasses {
+		if number, _ := strconv.ParseFloat(known, 64); number == value {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+// splitNMFC splits an NMFC number into its item and sub. An empty number is valid.
+func splitNMFC(nmfc string) (string, string, bool) {
+	nmfc = strings.TrimSpace(nmfc)
+	if nmfc == "" {
+		return "", "", true
+	}
+	match := nmfcPattern.FindStringSubmatch(nmfc)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// validateCommodities checks each commodity's counts, weight, dimensions,
+// handling unit, freight class and NMFC number
+func validateCommodities(commodities []types.Commodity, add func(field, format string, args ...interface{})) {
+	for i, commodity := range commodities {
+		field := func(name string) string {
+			return fmt.Sprintf("commodities[%d].%s", i, name)
+		}
+		if commodity.PieceCount < 0 {
+			add(field("pieceCount"), "cannot be negative")
+		}
+		if commodity.HandlingUnitCount < 0 {
+			add(field("handlingUnitCount"), "cannot be negative")
+		}
+		if commodity.WeightLbs <= 0 {
+			add(field("weightLbs"), "must be greater than zero")
+		}
+		if commodity.LengthIn < 0 || commodity.WidthIn < 0 || commodity.HeightIn < 0 {
+			add(field("lengthIn"), "dimensions cannot be negative")
+		}
+		if _, ok := normalizeHandlingUnit(commodity.HandlingUnit); !ok {
+			add(field("handlingUnit"), "unsupported handling unit %q", commodity.HandlingUnit)
+		}
+		if _, ok := normalizeFreightClass(commodity.FreightClass); !ok {
+			add(field("freightClass"), "unsupported freight class %q (expected one of %s)",
+				commodity.FreightClass, strings.Join(freightClasses, ", "))
+		}
+		if _, _, ok := splitNMFC(commodity.NMFC); !ok {
+			add(field("nmfc"), "invalid NMFC number %q (expected digits with an optional -sub, such as 156600-03)", commodity.NMFC)
+		}
+	}
+}
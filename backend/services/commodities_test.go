@@ -0,0 +1,178 @@
+package services
+
+import (
+	"testing"
+
+	"turvo-app/types"
+)
+
+func TestApplyCommodities(t *testing.T) {
+	pallets := types.Commodity{HandlingUnitCount: 4, WeightLbs: 2000, LengthIn: 48, WidthIn: 40, HeightIn: 48}
+	stacked := pallets
+	stacked.Stackable = true
+
+	tests := []struct {
+		name        string
+		commodities []types.Commodity
+		want        types.Specifications
+	}{
+		{
+			name:        "without commodities the entered totals stand",
+			commodities: nil,
+			want:        types.Specifications{TotalWeight: 1000, NumCommodities: 3},
+		},
+		{
+			name:        "two pallets across the trailer in two rows",
+			commodities: []types.Commodity{pallets},
+			want:        types.Specifications{NumCommodities: 1, TotalWeight: 2000, CubicFeet: 213.33, DensityPcf: 9.38, LinearFeet: 8},
+		},
+		{
+			name:        "stackable pallets take one row",
+			commodities: []types.Commodity{stacked},
+			want:        types.Specifications{NumCommodities: 1, TotalWeight: 2000, CubicFeet: 213.33, DensityPcf: 9.38, LinearFeet: 4},
+		},
+		{
+			name:        "a commodity without dimensions adds only weight",
+			commodities: []types.Commodity{pallets, {WeightLbs: 500.5, Hazmat: &types.Hazmat{HazardClass: " 3 "}}},
+			want:        types.Specifications{NumCommodities: 2, TotalWeight: 2500.5, CubicFeet: 213.33, DensityPcf: 11.72, LinearFeet: 8, Hazmat: true},
+		},
+	}
+	for _, test := range tests {
+		specs := types.Specifications{TotalWeight: 1000, NumCommodities: 3}
+		ApplyCommodities(&specs, test.commodities)
+		if specs != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, specs, test.want)
+		}
+	}
+}
+
+func TestApplyCommoditiesCopiesHazmat(t *testing.T) {
+	hazmat := &types.Hazmat{UNNumber: "un 1993", HazardClass: " 3 ", PackingGroup: "pg ii", PlacardRequired: true}
+	commodities := []types.Commodity{{WeightLbs: 500, Hazmat: hazmat}}
+
+	ApplyCommodities(&types.Specifications{}, commodities)
+	if got := commodities[0].Hazmat; got == hazmat || got.UNNumber != "UN1993" || got.PackingGroup != "II" || got.Placard != "FLAMMABLE" {
+		t.Errorf("expected a normalized copy of the hazmat details, got %+v", got)
+	}
+	if hazmat.UNNumber != "un 1993" || hazmat.Placard != "" {
+		t.Errorf("expected the caller's hazmat details to be left alone, got %+v", hazmat)
+	}
+}
+
+func TestHazmatNoteRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		hazmat types.Hazmat
+		want   types.Hazmat
+	}{
+		{
+			name:   "punctuation in names survives",
+			hazmat: types.Hazmat{UNNumber: "UN1993", ProperShippingName: "Flammable liquid, n.o.s.; (contains xylene)", HazardClass: "3", PackingGroup: "II", EmergencyContact: "CHEMTREC; acct 12", EmergencyPhone: "800-424-9300", PlacardRequired: true, Placard: "FLAMMABLE"},
+			want:   types.Hazmat{UNNumber: "UN1993", ProperShippingName: "Flammable liquid, n.o.s.; (contains xylene)", HazardClass: "3", PackingGroup: "II", EmergencyContact: "CHEMTREC; acct 12", EmergencyPhone: "800-424-9300", PlacardRequired: true, Placard: "FLAMMABLE"},
+		},
+		{
+			name:   "placard dropped when not required",
+			hazmat: types.Hazmat{UNNumber: "UN1263", HazardClass: "3", Placard: "FLAMMABLE"},
+			want:   types.Hazmat{UNNumber: "UN1263", HazardClass: "3"},
+		},
+	}
+	for _, test := range tests {
+		notes := "Handle with care\n" + hazmatNote(test.hazmat)
+		if got := parseHazmatNote(notes); *got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, *got, test.want)
+		}
+	}
+	if got := parseHazmatNote("Hazmat: not details"); *got != (types.Hazmat{}) {
+		t.Errorf("expected empty details from an unreadable line, got %+v", *got)
+	}
+}
+
+func TestNormalizeFreightClass(t *testing.T) {
+	tests := []struct {
+		class  string
+		want   string
+		wantOK bool
+	}{
+		{"", "", true},
+		{" 77.5 ", "77.5", true},
+		{"077.50", "77.5", true},
+		{"100", "100", true},
+		{"100.0", "100", true},
+		{"75", "", false},
+		{"class 70", "", false},
+	}
+	for _, test := range tests {
+		got, ok := normalizeFreightClass(test.class)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("%q: got %q (%v), want %q (%v)", test.class, got, ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestValidateCommodities(t *testing.T) {
+	valid := types.Commodity{PieceCount: 10, HandlingUnit: "PLT", HandlingUnitCount: 2, WeightLbs: 800, FreightClass: "85", NMFC: "156600-03"}
+
+	tests := []struct {
+		name      string
+		commodity func(types.Commodity) types.Commodity
+		wantField string
+	}{
+		{"valid", func(c types.Commodity) types.Commodity { return c }, ""},
+		{"negative pieces", func(c types.Commodity) types.Commodity { c.PieceCount = -1; return c }, "commodities[0].pieceCount"},
+		{"no weight", func(c types.Commodity) types.Commodity { c.WeightLbs = 0; return c }, "commodities[0].weightLbs"},
+		{"negative dimension", func(c types.Commodity) types.Commodity { c.HeightIn = -4; return c }, "commodities[0].lengthIn"},
+		{"unknown handling unit", func(c types.Commodity) types.Commodity { c.HandlingUnit = "sack"; return c }, "commodities[0].handlingUnit"},
+		{"unknown freight class", func(c types.Commodity) types.Commodity { c.FreightClass = "75"; return c }, "commodities[0].freightClass"},
+		{"malformed NMFC", func(c types.Commodity) types.Commodity { c.NMFC = "1566-003"; return c }, "commodities[0].nmfc"},
+	}
+	for _, test := range tests {
+		fields := []string{}
+		validateCommodities([]types.Commodity{test.commodity(valid)}, func(field, format string, args ...interface{}) {
+			fields = append(fields, field)
+		})
+		if test.wantField == "" && len(fields) > 0 || test.wantField != "" && (len(fields) != 1 || fields[0] != test.wantField) {
+			t.Errorf("%s: got errors on %q, want %q", test.name, fields, test.wantField)
+		}
+	}
+}
+
+func TestCommoditiesRoundTrip(t *testing.T) {
+	codes := testCodes()
+	load := types.Load{Commodities: []types.Commodity{
+		{Description: "Widgets", PieceCount: 40, HandlingUnit: "skids", HandlingUnitCount: 2, WeightLbs: 800, LengthIn: 48, WidthIn: 40, HeightIn: 40, FreightClass: "077.5", NMFC: "156600-03", Stackable: true},
+		{Description: "Gadgets", PieceCount: 5, HandlingUnit: "pcs", WeightLbs: 120, FreightClass: "100"},
+	}}
+
+	items := turvoItems(load, codes)
+	if len(items) != 2 {
+		t.Fatalf("expected one item per commodity, got %d", len(items))
+	}
+	first := items[0]
+	if first.HandlingUnit.Key != "H4" || first.Unit.Key != "H2" || first.FreightClass.Key != "C775" || first.NMFC != "156600" || first.NMFCSub != "03" ||
+		first.Dimensions.Units.Key != "DIN" || first.WeightUnits.Key != "WLB" {
+		t.Errorf("unexpected Turvo item %+v", first)
+	}
+	if items[1].Dimensions.Units.Key != "" {
+		t.Errorf("expected no dimensions on a commodity without them, got %+v", items[1].Dimensions)
+	}
+
+	commodities := CommoditiesFromTurvo(items, codes)
+	if len(commodities) != 2 {
+		t.Fatalf("expected both commodities back, got %+v", commodities)
+	}
+	if got := commodities[0]; got.HandlingUnit != HandlingSkid || got.FreightClass != "77.5" || got.NMFC != "156600-03" || got.LengthIn != 48 || !got.Stackable {
+		t.Errorf("unexpected commodity read back: %+v", got)
+	}
+	if got := commodities[1]; got.HandlingUnit != HandlingPiece || got.FreightClass != "100" {
+		t.Errorf("unexpected commodity read back: %+v", got)
+	}
+
+	// Loads without commodities are sent as one freight item that is not read back
+	freight := turvoItems(types.Load{Specifications: types.Specifications{InPalletCount: 20, TotalWeight: 30000}}, &TurvoCodeTable{codes: defaultTurvoCodes()})
+	if len(freight) != 1 || freight[0].Unit.Key != "6003" || freight[0].GrossWeight != 0 {
+		t.Errorf("expected a pallet freight item without a weight unit, got %+v", freight)
+	}
+	if got := CommoditiesFromTurvo(freight, codes); len(got) != 0 {
+		t.Errorf("expected the freight item to be skipped, got %+v", got)
+	}
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"turvo-app/types"
+)
+
+// Errors returned when a rate confirmation send cannot be recorded in Turvo
+var (
+	ErrNoCarrierOrder         = errors.New("shipment has no carrier order in Turvo")
+	ErrNoConfirmationSentCode = errors.New("no Turvo rateConfirmationSent external ID type is configured")
+)
+
+// DispatchShipment assigns the load's carrier, drivers, tractor and trailer
+// to the shipment's carrier order and, when the load has a carrier rate,
+// replaces the carrier costs with the computed carrier total. Without one the
+// order's existing costs are left alone. Drivers are matched in Turvo by name and phone
+// under the carrier, and created when no match exists. Drivers created for a
+// dispatch that fails are deleted again.
+func (s *TurvoService) DispatchShipment(shipment *types.TurvoShipment, load types.Load, pricing *types.PricingResult) (*types.DispatchResult, error) {
+	carrier := load.Carrier
+	carrierID, err := strconv.Atoi(strings.TrimSpace(carrier.ExternalTMSId))
+	if err != nil {
+		return nil, fmt.Errorf("carrier externalTMSId must be the Turvo carrier ID: %q", carrier.ExternalTMSId)
+	}
+
+	result := &types.DispatchResult{
+		ShipmentID:      shipment.ShipmentID,
+		CarrierID:       carrierID,
+		Drivers:         []types.DispatchedDriver{},
+		TractorNumber:   knownValue(carrier.ExternalTMSTruckID),
+		TrailerNumber:   knownValue(carrier.ExternalTMSTrailerID),
+		CarrierTotalUsd: pricing.CarrierTotalUsd,
+	}
+	drivers := []types.TurvoDriver{}
+	for _, driver := range []struct{ name, phone string }{
+		{carrier.FirstDriverName, carrier.FirstDriverPhone},
+		{carrier.SecondDriverName, carrier.SecondDriverPhone},
+	} {
+		if knownValue(driver.name) == "" {
+			continue
+		}
+		resolved, err := s.ResolveDriver(driver.name, driver.phone, carrierID)
+		if err != nil {
+			s.deleteCreatedDrivers(result.Drivers)
+			return nil, err
+		}
+		result.Drivers = append(result.Drivers, resolved)
+		drivers = append(drivers, types.TurvoDriver{DriverID: resolved.ID, Operation: 0, SegmentSequence: 0})
+	}
+
+	order := types.TurvoCarrierOrder{
+		CarrierOrderSourceID: 626, // Same source ID as carrier orders sent on create
+		Carrier:              types.TurvoCarrier{ID: carrierID, Name: carrier.Name},
+		Drivers:              drivers,
+		TractorNumber:        result.TractorNumber,
+		TrailerNumber:        result.TrailerNumber,
+	}
+	if load.RateData.CarrierLhRateUsd > 0 {
+		costs := carrierOrderCosts(pricing)
+		order.Costs = &costs
+	}
+	if len(shipment.CarrierOrder) > 0 && shipment.CarrierOrder[0].ID != 0 {
+		order.ID = shipment.CarrierOrder[0].ID
+		order.CarrierOrderSourceID = shipment.CarrierOrder[0].CarrierOrderSourceID
+		order.Operation = 1 // Update the existing carrier order
+	}
+
+	shipmentID := shipment.ShipmentID
+	if shipment.ID != 0 {
+		shipmentID = strconv.Itoa(shipment.ID)
+	}
+	update := types.TurvoShipmentUpdate{CarrierOrder: []types.TurvoCarrierOrder{order}}
+	if _, err := s.turvoRequest("PUT", fmt.Sprintf("%s/v1/shipments/%s", s.config.TurvoBaseURL, shipmentID), update); err != nil {
+		s.deleteCreatedDrivers(result.Drivers)
+		return nil, fmt.Errorf("failed to update carrier order: %w", err)
+	}
+	return result, nil
+}
+
+// deleteCreatedDrivers removes the drivers a failed dispatch created in Turvo.
+// Failures are logged, since the dispatch error is the one reported.
+func (s *TurvoService) deleteCreatedDrivers(drivers []types.DispatchedDriver) {
+	for _, driver := range drivers {
+		if !driver.Created {
+			continue
+		}
+		if _, err := s.turvoRequest("DELETE", fmt.Sprintf("%s/v1/drivers/%d", s.config.TurvoBaseURL, driver.ID), nil); err != nil {
+			fmt.Printf("DEBUG: Failed to delete orphaned Turvo driver %d: %v\n", driver.ID, err)
+			continue
+		}
+		fmt.Printf("DEBUG: Deleted orphaned Turvo driver %d\n", driver.ID)
+	}
+}
+
+// RecordConfirmationSent adds the time the rate confirmation was sent to the
+// shipment's carrier order, as an external ID of the account's
+// rateConfirmationSent type
+func (s *TurvoService) RecordConfirmationSent(shipment *types.TurvoShipment, sentAt time.Time) error {
+	idType, ok := s.codes.RateConfirmationSent()
+	if !ok {
+		return ErrNoConfirmationSentCode
+	}
+	if len(shipment.CarrierOrder) == 0 || shipment.CarrierOrder[0].ID == 0 {
+		return ErrNoCarrierOrder
+	}
+
+	current := shipment.CarrierOrder[0]
+	order := types.TurvoCarrierOrder{
+		ID:                   current.ID,
+		CarrierOrderSourceID: current.CarrierOrderSourceID,
+		Carrier:              current.Carrier,
+		Operation:            1, // Update the existing carrier order
+	}
+	for _, id := range current.ExternalIDs {
+		if id.Type.Key != idType.Key {
+			order.ExternalIDs = append(order.ExternalIDs, id)
+		}
+	}
+	order.ExternalIDs = append(order.ExternalIDs, types.TurvoExternalID{Type: idType, Value: sentAt.UTC().Format(time.RFC3339)})
+
+	shipmentID := shipment.ShipmentID
+	if shipment.ID != 0 {
+		shipmentID = strconv.Itoa(shipment.ID)
+	}
+	update := types.TurvoShipmentUpdate{CarrierOrder: []types.TurvoCarrierOrder{order}}
+	if _, err := s.turvoRequest("PUT", fmt.Sprintf("%s/v1/shipments/%s", s.config.TurvoBaseURL, shipmentID), update); err != nil {
+		return fmt.Errorf("failed to update carrier order: %w", err)
+	}
+	return nil
+}
+
+// ConfirmationSentFromTurvo reads when the rate confirmation was sent from a
+// carrier order's external IDs, or returns the zero time
+func ConfirmationSentFromTurvo(order types.TurvoCarrierOrder, codes *TurvoCodeTable) time.Time {
+	idType, ok := codes.RateConfirmationSent()
+	if !ok {
+		return time.Time{}
+	}
+	for _, id := range order.ExternalIDs {
+		if id.Type.Key != idType.Key {
+			continue
+		}
+		if sentAt, err := time.Parse(time.RFC3339, strings.TrimSpace(id.Value)); err == nil {
+			return sentAt
+		}
+	}
+	return time.Time{}
+}
+
+// ResolveDriver finds a carrier's driver in Turvo by name, preferring one
+// whose phone matches, and creates the driver when none is found
+func (s *TurvoService) ResolveDriver(name, phone string, carrierID int) (types.DispatchedDriver, error) {
+	name = strings.TrimSpace(name)
+	phone = knownValue(phone)
+
+	query := url.Values{}
+	query.Set("name[eq]", name)
+	query.Set("carrierId[eq]", strconv.Itoa(carrierID))
+	body, err := s.turvoRequest("GET", fmt.Sprintf("%s/v1/drivers/list?%s", s.config.TurvoBaseURL, query.Encode()), nil)
+	if err != nil {
+		return types.DispatchedDriver{}, fmt.Errorf("failed to search drivers: %w", err)
+	}
+	var found types.TurvoDriversResponse
+	if err := json.Unmarshal(body, &found); err != nil {
+		return types.DispatchedDriver{}, fmt.Errorf("failed to decode drivers: %w", err)
+	}
+
+	var match *types.TurvoDriverProfile
+	for i, driver := range found.Details.Drivers {
+		if !strings.EqualFold(strings.TrimSpace(driver.Name), name) {
+			continue
+		}
+		if phone == "" || driver.Phone == "" || phoneDigits(driver.Phone) == phoneDigits(phone) {
+			match = &found.Details.Drivers[i]
+			break
+		}
+	}
+	if match != nil {
+		fmt.Printf("DEBUG: Matched driver %q to Turvo driver %d\n", name, match.ID)
+		return types.DispatchedDriver{ID: match.ID, Name: match.Name, Phone: firstKnown(match.Phone, phone)}, nil
+	}
+
+	profile := types.TurvoDriverProfile{Name: name, Phone: phone, Carrier: types.TurvoCarrier{ID: carrierID}}
+	body, err = s.turvoRequest("POST", fmt.Sprintf("%s/v1/drivers", s.config.TurvoBaseURL), profile)
+	if err != nil {
+		return types.DispatchedDriver{}, fmt.Errorf("failed to create driver %q: %w", name, err)
+	}
+	var created types.TurvoDriverResponse
+	if err := json.Unmarshal(body, &created); err != nil {
+		return types.DispatchedDriver{}, fmt.Errorf("failed to decode created driver: %w", err)
+	}
+	if created.Details.ID == 0 {
+		return types.DispatchedDriver{}, fmt.Errorf("Turvo returned no ID for driver %q", name)
+	}
+	fmt.Printf("DEBUG: Created Turvo driver %d for %q\n", created.Details.ID, name)
+	return types.DispatchedDriver{ID: created.Details.ID, Name: name, Phone: phone, Created: true}, nil
+}
+
+// turvoRequest sends an authenticated request to Turvo with an optional JSON
+// payload and returns the response body, treating HTTP errors as failures
+func (s *TurvoService) turvoRequest(method, requestURL string, payload interface{}) ([]byte, error) {
+	token, err := s.getAccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Turvo OAuth token: %w", err)
+	}
+
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		fmt.Printf("DEBUG: Turvo %s %s request JSON: %s\n", method, requestURL, string(jsonData))
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("x-api-key", s.config.TurvoXApiKey)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	fmt.Printf("DEBUG: Turvo %s %s response status: %s\n", method, requestURL, resp.Status)
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Turvo API error: %s - %s", resp.Status, string(bodyBytes))
+	}
+	return bodyBytes, nil
+}
+
+// ValidateDispatch checks that a load's carrier can be dispatched: a Turvo
+// carrier ID, a first driver, and a phone for every named driver
+func ValidateDispatch(load types.Load) types.ValidationErrors {
+	var errs types.ValidationErrors
+	add := func(field, message string) {
+		errs = append(errs, types.ValidationError{Field: field, Message: message})
+	}
+
+	carrier := load.Carrier
+	if _, err := strconv.Atoi(strings.TrimSpace(carrier.ExternalTMSId)); err != nil {
+		add("carrier.externalTMSId", "must be the numeric Turvo carrier ID")
+	}
+	if knownValue(carrier.FirstDriverName) == "" {
+		add("carrier.firstDriverName", "is required")
+	} else if knownValue(carrier.FirstDriverPhone) == "" {
+		add("carrier.firstDriverPhone", "is required")
+	}
+	if knownValue(carrier.SecondDriverName) != "" && knownValue(carrier.SecondDriverPhone) == "" {
+		add("carrier.secondDriverPhone", "is required when a second driver is named")
+	}
+	if knownValue(carrier.SecondDriverName) == "" && knownValue(carrier.SecondDriverPhone) != "" {
+		add("carrier.secondDriverName", "is required when a second driver phone is given")
+	}
+	return errs
+}
+
+// phoneDigits strips a phone number to its digits, dropping a leading US country code
+func phoneDigits(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+	if len(digits) == 11 && strings.HasPrefix(digits, "1") {
+		digits = digits[1:]
+	}
+	return digits
+}
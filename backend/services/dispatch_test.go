@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// fakeTurvo serves Turvo requests from handle and records each as "METHOD path"
+type fakeTurvo struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string]string
+}
+
+// newFakeTurvo starts a fake Turvo API and returns a service that calls it
+// with an already valid token
+func newFakeTurvo(t *testing.T, handle func(method, path string) (int, string)) (*TurvoService, *fakeTurvo) {
+	fake := &fakeTurvo{bodies: map[string]string{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		request := r.Method + " " + r.URL.Path
+		fake.mu.Lock()
+		fake.requests = append(fake.requests, request)
+		fake.bodies[request] = string(body)
+		fake.mu.Unlock()
+		status, response := handle(r.Method, r.URL.Path)
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	service := &TurvoService{
+		config:       &config.Config{TurvoBaseURL: server.URL},
+		client:       server.Client(),
+		token:        &turvoToken{accessToken: "test", expiry: time.Now().Add(time.Hour)},
+		accessorials: testAccessorials(),
+		codes:        testCodes(),
+	}
+	return service, fake
+}
+
+// made reports whether the fake received a request
+func (f *fakeTurvo) made(request string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, made := range f.requests {
+		if made == request {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDispatchShipmentDeletesCreatedDrivers(t *testing.T) {
+	load := types.Load{Carrier: types.Carrier{
+		ExternalTMSId:     "42",
+		FirstDriverName:   "Ann Lee",
+		FirstDriverPhone:  "312-555-0100",
+		SecondDriverName:  "Bob Ray",
+		SecondDriverPhone: "312-555-0101",
+	}}
+	existingBob := `{"details":{"drivers":[{"id":77,"name":"Bob Ray","phone":"3125550101"}]}}`
+
+	tests := []struct {
+		name        string
+		bobList     string
+		bobCreate   int
+		putStatus   int
+		wantErr     bool
+		wantDeleted []string
+		wantKept    []string
+	}{
+		{name: "successful dispatch keeps created drivers", bobList: existingBob, putStatus: 200, wantKept: []string{"DELETE /v1/drivers/501"}},
+		{name: "failed update deletes only created drivers", bobList: existingBob, putStatus: 500, wantErr: true,
+			wantDeleted: []string{"DELETE /v1/drivers/501"}, wantKept: []string{"DELETE /v1/drivers/77"}},
+		{name: "failed second driver deletes the first", bobList: `{"details":{"drivers":[]}}`, bobCreate: 500, wantErr: true,
+			wantDeleted: []string{"DELETE /v1/drivers/501"}, wantKept: []string{"PUT /v1/shipments/9"}},
+	}
+	for _, test := range tests {
+		listed := 0
+		created := 0
+		service, fake := newFakeTurvo(t, func(method, path string) (int, string) {
+			switch {
+			case method == "GET" && path == "/v1/drivers/list":
+				listed++
+				if listed == 1 {
+					return 200, `{"details":{"drivers":[]}}`
+				}
+				return 200, test.bobList
+			case method == "POST" && path == "/v1/drivers":
+				created++
+				if created == 2 && test.bobCreate != 0 {
+					return test.bobCreate, `{}`
+				}
+				return 200, `{"details":{"id":` + strconv.Itoa(500+created) + `}}`
+			case method == "PUT":
+				return test.putStatus, `{}`
+			}
+			return 200, `{}`
+		})
+
+		_, err := service.DispatchShipment(&types.TurvoShipment{ID: 9, ShipmentID: "SHP-9"}, load, &types.PricingResult{})
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+		}
+		for _, request := range test.wantDeleted {
+			if !fake.made(request) {
+				t.Errorf("%s: expected %s", test.name, request)
+			}
+		}
+		for _, request := range test.wantKept {
+			if fake.made(request) {
+				t.Errorf("%s: did not expect %s", test.name, request)
+			}
+		}
+	}
+}
+
+func TestRecordConfirmationSent(t *testing.T) {
+	sentAt := time.Date(2026, 10, 20, 14, 30, 0, 0, time.UTC)
+	order := types.TurvoCarrierOrder{
+		ID:                   12,
+		CarrierOrderSourceID: 626,
+		Carrier:              types.TurvoCarrier{ID: 42, Name: "Fast Freight"},
+		ExternalIDs: []types.TurvoExternalID{
+			{Type: types.TurvoCode{Key: "1400", Value: "Purchase order #"}, Value: "PO-9"},
+			{Type: types.TurvoCode{Key: "X1"}, Value: "not a time"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		codes    *TurvoCodeTable
+		shipment types.TurvoShipment
+		wantErr  error
+	}{
+		{name: "without a code", codes: &TurvoCodeTable{codes: defaultTurvoCodes()}, shipment: types.TurvoShipment{ID: 9, CarrierOrder: []types.TurvoCarrierOrder{order}}, wantErr: ErrNoConfirmationSentCode},
+		{name: "without a carrier order", codes: testCodes(), shipment: types.TurvoShipment{ID: 9}, wantErr: ErrNoCarrierOrder},
+		{name: "recorded on the carrier order", codes: testCodes(), shipment: types.TurvoShipment{ID: 9, CarrierOrder: []types.TurvoCarrierOrder{order}}},
+	}
+	for _, test := range tests {
+		service, fake := newFakeTurvo(t, func(method, path string) (int, string) { return 200, `{}` })
+		service.codes = test.codes
+
+		err := service.RecordConfirmationSent(&test.shipment, sentAt)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+		if test.wantErr != nil {
+			if fake.made("PUT /v1/shipments/9") {
+				t.Errorf("%s: expected no update to be sent", test.name)
+			}
+			continue
+		}
+		body := fake.bodies["PUT /v1/shipments/9"]
+		for _, want := range []string{`"id":12`, `"_operation":1`, `"value":"PO-9"`, `"value":"2026-10-20T14:30:00Z"`} {
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: expected %s in %s", test.name, want, body)
+			}
+		}
+		if strings.Contains(body, "not a time") {
+			t.Errorf("%s: expected the earlier value of the same type to be replaced: %s", test.name, body)
+		}
+	}
+
+	order.ExternalIDs[1].Value = "2026-10-20T14:30:00Z"
+	if got := ConfirmationSentFromTurvo(order, testCodes()); !got.Equal(sentAt) {
+		t.Errorf("expected the send time to be read back, got %v", got)
+	}
+	if got := ConfirmationSentFromTurvo(order, &TurvoCodeTable{codes: defaultTurvoCodes()}); !got.IsZero() {
+		t.Errorf("expected no send time without a code, got %v", got)
+	}
+}
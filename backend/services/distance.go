@@ -0,0 +1,152 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"turvo-app/types"
+)
+
+// zip3CentroidsCSV holds approximate centroids of US 3-digit zip prefixes
+//
+//go:embed data/zip3_centroids.csv
+var zip3CentroidsCSV string
+
+// roadFactor converts great-circle miles to typical truck route miles
+const roadFactor = 1.2
+
+// earthRadiusMiles is the mean radius of the earth
+const earthRadiusMiles = 3958.8
+
+var (
+	centroidsOnce  sync.Once
+	zip3Centroids  map[string]types.GeoPoint
+	zip3States     map[string]string
+	stateCentroids map[string]types.GeoPoint
+)
+
+// loadCentroids parses the bundled zip3 dataset and derives a centroid for each state
+func loadCentroids() {
+	zip3Centroids = map[string]types.GeoPoint{}
+	zip3States = map[string]string{}
+	stateCentroids = map[string]types.GeoPoint{}
+
+	records, err := csv.NewReader(strings.NewReader(zip3CentroidsCSV)).ReadAll()
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to parse zip3 centroids: %v\n", err)
+		return
+	}
+
+	sums := map[string]types.GeoPoint{}
+	counts := map[string]int{}
+	for _, record := range records[1:] {
+		if len(record) < 5 {
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(record[3], 64)
+		lng, lngErr := strconv.ParseFloat(record[4], 64)
+		if latErr != nil || lngErr != nil {
+			continue
+		}
+		zip3Centroids[record[0]] = types.GeoPoint{Lat: lat, Lng: lng}
+
+		state := record[2]
+		zip3States[record[0]] = state
+		sums[state] = types.GeoPoint{Lat: sums[state].Lat + lat, Lng: sums[state].Lng + lng}
+		counts[state]++
+	}
+	for state, sum := range sums {
+		n := float64(counts[state])
+		stateCentroids[state] = types.GeoPoint{Lat: sum.Lat / n, Lng: sum.Lng / n}
+	}
+}
+
+// LocatePostalCode returns the position of a US zip code from the zip5
+// dataset, or its zip3 prefix, falling back to the state centroid when the
+// zip is missing or unknown. Canadian,
+// Mexican and other addresses are not in the dataset and cannot be located.
+func LocatePostalCode(zip, state, country string) (types.GeoPoint, bool) {
+	if code, ok := normalizeCountry(country); !ok || code != CountryUS {
+		return types.GeoPoint{}, false
+	}
+	centroidsOnce.Do(loadCentroids)
+
+	if place, ok := lookupZip(zip); ok {
+		return place.Geo, true
+	}
+	zip = knownValue(zip)
+	if len(zip) >= 3 {
+		if point, ok := zip3Centroids[zip[:3]]; ok {
+			return point, true
+		}
+	}
+	point, ok := stateCentroids[strings.ToUpper(knownValue(state))]
+	return point, ok
+}
+
+// stateForZip returns the state a US zip code belongs to, from the zip5
+// dataset or its zip3 prefix, or an empty string
+func stateForZip(zip string) string {
+	if place, ok := lookupZip(zip); ok {
+		return place.State
+	}
+	centroidsOnce.Do(loadCentroids)
+	zip = knownValue(zip)
+	if len(zip) < 3 {
+		return ""
+	}
+	return zip3States[zip[:3]]
+}
+
+// GreatCircleMiles returns the haversine distance between two points
+func GreatCircleMiles(a, b types.GeoPoint) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRadians(b.Lat - a.Lat)
+	dLng := toRadians(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(a.Lat))*math.Cos(toRadians(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMiles * math.Asin(math.Sqrt(h))
+}
+
+// RoadMiles estimates driving miles between two points, rounded to whole miles
+func RoadMiles(a, b types.GeoPoint) float64 {
+	return math.Round(GreatCircleMiles(a, b) * roadFactor)
+}
+
+// LoadRouteLegs returns the road miles from the previous stop for each of the
+// load's stops in order. The first stop is always 0. Stops that cannot be
+// located, or that fall in the same zip prefix and so estimate as 0 miles,
+// return an error so the distance is left to the client or Turvo.
+func LoadRouteLegs(load types.Load) ([]float64, error) {
+	pickup, ok := LocatePostalCode(load.Pickup.Zipcode, load.Pickup.State, load.Pickup.Country)
+	if !ok {
+		return nil, fmt.Errorf("cannot locate pickup %s %s %s", load.Pickup.Zipcode, load.Pickup.State, load.Pickup.Country)
+	}
+	delivery, ok := LocatePostalCode(load.Consignee.Zipcode, load.Consignee.State, load.Consignee.Country)
+	if !ok {
+		return nil, fmt.Errorf("cannot locate delivery %s %s %s", load.Consignee.Zipcode, load.Consignee.State, load.Consignee.Country)
+	}
+	miles := RoadMiles(pickup, delivery)
+	if miles <= 0 {
+		return nil, fmt.Errorf("pickup %s and delivery %s are too close to estimate miles", load.Pickup.Zipcode, load.Consignee.Zipcode)
+	}
+	return []float64{0, miles}, nil
+}
+
+// LoadRouteMiles returns the total estimated road miles for a load
+func LoadRouteMiles(load types.Load) (float64, error) {
+	legs, err := LoadRouteLegs(load)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, leg := range legs {
+		total += leg
+	}
+	return total, nil
+}
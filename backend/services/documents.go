@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// defaultBOLTemplate is used when no customer-specific template matches
+var defaultBOLTemplate = types.BOLTemplate{
+	ID:    "default",
+	Title: "STRAIGHT BILL OF LADING - SHORT FORM - NOT NEGOTIABLE",
+	Terms: "RECEIVED, subject to the classifications and tariffs in effect on the date of issue of this Bill of Lading, " +
+		"the property described above in apparent good order, except as noted, marked, consigned and destined as indicated above, " +
+		"which said carrier agrees to carry to its usual place of delivery at said destination. The shipper certifies that the " +
+		"above named materials are properly classified, packaged, marked and labeled, and are in proper condition for " +
+		"transportation according to the applicable regulations of the DOT.",
+	SignatureLabels: []string{"Shipper Signature / Date", "Carrier Signature / Pickup Date", "Consignee Signature / Date"},
+}
+
+// defaultBrokerTerms are printed on rate confirmations when no broker profile provides terms
+const defaultBrokerTerms = "Carrier agrees to transport the shipment described above at the rate shown, which is the total " +
+	"compensation for the load unless additional charges are approved in writing before they are incurred. Carrier must " +
+	"not broker, re-broker, co-broker or interline this shipment. Carrier shall maintain the insurance coverage required " +
+	"by law and provide proof upon request. Detention, layover and other accessorials are payable only with signed " +
+	"documentation. Invoices must include the signed bill of lading and this confirmation. Please sign and return " +
+	"this confirmation before dispatch."
+
+// DocumentService renders shipping documents as PDFs
+type DocumentService struct {
+	bolTemplates []types.BOLTemplate
+	brokers      []types.BrokerProfile
+}
+
+// NewDocumentService creates a new document service, loading templates from the configured files
+func NewDocumentService(cfg *config.Config) *DocumentService {
+	service := &DocumentService{}
+
+	if cfg.BOLTemplatesFile != "" {
+		if err := readJSONFile(cfg.BOLTemplatesFile, &service.bolTemplates); err != nil {
+			fmt.Printf("DEBUG: Failed to load BOL templates from %s: %v\n", cfg.BOLTemplatesFile, err)
+		}
+	}
+
+	if cfg.RateConBrokersFile != "" {
+		if err := readJSONFile(cfg.RateConBrokersFile, &service.brokers); err != nil {
+			fmt.Printf("DEBUG: Failed to load rate confirmation brokers from %s: %v\n", cfg.RateConBrokersFile, err)
+		}
+	}
+
+	fmt.Printf("DEBUG: Loaded %d BOL templates and %d broker profiles\n", len(service.bolTemplates), len(service.brokers))
+	return service
+}
+
+// bolTemplateFor picks the template by explicit ID, then by customer, then the default
+func (s *DocumentService) bolTemplateFor(load types.Load, templateID string) (types.BOLTemplate, error) {
+	if templateID != "" {
+		for _, template := range s.bolTemplates {
+			if template.ID == templateID {
+				return withBOLDefaults(template), nil
+			}
+		}
+		if templateID == defaultBOLTemplate.ID {
+			return defaultBOLTemplate, nil
+		}
+		return types.BOLTemplate{}, fmt.Errorf("unknown BOL template: %s", templateID)
+	}
+
+	for _, template := range s.bolTemplates {
+		for _, customer := range template.Customers {
+			if customer == "" {
+				continue
+			}
+			if customer == load.Customer.ExternalTMSId || strings.EqualFold(customer, load.Customer.Name) {
+				return withBOLDefaults(template), nil
+			}
+		}
+	}
+	return defaultBOLTemplate, nil
+}
+
+// brokerProfileFor returns the named broker profile, or the "default" profile when no ID is given
+func (s *DocumentService) brokerProfileFor(brokerID string) (types.BrokerProfile, error) {
+	lookup := brokerID
+	if lookup == "" {
+		lookup = "default"
+	}
+	for _, broker := range s.brokers {
+		if broker.ID == lookup {
+			if broker.Terms == "" {
+				broker.Terms = defaultBrokerTerms
+			}
+			return broker, nil
+		}
+	}
+	if brokerID != "" {
+		return types.BrokerProfile{}, fmt.Errorf("unknown broker: %s", brokerID)
+	}
+	return types.BrokerProfile{ID: "default", Terms: defaultBrokerTerms}, nil
+}
+
+// withBOLDefaults fills unset template fields from the default template
+func withBOLDefaults(template types.BOLTemplate) types.BOLTemplate {
+	if template.Title == "" {
+		template.Title = defaultBOLTemplate.Title
+	}
+	if template.Terms == "" {
+		template.Terms = defaultBOLTemplate.Terms
+	}
+	if len(template.SignatureLabels) == 0 {
+		template.SignatureLabels = defaultBOLTemplate.SignatureLabels
+	}
+	return template
+}
+
+// specialServices lists the accessorial services requested in a load's specifications
+func specialServices(specs types.Specifications) []string {
+	services := []string{}
+	flags := []struct {
+		enabled bool
+		label   string
+	}{
+		{specs.LiftgatePickup, "Liftgate at pickup"},
+		{specs.LiftgateDelivery, "Liftgate at delivery"},
+		{specs.InsidePickup, "Inside pickup"},
+		{specs.InsideDelivery, "Inside delivery"},
+		{specs.Tarps, "Tarps"},
+		{specs.Straps, "Straps"},
+		{specs.Oversized, "Oversized"},
+		{specs.Permits, "Permits"},
+		{specs.Escorts, "Escorts"},
+		{specs.Hazmat, "Hazardous materials"},
+		{specs.Seal, "Seal required"},
+		{specs.CustomBonded, "Customs bonded"},
+		{specs.Labor, "Labor / driver assist"},
+		{specs.ResidentialPickup, "Residential pickup"},
+		{specs.ResidentialDelivery, "Residential delivery"},
+		{specs.LimitedAccessPickup, "Limited access pickup"},
+		{specs.LimitedAccessDelivery, "Limited access delivery"},
+		{specs.DeliveryNotification, "Notify before delivery"},
+	}
+	for _, flag := range flags {
+		if flag.enabled {
+			services = append(services, flag.label)
+		}
+	}
+	return services
+}
+
+// addressLines formats a party address for printing, skipping unknown values
+func addressLines(name, address1, address2, city, state, zip, country string) []string {
+	lines := []string{}
+	for _, value := range []string{name, address1, address2} {
+		if known := knownValue(value); known != "" {
+			lines = append(lines, known)
+		}
+	}
+
+	cityLine := strings.TrimSpace(strings.Join(nonEmpty(knownValue(city), strings.TrimSpace(knownValue(state)+" "+knownValue(zip))), ", "))
+	if cityLine != "" {
+		lines = append(lines, cityLine)
+	}
+	if c := knownValue(country); c != "" && x12Country(c) != "US" {
+		lines = append(lines, c)
+	}
+	return lines
+}
+
+// contactLine formats a contact name, phone and email on one line
+func contactLine(contact, phone, email string) string {
+	return strings.Join(nonEmpty(knownValue(contact), knownValue(phone), knownValue(email)), "  |  ")
+}
+
+// documentTime formats a timestamp for printing, or returns an empty string when unset
+func documentTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("Jan 2, 2006 15:04")
+}
+
+// nonEmpty filters out empty strings
+func nonEmpty(values ...string) []string {
+	result := []string{}
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// readJSONFile decodes a JSON file into out
+func readJSONFile(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
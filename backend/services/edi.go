@@ -0,0 +1,445 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// X12 functional identifier codes used in the GS segment
+const (
+	functionalIDResponse = "GF" // 990 Response to a Load Tender
+	functionalIDStatus   = "QM" // 214 Transportation Carrier Shipment Status
+	functionalIDInvoice  = "IM" // 210 Motor Carrier Freight Details and Invoice
+)
+
+// shipmentStatusCodes maps Turvo shipment status values to X12 AT7 shipment status codes
+var shipmentStatusCodes = map[string]string{
+	"at pickup":   "X3",
+	"picked up":   "AF",
+	"en route":    "X6",
+	"in transit":  "X6",
+	"at delivery": "X1",
+	"delivered":   "D1",
+	"canceled":    "CA",
+	"cancelled":   "CA",
+}
+
+// stopStatusCodes maps a Turvo stop type and stop change to an X12 AT7 status code
+var stopStatusCodes = map[string]map[string]string{
+	"1500": { // Pickup
+		types.ChangeStopArrived:  "X3",
+		types.ChangeStopDeparted: "AF",
+	},
+	"1501": { // Delivery
+		types.ChangeStopArrived:  "X1",
+		types.ChangeStopDeparted: "D1",
+	},
+}
+
+// statusStopTypes maps AT7 codes to the stop type whose location they report
+var statusStopTypes = map[string]string{
+	"X3": "1500",
+	"AF": "1500",
+	"X1": "1501",
+	"D1": "1501",
+}
+
+// EDIService generates outbound X12 documents for trading partners
+type EDIService struct {
+	partners map[string]types.EDIPartner
+	controls *controlNumberStore
+	outbox   *ediOutbox
+}
+
+// NewEDIService creates a new EDI service, loading trading partners from the configured file
+func NewEDIService(cfg *config.Config) *EDIService {
+	service := &EDIService{
+		partners: make(map[string]types.EDIPartner),
+		controls: newControlNumberStore(cfg.EDIControlNumbersFile),
+		outbox:   newEDIOutbox(cfg.EDIOutboxFile),
+	}
+
+	if cfg.EDIPartnersFile != "" {
+		partners, err := loadEDIPartners(cfg.EDIPartnersFile)
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to load EDI partners from %s: %v\n", cfg.EDIPartnersFile, err)
+		}
+		for _, partner := range partners {
+			service.partners[partner.ID] = withEnvelopeDefaults(partner)
+		}
+	}
+
+	fmt.Printf("DEBUG: Loaded %d EDI trading partners\n", len(service.partners))
+	return service
+}
+
+// Partners returns all configured trading partners
+func (s *EDIService) Partners() []types.EDIPartner {
+	partners := []types.EDIPartner{}
+	for _, partner := range s.partners {
+		partners = append(partners, partner)
+	}
+	return partners
+}
+
+// Partner looks up a trading partner by ID
+func (s *EDIService) Partner(partnerID string) (types.EDIPartner, error) {
+	partner, ok := s.partners[partnerID]
+	if !ok {
+		return types.EDIPartner{}, fmt.Errorf("unknown trading partner: %s", partnerID)
+	}
+	return partner, nil
+}
+
+// Generate990 builds a 990 accept or decline response for a tendered load.
+// Accepting a tender starts 214s to the partner as the shipment changes;
+// declining it stops them.
+func (s *EDIService) Generate990(partnerID string, load types.Load, accept bool, reason string) (*types.EDIMessage, error) {
+	partner, err := s.Partner(partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	action := "D"
+	if accept {
+		action = "A"
+	}
+
+	body := [][]string{
+		{"B1", partner.SCAC, tenderShipmentID(load), now.Format("20060102"), action},
+	}
+	if load.ExternalTMSLoadID != "" {
+		body = append(body, []string{"N9", "CN", load.ExternalTMSLoadID})
+	}
+	if !accept && reason != "" {
+		body = append(body, []string{"K1", reason})
+	}
+
+	control, err := s.controls.next(partner.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate control number: %w", err)
+	}
+
+	message := types.EDIMessage{
+		PartnerID:      partner.ID,
+		TransactionSet: "990",
+		ControlNumber:  control,
+		ShipmentID:     load.ExternalTMSLoadID,
+		StatusCode:     action,
+		Content:        buildInterchange(partner, functionalIDResponse, "990", body, control, now),
+		CreatedAt:      now,
+	}
+	if err := s.outbox.add(message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Report214 generates 214s for the changes to a shipment since the partner's
+// previous 214s and records them as sent. The changes are only marked as
+// reported once their 214s are saved, so a failure reports them again on the
+// next call, and concurrent calls never report the same change twice.
+func (s *EDIService) Report214(partnerID string, load types.Load, shipment types.TurvoShipment, tracker *ShipmentTracker) ([]types.EDIMessage, []types.ShipmentChange, error) {
+	messages := []types.EDIMessage{}
+	changes, err := tracker.Take("edi:"+partnerID, shipment, func(changes []types.ShipmentChange) error {
+		generated, err := s.Generate214(partnerID, load, shipment, changes)
+		if err != nil {
+			return err
+		}
+		if err := s.outbox.add(generated...); err != nil {
+			return err
+		}
+		messages = generated
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return messages, changes, nil
+}
+
+// TenderedPartners returns the partners whose tender of a shipment was accepted
+func (s *EDIService) TenderedPartners(shipmentID string) []string {
+	return s.outbox.tenderedPartners(shipmentID)
+}
+
+// Messages returns the 990s and 214s sent for a shipment, optionally limited
+// to a partner and transaction set
+func (s *EDIService) Messages(shipmentID, partnerID, transactionSet string) []types.EDIMessage {
+	return s.outbox.list(shipmentID, partnerID, transactionSet)
+}
+
+// Generate214 builds one 214 status message for each tracked change that
+// maps to an X12 shipment status code. Changes without a mapping are skipped.
+func (s *EDIService) Generate214(partnerID string, load types.Load, shipment types.TurvoShipment, changes []types.ShipmentChange) ([]types.EDIMessage, error) {
+	partner, err := s.Partner(partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []types.EDIMessage{}
+	emitted := make(map[string]bool)
+
+	for _, change := range changes {
+		code, stop := statusCodeForChange(change, shipment)
+		if code == "" || emitted[code] {
+			continue
+		}
+		emitted[code] = true
+
+		control, err := s.controls.next(partner.ID)
+		if err != nil {
+			return messages, fmt.Errorf("failed to allocate control number: %w", err)
+		}
+
+		now := time.Now()
+		messages = append(messages, types.EDIMessage{
+			PartnerID:      partner.ID,
+			TransactionSet: "214",
+			ControlNumber:  control,
+			ShipmentID:     shipment.ShipmentID,
+			StatusCode:     code,
+			Content:        buildInterchange(partner, functionalIDStatus, "214", build214Body(partner, load, shipment, code, stop, change.OccurredAt), control, now),
+			CreatedAt:      now,
+		})
+	}
+
+	return messages, nil
+}
+
+// statusCodeForChange resolves the AT7 status code and reporting stop for a change
+func statusCodeForChange(change types.ShipmentChange, shipment types.TurvoShipment) (string, *types.TurvoGlobalRoute) {
+	if change.Stop != nil {
+		return stopStatusCodes[change.Stop.StopType.Key][change.Type], change.Stop
+	}
+
+	code := shipmentStatusCodes[strings.ToLower(strings.TrimSpace(change.Status.Code.Value))]
+	if code == "" {
+		return "", nil
+	}
+	if stopType, ok := statusStopTypes[code]; ok {
+		for i := range shipment.GlobalRoute {
+			if shipment.GlobalRoute[i].StopType.Key == stopType {
+				return code, &shipment.GlobalRoute[i]
+			}
+		}
+	}
+	return code, nil
+}
+
+// build214Body builds the transaction set segments for a 214 status message
+func build214Body(partner types.EDIPartner, load types.Load, shipment types.TurvoShipment, code string, stop *types.TurvoGlobalRoute, occurredAt time.Time) [][]string {
+	body := [][]string{
+		{"B10", shipment.ShipmentID, tenderShipmentID(load), partner.SCAC},
+	}
+	if po := strings.TrimSpace(load.Specifications.PONums); po != "" && po != "N/A" {
+		body = append(body, []string{"L11", po, "PO"})
+	}
+
+	timeCode := "UT"
+	eventTime := occurredAt.UTC()
+	if stop != nil && stop.Timezone != "" {
+		if loc, err := time.LoadLocation(stop.Timezone); err == nil {
+			eventTime = occurredAt.In(loc)
+			timeCode = "LT"
+		}
+	}
+
+	body = append(body,
+		[]string{"LX", "1"},
+		[]string{"AT7", code, "NS", "", "", eventTime.Format("20060102"), eventTime.Format("1504"), timeCode},
+	)
+	if stop != nil && stop.Location.City != "" {
+		body = append(body, []string{"MS1", stop.Location.City, stop.Location.State, x12Country(stop.Location.Country)})
+	}
+
+	return body
+}
+
+// tenderShipmentID returns the shipper's shipment identifier for a load
+func tenderShipmentID(load types.Load) string {
+	if load.FreightLoadID != "" {
+		return load.FreightLoadID
+	}
+	return load.ExternalTMSLoadID
+}
+
+// x12Country converts a country name to the X12 country code
+func x12Country(country string) string {
+	switch strings.ToUpper(strings.TrimSpace(country)) {
+	case "", "US", "USA", "UNITED STATES":
+		return "US"
+	case "CA", "CAN", "CANADA":
+		return "CA"
+	case "MX", "MEX", "MEXICO":
+		return "MX"
+	}
+	return strings.ToUpper(country)
+}
+
+// buildInterchange wraps a single transaction set in ST/SE, GS/GE and ISA/IEA envelopes
+func buildInterchange(partner types.EDIPartner, functionalID, transactionSet string, body [][]string, control int, now time.Time) string {
+	segments := [][]string{
+		{
+			"ISA", "00", strings.Repeat(" ", 10), "00", strings.Repeat(" ", 10),
+			padRight(partner.ISASenderQualifier, 2), padRight(partner.ISASenderID, 15),
+			padRight(partner.ISAReceiverQualifier, 2), padRight(partner.ISAReceiverID, 15),
+			now.Format("060102"), now.Format("1504"), "U", "00401",
+			fmt.Sprintf("%09d", control), "0", partner.UsageIndicator, partner.ComponentSeparator,
+		},
+		{"GS", functionalID, partner.GSSenderCode, partner.GSReceiverCode, now.Format("20060102"), now.Format("1504"), fmt.Sprintf("%d", control), "X", "004010"},
+		{"ST", transactionSet, fmt.Sprintf("%04d", control%10000)},
+	}
+	segments = append(segments, body...)
+	segments = append(segments,
+		[]string{"SE", fmt.Sprintf("%d", len(body)+2), fmt.Sprintf("%04d", control%10000)},
+		[]string{"GE", "1", fmt.Sprintf("%d", control)},
+		[]string{"IEA", "1", fmt.Sprintf("%09d", control)},
+	)
+
+	var builder strings.Builder
+	for i, segment := range segments {
+		elements := make([]string, len(segment))
+		for j, element := range segment {
+			// ISA elements are fixed width and must not be altered
+			if i == 0 {
+				elements[j] = element
+			} else {
+				elements[j] = sanitizeX12(element, partner)
+			}
+		}
+		builder.WriteString(strings.TrimRight(strings.Join(elements, partner.ElementSeparator), partner.ElementSeparator))
+		builder.WriteString(partner.SegmentTerminator)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// sanitizeX12 strips delimiter characters from an element value
+func sanitizeX12(value string, partner types.EDIPartner) string {
+	replacer := strings.NewReplacer(
+		partner.ElementSeparator, " ",
+		partner.SegmentTerminator, " ",
+		partner.ComponentSeparator, " ",
+		"\n", " ",
+		"\r", " ",
+	)
+	return strings.ToUpper(strings.TrimSpace(replacer.Replace(value)))
+}
+
+// padRight pads or truncates a value to a fixed width
+func padRight(value string, width int) string {
+	if len(value) > width {
+		return value[:width]
+	}
+	return value + strings.Repeat(" ", width-len(value))
+}
+
+// withEnvelopeDefaults fills unset envelope settings with X12 defaults
+func withEnvelopeDefaults(partner types.EDIPartner) types.EDIPartner {
+	if partner.ISASenderQualifier == "" {
+		partner.ISASenderQualifier = "ZZ"
+	}
+	if partner.ISAReceiverQualifier == "" {
+		partner.ISAReceiverQualifier = "ZZ"
+	}
+	if partner.GSSenderCode == "" {
+		partner.GSSenderCode = partner.ISASenderID
+	}
+	if partner.GSReceiverCode == "" {
+		partner.GSReceiverCode = partner.ISAReceiverID
+	}
+	if partner.UsageIndicator == "" {
+		partner.UsageIndicator = "T"
+	}
+	if partner.ElementSeparator == "" {
+		partner.ElementSeparator = "*"
+	}
+	if partner.SegmentTerminator == "" {
+		partner.SegmentTerminator = "~"
+	}
+	if partner.ComponentSeparator == "" {
+		partner.ComponentSeparator = ">"
+	}
+	return partner
+}
+
+// loadEDIPartners reads trading partner definitions from a JSON file
+func loadEDIPartners(path string) ([]types.EDIPartner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var partners []types.EDIPartner
+	if err := json.Unmarshal(data, &partners); err != nil {
+		return nil, fmt.Errorf("failed to parse partners file: %w", err)
+	}
+	return partners, nil
+}
+
+// controlNumberStore hands out per-partner interchange control numbers,
+// persisting the last used value when a file is configured
+type controlNumberStore struct {
+	mu      sync.Mutex
+	path    string
+	numbers map[string]int
+}
+
+// newControlNumberStore creates a control number store backed by an optional file
+func newControlNumberStore(path string) *controlNumberStore {
+	store := &controlNumberStore{
+		path:    path,
+		numbers: make(map[string]int),
+	}
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			if err := json.Unmarshal(data, &store.numbers); err != nil {
+				fmt.Printf("DEBUG: Failed to parse EDI control numbers file: %v\n", err)
+			}
+		}
+	}
+	return store
+}
+
+// peek returns the control number the next call to next would hand out
+func (c *controlNumberStore) peek(partnerID string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	number := c.numbers[partnerID] + 1
+	if number > 999999999 {
+		number = 1
+	}
+	return number
+}
+
+// next returns the next control number for a partner, wrapping before ISA13 overflows
+func (c *controlNumberStore) next(partnerID string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	number := c.numbers[partnerID] + 1
+	if number > 999999999 {
+		number = 1
+	}
+	c.numbers[partnerID] = number
+
+	if c.path != "" {
+		data, err := json.MarshalIndent(c.numbers, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(c.path, data, 0644); err != nil {
+			return 0, err
+		}
+	}
+	return number, nil
+}
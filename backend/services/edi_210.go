@@ -0,0 +1,333 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"turvo-app/types"
+)
+
+// deliveredStatuses are Turvo status values that allow a load to be invoiced
+var deliveredStatuses = map[string]bool{
+	"delivered":     true,
+	"ready to bill": true,
+}
+
+// Generate210 builds a 210 freight invoice for a delivered load. Charges come
+// from the billable line items on the Turvo customer order. When Turvo lists
+// none, linehaul and fuel come from the load's RateData and accessorials from
+// its flags. When preview is true no control number is consumed.
+func (s *EDIService) Generate210(partnerID string, load types.Load, shipment types.TurvoShipment, accessorials *AccessorialTable, codes *TurvoCodeTable, preview bool) (*types.EDIInvoice, error) {
+	partner, err := s.Partner(partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var turvoCosts types.TurvoCosts
+	var customerName string
+	if len(shipment.CustomerOrder) > 0 {
+		turvoCosts = shipment.CustomerOrder[0].Costs
+		customerName = shipment.CustomerOrder[0].Customer.Name
+	}
+
+	charges, err := invoiceCharges(load, turvoCosts, accessorials, codes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price load: %w", err)
+	}
+	totalCents := 0
+	for _, charge := range charges {
+		totalCents += usdToCents(charge.AmountUsd)
+	}
+
+	invoice := &types.EDIInvoice{
+		InvoiceNumber: invoiceNumber(shipment),
+		ShipmentID:    shipment.ShipmentID,
+		BillTo:        firstKnown(load.BillTo.Name, load.Customer.Name, customerName),
+		Charges:       charges,
+		TotalUsd:      centsToUsd(totalCents),
+		TurvoTotalUsd: centsToUsd(turvoCosts.TotalAmount),
+		Issues:        reconcileInvoice(shipment, turvoCosts, totalCents),
+	}
+	invoice.Valid = len(invoice.Issues) == 0
+
+	var control int
+	if preview {
+		control = s.controls.peek(partner.ID)
+	} else {
+		control, err = s.controls.next(partner.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate control number: %w", err)
+		}
+	}
+
+	now := time.Now()
+	invoice.Message = &types.EDIMessage{
+		PartnerID:      partner.ID,
+		TransactionSet: "210",
+		ControlNumber:  control,
+		ShipmentID:     shipment.ShipmentID,
+		Content:        buildInterchange(partner, functionalIDInvoice, "210", build210Body(partner, load, invoice, totalCents, now), control, now),
+		CreatedAt:      now,
+	}
+
+	return invoice, nil
+}
+
+// invoiceCharges builds the billed charges for a load, preferring the Turvo
+// customer order line items over the load's own rates
+func invoiceCharges(load types.Load, costs types.TurvoCosts, accessorials *AccessorialTable, codes *TurvoCodeTable) ([]types.EDIInvoiceCharge, error) {
+	charges := []types.EDIInvoiceCharge{}
+	unmapped := []string{}
+	for _, item := range costs.LineItem {
+		if !item.Billable {
+			continue
+		}
+		code, ok := lineItemChargeCode(item, accessorials, codes)
+		if !ok {
+			unmapped = append(unmapped, fmt.Sprintf("%s (%s)", item.Code.Value, item.Code.Key))
+			continue
+		}
+		qty := float64(item.Qty)
+		if qty == 0 {
+			qty = 1
+		}
+		qualifier := "FR"
+		if qty > 1 {
+			qualifier = "PE"
+		}
+		charges = append(charges, types.EDIInvoiceCharge{
+			ChargeCode:    code,
+			Description:   item.Code.Value,
+			Quantity:      qty,
+			RateUsd:       centsToUsd(item.Price),
+			RateQualifier: qualifier,
+			AmountUsd:     centsToUsd(item.Amount),
+		})
+	}
+	if len(unmapped) > 0 {
+		return nil, fmt.Errorf("no X12 charge code for Turvo charges: %s", strings.Join(unmapped, ", "))
+	}
+	if len(charges) > 0 {
+		return charges, nil
+	}
+	return rateDataCharges(load, accessorials)
+}
+
+// lineItemChargeCode returns the X12 charge code for a Turvo customer order line item
+func lineItemChargeCode(item types.TurvoLineItem, accessorials *AccessorialTable, codes *TurvoCodeTable) (string, bool) {
+	switch fuel := codes.codes.FuelSurcharge.Key; {
+	case item.Code.Key == turvoFreightFlatCode.Key:
+		return "400", true
+	case fuel != "" && item.Code.Key == fuel:
+		return "FUE", true
+	}
+	return accessorials.X12ChargeCode(item)
+}
+
+// rateDataCharges builds the billed charges from the load's RateData and accessorial flags
+func rateDataCharges(load types.Load, accessorials *AccessorialTable) ([]types.EDIInvoiceCharge, error) {
+	rates := load.RateData
+	// Only the customer side is billed, so carrier rates have no bearing on the invoice
+	pricing, err := PriceCustomer(rates, load.Specifications.RouteMiles)
+	if err != nil {
+		return nil, err
+	}
+
+	linehaul := types.EDIInvoiceCharge{
+		ChargeCode:  "400",
+		Description: "Linehaul",
+		Quantity:    pricing.CustomerQuantity,
+		RateUsd:     rates.CustomerLhRateUsd,
+		AmountUsd:   pricing.CustomerLinehaulUsd,
+	}
+	linehaul.RateQualifier = rateQualifier(rates, linehaul)
+	charges := []types.EDIInvoiceCharge{linehaul}
+
+	if pricing.FuelSurchargeUsd > 0 {
+		fuel := types.EDIInvoiceCharge{
+			ChargeCode:  "FUE",
+			Description: "Fuel surcharge",
+			Quantity:    1,
+			RateUsd:     rates.FSCPercent,
+			AmountUsd:   pricing.FuelSurchargeUsd,
+		}
+		if rates.FSCPercent <= 0 {
+			fuel.Quantity = pricing.RouteMiles
+			fuel.RateUsd = rates.FSCPerMile
+		}
+		fuel.RateQualifier = rateQualifier(rates, fuel)
+		charges = append(charges, fuel)
+	}
+
+	for _, accessorial := range accessorials.Charges(load.Specifications) {
+		code, ok := accessorials.X12ChargeCode(types.TurvoLineItem{Code: accessorial.Code})
+		if !ok {
+			return nil, fmt.Errorf("no X12 charge code for accessorial %s", accessorial.Flag)
+		}
+		charges = append(charges, types.EDIInvoiceCharge{
+			ChargeCode:    code,
+			Description:   accessorial.Code.Value,
+			Quantity:      1,
+			RateUsd:       accessorial.AmountUsd,
+			RateQualifier: "FR",
+			AmountUsd:     accessorial.AmountUsd,
+		})
+	}
+
+	return charges, nil
+}
+
+// reconcileInvoice checks the invoice against the load status and Turvo customer order costs
+func reconcileInvoice(shipment types.TurvoShipment, costs types.TurvoCosts, totalCents int) []string {
+	issues := []string{}
+
+	if !deliveredStatuses[strings.ToLower(strings.TrimSpace(shipment.Status.Code.Value))] {
+		issues = append(issues, fmt.Sprintf("load status is %q, expected delivered or ready to bill", shipment.Status.Code.Value))
+	}
+	if len(shipment.CustomerOrder) == 0 {
+		issues = append(issues, "shipment has no customer order")
+		return issues
+	}
+	if totalCents <= 0 {
+		issues = append(issues, "invoice total must be greater than zero")
+	}
+	// Without Turvo costs the invoice is priced from the load, with nothing to reconcile against
+	if len(costs.LineItem) == 0 && costs.TotalAmount == 0 {
+		return issues
+	}
+
+	lineItemCents := 0
+	for _, item := range costs.LineItem {
+		if item.Billable {
+			lineItemCents += item.Amount
+		}
+	}
+	if lineItemCents != costs.TotalAmount {
+		issues = append(issues, fmt.Sprintf("Turvo line items total %.2f but customer order total is %.2f",
+			centsToUsd(lineItemCents), centsToUsd(costs.TotalAmount)))
+	}
+	if totalCents != costs.TotalAmount {
+		issues = append(issues, fmt.Sprintf("invoice total %.2f does not match Turvo customer order total %.2f",
+			centsToUsd(totalCents), centsToUsd(costs.TotalAmount)))
+	}
+
+	return issues
+}
+
+// build210Body builds the transaction set segments for a 210 freight invoice
+func build210Body(partner types.EDIPartner, load types.Load, invoice *types.EDIInvoice, totalCents int, now time.Time) [][]string {
+	body := [][]string{
+		{"B3", "", invoice.InvoiceNumber, tenderShipmentID(load), "PP", "L", now.Format("20060102"), fmt.Sprintf("%d", totalCents), "", "", "", partner.SCAC},
+		{"C3", "USD"},
+	}
+	if po := knownValue(load.Specifications.PONums); po != "" {
+		body = append(body, []string{"N9", "PO", po})
+	}
+	body = append(body, []string{"N9", "CN", invoice.ShipmentID})
+	if ref := knownValue(load.Customer.RefNumber); ref != "" {
+		body = append(body, []string{"N9", "CR", ref})
+	}
+
+	body = append(body, partySegments("BT", invoice.BillTo, load.BillTo.AddressLine1, load.BillTo.AddressLine2,
+		load.BillTo.City, load.BillTo.State, load.BillTo.Zipcode, load.BillTo.Country)...)
+	body = append(body, partySegments("SH", load.Pickup.Name, load.Pickup.AddressLine1, load.Pickup.AddressLine2,
+		load.Pickup.City, load.Pickup.State, load.Pickup.Zipcode, load.Pickup.Country)...)
+	body = append(body, partySegments("CN", load.Consignee.Name, load.Consignee.AddressLine1, load.Consignee.AddressLine2,
+		load.Consignee.City, load.Consignee.State, load.Consignee.Zipcode, load.Consignee.Country)...)
+
+	weight := fmt.Sprintf("%.0f", load.Specifications.TotalWeight)
+	body = append(body,
+		[]string{"LX", "1"},
+		[]string{"L5", "1", "FREIGHT"},
+		[]string{"L0", "1", "", "", weight, "G", "", "", fmt.Sprintf("%d", load.Specifications.InPalletCount), "PLT"},
+	)
+
+	for i, charge := range invoice.Charges {
+		body = append(body, []string{
+			"L1", fmt.Sprintf("%d", i+1), fmt.Sprintf("%.2f", charge.RateUsd), charge.RateQualifier,
+			fmt.Sprintf("%d", usdToCents(charge.AmountUsd)), "", "", "", charge.ChargeCode,
+		})
+	}
+
+	body = append(body, []string{"L3", weight, "G", "", "", fmt.Sprintf("%d", totalCents)})
+	return body
+}
+
+// partySegments builds the N1/N3/N4 loop for a party, skipping unknown values
+func partySegments(qualifier, name, address1, address2, city, state, zip, country string) [][]string {
+	name = knownValue(name)
+	if name == "" {
+		return nil
+	}
+	segments := [][]string{{"N1", qualifier, name}}
+	if address1 = knownValue(address1); address1 != "" {
+		segments = append(segments, []string{"N3", address1, knownValue(address2)})
+	}
+	if city = knownValue(city); city != "" {
+		segments = append(segments, []string{"N4", city, knownValue(state), knownValue(zip), x12Country(knownValue(country))})
+	}
+	return segments
+}
+
+// rateQualifier returns the X12 rate/value qualifier for a charge priced from RateData
+func rateQualifier(rates types.RateData, charge types.EDIInvoiceCharge) string {
+	if charge.ChargeCode == "FUE" && rates.FSCPercent > 0 {
+		return "PC"
+	}
+	if charge.ChargeCode == "400" {
+		switch rateType, _ := normalizeRateType(rates.CustomerRateType); rateType {
+		case RateTypePerMile:
+			return "PM"
+		case RateTypePerHour:
+			return "PH"
+		}
+	}
+	if charge.Quantity > 1 {
+		return "PE"
+	}
+	return "FR"
+}
+
+// invoiceNumber returns the invoice number for a shipment
+func invoiceNumber(shipment types.TurvoShipment) string {
+	if shipment.CustomID != "" {
+		return shipment.CustomID
+	}
+	return shipment.ShipmentID
+}
+
+// knownValue returns an empty string for placeholder values
+func knownValue(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "N/A" {
+		return ""
+	}
+	return value
+}
+
+// firstKnown returns the first non-placeholder value
+func firstKnown(values ...string) string {
+	for _, value := range values {
+		if known := knownValue(value); known != "" {
+			return known
+		}
+	}
+	return ""
+}
+
+// usdToCents converts a dollar amount to integer cents
+func usdToCents(usd float64) int {
+	return int(math.Round(usd * 100))
+}
+
+// centsToUsd converts integer cents to a dollar amount
+func centsToUsd(cents int) float64 {
+	return float64(cents) / 100
+}
+
+// roundUsd rounds a dollar amount to whole cents
+func roundUsd(usd float64) float64 {
+	return centsToUsd(usdToCents(usd))
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"turvo-app/types"
+)
+
+// testAccessorials is a tenant accessorial table with one code that has a
+// default X12 code and one that has none
+func testAccessorials() *AccessorialTable {
+	return &AccessorialTable{codes: []types.AccessorialCode{
+		{Flag: "liftgateDelivery", Stop: types.AccessorialStopDelivery, Charge: types.TurvoCode{Key: "1611", Value: "Liftgate"}, ChargeUsd: 75},
+		{Flag: "insidePickup", Stop: types.AccessorialStopPickup, Charge: types.TurvoCode{Key: "1612", Value: "Inside pickup"}, ChargeUsd: 50},
+	}}
+}
+
+// lineItem builds a billable Turvo line item of qty units at price cents each
+func lineItem(key, name string, qty, price int) types.TurvoLineItem {
+	return types.TurvoLineItem{Code: types.TurvoCode{Key: key, Value: name}, Qty: qty, Price: price, Amount: qty * price, Billable: true}
+}
+
+// testShipment builds a shipment with the given status and customer order costs
+func testShipment(status string, items ...types.TurvoLineItem) types.TurvoShipment {
+	total := 0
+	for _, item := range items {
+		if item.Billable {
+			total += item.Amount
+		}
+	}
+	return types.TurvoShipment{
+		ShipmentID: "SHP-1",
+		CustomID:   "INV-1",
+		Status:     types.TurvoStatus{Code: types.TurvoCode{Value: status}},
+		CustomerOrder: []types.TurvoCustomerOrder{{
+			Customer: types.TurvoCustomer{ID: 7, Name: "Acme Foods"},
+			Costs:    types.TurvoCosts{TotalAmount: total, LineItem: items},
+		}},
+	}
+}
+
+// orderCosts returns the costs of a shipment's first customer order
+func orderCosts(shipment types.TurvoShipment) types.TurvoCosts {
+	if len(shipment.CustomerOrder) == 0 {
+		return types.TurvoCosts{}
+	}
+	return shipment.CustomerOrder[0].Costs
+}
+
+func TestInvoiceCharges(t *testing.T) {
+	flatRates := types.Load{RateData: types.RateData{CustomerRateType: "flat", CustomerLhRateUsd: 1200, FSCPercent: 10}}
+	perMileRates := types.Load{
+		RateData:       types.RateData{CustomerRateType: "per mile", CustomerLhRateUsd: 2.5, FSCPerMile: 0.4},
+		Specifications: types.Specifications{RouteMiles: 400, LiftgateDelivery: true},
+	}
+	withInsidePickup := perMileRates
+	withInsidePickup.Specifications.InsidePickup = true
+
+	tests := []struct {
+		name      string
+		load      types.Load
+		items     []types.TurvoLineItem
+		wantCodes string
+		wantTotal int
+		wantErr   string
+	}{
+		{
+			name: "Turvo line items are billed by charge key",
+			load: flatRates,
+			items: []types.TurvoLineItem{
+				lineItem(turvoFreightFlatCode.Key, "Freight - flat", 1, 150000),
+				lineItem(testCodes().codes.FuelSurcharge.Key, "Fuel surcharge", 1, 15000),
+				lineItem("1611", "Liftgate", 1, 7500),
+			},
+			wantCodes: "400 FUE LFT", wantTotal: 172500,
+		},
+		{
+			name: "non-billable items are left off",
+			load: flatRates,
+			items: []types.TurvoLineItem{
+				lineItem(turvoFreightFlatCode.Key, "Freight - flat", 1, 150000),
+				{Code: types.TurvoCode{Key: "9999", Value: "Internal note"}, Qty: 1, Amount: 500},
+			},
+			wantCodes: "400", wantTotal: 150000,
+		},
+		{
+			name: "accessorial without an X12 code is rejected",
+			load: flatRates,
+			items: []types.TurvoLineItem{
+				lineItem(turvoFreightFlatCode.Key, "Freight - flat", 1, 150000),
+				lineItem("1612", "Inside pickup", 1, 5000),
+			},
+			wantErr: "Inside pickup (1612)",
+		},
+		{
+			name: "unknown charge is rejected",
+			load: flatRates,
+			items: []types.TurvoLineItem{
+				lineItem(turvoFreightFlatCode.Key, "Freight - flat", 1, 150000),
+				lineItem("4242", "Detention", 2, 7500),
+			},
+			wantErr: "Detention (4242)",
+		},
+		{
+			name:      "without Turvo costs the load's rates are billed",
+			load:      flatRates,
+			wantCodes: "400 FUE", wantTotal: 132000,
+		},
+		{
+			name:      "per-mile fallback adds the accessorial flags",
+			load:      perMileRates,
+			wantCodes: "400 FUE LFT", wantTotal: 100000 + 16000 + 7500,
+		},
+		{
+			name:    "fallback flag without an X12 code is rejected",
+			load:    withInsidePickup,
+			wantErr: "insidePickup",
+		},
+	}
+	for _, test := range tests {
+		costs := types.TurvoCosts{LineItem: test.items}
+		charges, err := invoiceCharges(test.load, costs, testAccessorials(), testCodes())
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: expected an error naming %q, got %v", test.name, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		codes := []string{}
+		total := 0
+		for _, charge := range charges {
+			codes = append(codes, charge.ChargeCode)
+			total += usdToCents(charge.AmountUsd)
+		}
+		if got := strings.Join(codes, " "); got != test.wantCodes {
+			t.Errorf("%s: got charge codes %q, want %q", test.name, got, test.wantCodes)
+		}
+		if total != test.wantTotal {
+			t.Errorf("%s: got total %d cents, want %d", test.name, total, test.wantTotal)
+		}
+	}
+}
+
+func TestInvoiceChargeRateQualifiers(t *testing.T) {
+	load := types.Load{
+		RateData:       types.RateData{CustomerRateType: "per mile", CustomerLhRateUsd: 2.5, FSCPercent: 12},
+		Specifications: types.Specifications{RouteMiles: 400},
+	}
+	charges, err := invoiceCharges(load, types.TurvoCosts{}, testAccessorials(), testCodes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(charges) != 2 || charges[0].RateQualifier != "PM" || charges[1].RateQualifier != "PC" {
+		t.Errorf("expected per-mile linehaul and percent fuel qualifiers, got %+v", charges)
+	}
+
+	costs := types.TurvoCosts{LineItem: []types.TurvoLineItem{
+		lineItem(turvoFreightFlatCode.Key, "Freight - flat", 1, 100000),
+		lineItem("1611", "Liftgate", 2, 7500),
+	}}
+	charges, err = invoiceCharges(load, costs, testAccessorials(), testCodes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if charges[0].RateQualifier != "FR" || charges[1].RateQualifier != "PE" || charges[1].Quantity != 2 || charges[1].RateUsd != 75 {
+		t.Errorf("expected flat linehaul and per-unit liftgate, got %+v", charges)
+	}
+}
+
+func TestReconcileInvoice(t *testing.T) {
+	freight := lineItem(turvoFreightFlatCode.Key, "Freight - flat", 1, 150000)
+
+	tests := []struct {
+		name       string
+		shipment   types.TurvoShipment
+		totalCents int
+		wantIssues []string
+	}{
+		{name: "delivered", shipment: testShipment("Delivered", freight), totalCents: 150000},
+		{name: "ready to bill", shipment: testShipment("Ready to bill", freight), totalCents: 150000},
+		{name: "processing is not delivered", shipment: testShipment("Processing", freight), totalCents: 150000, wantIssues: []string{"expected delivered or ready to bill"}},
+		{name: "carrier paid is not delivered", shipment: testShipment("Carrier paid", freight), totalCents: 150000, wantIssues: []string{"expected delivered or ready to bill"}},
+		{name: "no customer order", shipment: types.TurvoShipment{Status: types.TurvoStatus{Code: types.TurvoCode{Value: "Delivered"}}}, wantIssues: []string{"no customer order"}},
+		{name: "zero total", shipment: testShipment("Delivered"), wantIssues: []string{"greater than zero"}},
+		{name: "priced from the load without Turvo costs", shipment: testShipment("Delivered"), totalCents: 120000},
+		{name: "invoice differs from Turvo", shipment: testShipment("Delivered", freight), totalCents: 140000, wantIssues: []string{"does not match Turvo customer order total"}},
+	}
+	for _, test := range tests {
+		issues := reconcileInvoice(test.shipment, orderCosts(test.shipment), test.totalCents)
+		if len(issues) != len(test.wantIssues) {
+			t.Errorf("%s: got issues %q, want %q", test.name, issues, test.wantIssues)
+			continue
+		}
+		for i, want := range test.wantIssues {
+			if !strings.Contains(issues[i], want) {
+				t.Errorf("%s: issue %q does not mention %q", test.name, issues[i], want)
+			}
+		}
+	}
+
+	// Line items that do not add up to the Turvo total are reported
+	shipment := testShipment("Delivered", freight)
+	shipment.CustomerOrder[0].Costs.TotalAmount = 160000
+	issues := reconcileInvoice(shipment, shipment.CustomerOrder[0].Costs, 150000)
+	if len(issues) != 2 || !strings.Contains(issues[0], "Turvo line items total 1500.00") {
+		t.Errorf("expected line item and total mismatches, got %q", issues)
+	}
+}
+
+func TestGenerate210(t *testing.T) {
+	service := &EDIService{
+		partners: map[string]types.EDIPartner{
+			"acme": withEnvelopeDefaults(types.EDIPartner{ID: "acme", ISASenderID: "BROKER", ISAReceiverID: "ACME", SCAC: "BRKR"}),
+		},
+		controls: newControlNumberStore(filepath.Join(t.TempDir(), "controls.json")),
+	}
+	load := types.Load{
+		Customer:       types.Customer{Name: "Acme Foods", RefNumber: "N/A"},
+		Pickup:         types.Pickup{Name: "Plant", AddressLine1: "1 Main St", City: "Chicago", State: "IL", Zipcode: "60601", Country: "US"},
+		Consignee:      types.Consignee{Name: "DC", AddressLine1: "2 Elm St", City: "Boston", State: "MA", Zipcode: "02108", Country: "US"},
+		Specifications: types.Specifications{TotalWeight: 42000, InPalletCount: 20, PONums: "PO-9"},
+	}
+	shipment := testShipment("Delivered",
+		lineItem(turvoFreightFlatCode.Key, "Freight - flat", 1, 150000),
+		lineItem("1611", "Liftgate", 1, 7500),
+	)
+
+	preview, err := service.Generate210("acme", load, shipment, testAccessorials(), testCodes(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !preview.Valid || preview.TotalUsd != 1575 || preview.BillTo != "Acme Foods" {
+		t.Errorf("unexpected invoice %+v", preview)
+	}
+	wantSegments := []string{
+		"ST*210*0001~",
+		"B3**INV-1**PP*L*",
+		"N9*PO*PO-9~",
+		"N9*CN*SHP-1~",
+		"N1*BT*ACME FOODS~",
+		"N4*CHICAGO*IL*60601*US~",
+		"L1*1*1500.00*FR*150000****400~",
+		"L1*2*75.00*FR*7500****LFT~",
+		"L3*42000*G***157500~",
+		"SE*",
+	}
+	for _, want := range wantSegments {
+		if !strings.Contains(preview.Message.Content, want) {
+			t.Errorf("expected segment %q in:\n%s", want, preview.Message.Content)
+		}
+	}
+	if strings.Contains(preview.Message.Content, "N9*CR") {
+		t.Errorf("expected no customer reference for an unknown value")
+	}
+
+	// Previews show the next control number without consuming it
+	for i := 1; i <= 2; i++ {
+		invoice, err := service.Generate210("acme", load, shipment, testAccessorials(), testCodes(), false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if invoice.Message.ControlNumber != i {
+			t.Errorf("invoice %d: got control number %d", i, invoice.Message.ControlNumber)
+		}
+	}
+	if preview.Message.ControlNumber != 1 {
+		t.Errorf("expected the preview to show control number 1, got %d", preview.Message.ControlNumber)
+	}
+
+	if _, err := service.Generate210("other", load, shipment, testAccessorials(), testCodes(), true); err == nil {
+		t.Errorf("expected an unknown partner to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"turvo-app/types"
+)
+
+// ediOutbox keeps the 990s and 214s sent to trading partners, appending
+// them to a JSON lines file when one is configured. The latest 990 for a
+// shipment and partner decides whether the partner's tender stands.
+type ediOutbox struct {
+	mu       sync.RWMutex
+	path     string
+	messages []types.EDIMessage
+	tenders  map[string]map[string]bool
+}
+
+// newEDIOutbox creates an outbox, reading earlier messages from path
+func newEDIOutbox(path string) *ediOutbox {
+	outbox := &ediOutbox{
+		path:    path,
+		tenders: make(map[string]map[string]bool),
+	}
+	if path == "" {
+		return outbox
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return outbox
+	}
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to open EDI outbox %s: %v\n", path, err)
+		return outbox
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	for scanner.Scan() {
+		var message types.EDIMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			continue
+		}
+		outbox.remember(message)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("DEBUG: Failed to read EDI outbox %s: %v\n", path, err)
+	}
+	return outbox
+}
+
+// add records sent messages, writing them to the file before they count as sent
+func (o *ediOutbox) add(messages ...types.EDIMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.path != "" {
+		for _, message := range messages {
+			if err := appendJSONLine(o.path, message); err != nil {
+				return fmt.Errorf("failed to write EDI outbox: %w", err)
+			}
+		}
+	}
+	for _, message := range messages {
+		o.remember(message)
+	}
+	return nil
+}
+
+// remember adds a message to memory and tracks accepted and declined tenders.
+// Callers hold the lock or own the outbox.
+func (o *ediOutbox) remember(message types.EDIMessage) {
+	o.messages = append(o.messages, message)
+	if message.TransactionSet != "990" || message.ShipmentID == "" {
+		return
+	}
+	if o.tenders[message.ShipmentID] == nil {
+		o.tenders[message.ShipmentID] = make(map[string]bool)
+	}
+	o.tenders[message.ShipmentID][message.PartnerID] = message.StatusCode == "A"
+}
+
+// list returns the messages for a shipment, optionally limited to a partner
+// and transaction set
+func (o *ediOutbox) list(shipmentID, partnerID, transactionSet string) []types.EDIMessage {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	messages := []types.EDIMessage{}
+	for _, message := range o.messages {
+		if message.ShipmentID != shipmentID ||
+			(partnerID != "" && message.PartnerID != partnerID) ||
+			(transactionSet != "" && message.TransactionSet != transactionSet) {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// tenderedPartners returns the partners whose tender of a shipment was accepted
+func (o *ediOutbox) tenderedPartners(shipmentID string) []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	partners := []string{}
+	for partnerID, accepted := range o.tenders[shipmentID] {
+		if accepted {
+			partners = append(partners, partnerID)
+		}
+	}
+	sort.Strings(partners)
+	return partners
+}
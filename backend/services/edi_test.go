@@ -0,0 +1,247 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"turvo-app/types"
+)
+
+// newTestEDIService builds a service with one partner whose control numbers
+// and outbox live in dir
+func newTestEDIService(dir string) *EDIService {
+	return &EDIService{
+		partners: map[string]types.EDIPartner{
+			"acme": withEnvelopeDefaults(types.EDIPartner{ID: "acme", ISASenderID: "BROKER", ISAReceiverID: "ACME", SCAC: "BRKR"}),
+		},
+		controls: newControlNumberStore(filepath.Join(dir, "controls.json")),
+		outbox:   newEDIOutbox(filepath.Join(dir, "outbox.jsonl")),
+	}
+}
+
+// movingShipment builds a shipment in status whose pickup has been arrived
+// at and, when departed is set, left
+func movingShipment(status string, departed bool) types.TurvoShipment {
+	pickup := types.TurvoGlobalRoute{
+		GlobalShipLocationSourceId: "stop-1",
+		StopType:                   types.TurvoCode{Key: "1500", Value: "Pickup"},
+		Timezone:                   "America/Chicago",
+		Location:                   types.TurvoLocation{City: "Chicago", State: "IL", Country: "US"},
+		Arrival:                    &types.TurvoDate{Date: "2026-10-20T13:05:00Z"},
+	}
+	if departed {
+		pickup.Departed = &types.TurvoDate{Date: "2026-10-20T15:30:00Z"}
+	}
+	delivery := types.TurvoGlobalRoute{
+		GlobalShipLocationSourceId: "stop-2",
+		StopType:                   types.TurvoCode{Key: "1501", Value: "Delivery"},
+		Location:                   types.TurvoLocation{City: "Boston", State: "MA", Country: "US"},
+	}
+	return types.TurvoShipment{
+		ShipmentID:  "SHP-1",
+		Status:      types.TurvoStatus{Code: types.TurvoCode{Key: "2103", Value: status}},
+		GlobalRoute: []types.TurvoGlobalRoute{pickup, delivery},
+	}
+}
+
+func TestGenerate214Segments(t *testing.T) {
+	service := newTestEDIService(t.TempDir())
+	load := types.Load{FreightLoadID: "TENDER-9", Specifications: types.Specifications{PONums: "PO-9"}}
+	shipment := movingShipment("Picked up", true)
+
+	changes := shipmentChanges(shipment, shipmentSnapshot{}, snapshotShipment(shipment), false)
+	messages, err := service.Generate214("acme", load, shipment, changes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Arrival and departure at pickup; the picked-up status repeats AF and is not sent twice
+	if len(messages) != 2 || messages[0].StatusCode != "X3" || messages[1].StatusCode != "AF" {
+		t.Fatalf("expected X3 then AF, got %+v", messages)
+	}
+
+	wantSegments := []string{
+		"*000000001*0*T*>~",
+		"GS*QM*BROKER*ACME*",
+		"ST*214*0001~",
+		"B10*SHP-1*TENDER-9*BRKR~",
+		"L11*PO-9*PO~",
+		"AT7*X3*NS***20261020*0805*LT~",
+		"MS1*CHICAGO*IL*US~",
+		"SE*7*0001~",
+		"GE*1*1~",
+		"IEA*1*000000001~",
+	}
+	for _, want := range wantSegments {
+		if !strings.Contains(messages[0].Content, want) {
+			t.Errorf("expected segment %q in:\n%s", want, messages[0].Content)
+		}
+	}
+	if messages[1].ControlNumber != 2 || !strings.Contains(messages[1].Content, "AT7*AF*NS***20261020*1030*LT~") {
+		t.Errorf("expected the departure as control number 2, got %d:\n%s", messages[1].ControlNumber, messages[1].Content)
+	}
+
+	if _, err := service.Generate214("other", load, shipment, changes); err == nil {
+		t.Errorf("expected an unknown partner to be rejected")
+	}
+}
+
+func TestStatusCodeForChange(t *testing.T) {
+	shipment := movingShipment("At delivery", true)
+	pickup, delivery := shipment.GlobalRoute[0], shipment.GlobalRoute[1]
+
+	tests := []struct {
+		name     string
+		change   types.ShipmentChange
+		wantCode string
+		wantStop string
+	}{
+		{"pickup arrival", types.ShipmentChange{Type: types.ChangeStopArrived, Stop: &pickup}, "X3", "stop-1"},
+		{"pickup departure", types.ShipmentChange{Type: types.ChangeStopDeparted, Stop: &pickup}, "AF", "stop-1"},
+		{"delivery arrival", types.ShipmentChange{Type: types.ChangeStopArrived, Stop: &delivery}, "X1", "stop-2"},
+		{"delivery departure", types.ShipmentChange{Type: types.ChangeStopDeparted, Stop: &delivery}, "D1", "stop-2"},
+		{"delivered status reports at the delivery", types.ShipmentChange{Type: types.ChangeStatus, Status: types.TurvoStatus{Code: types.TurvoCode{Value: "Delivered"}}}, "D1", "stop-2"},
+		{"in transit has no stop", types.ShipmentChange{Type: types.ChangeStatus, Status: types.TurvoStatus{Code: types.TurvoCode{Value: " In Transit "}}}, "X6", ""},
+		{"unmapped status", types.ShipmentChange{Type: types.ChangeStatus, Status: types.TurvoStatus{Code: types.TurvoCode{Value: "Tendered"}}}, "", ""},
+	}
+	for _, test := range tests {
+		code, stop := statusCodeForChange(test.change, shipment)
+		stopID := ""
+		if stop != nil {
+			stopID = stop.GlobalShipLocationSourceId
+		}
+		if code != test.wantCode || stopID != test.wantStop {
+			t.Errorf("%s: got %q at %q, want %q at %q", test.name, code, stopID, test.wantCode, test.wantStop)
+		}
+	}
+}
+
+func TestReport214ReportsEachChangeOnce(t *testing.T) {
+	service := newTestEDIService(t.TempDir())
+	tracker := NewShipmentTracker()
+	shipment := movingShipment("At pickup", false)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sent := []types.EDIMessage{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			messages, _, err := service.Report214("acme", types.Load{}, shipment, tracker)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			sent = append(sent, messages...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(sent) != 1 || sent[0].StatusCode != "X3" || sent[0].ControlNumber != 1 {
+		t.Fatalf("expected a single X3 with control number 1, got %+v", sent)
+	}
+
+	// The departure is the only new change
+	messages, changes, err := service.Report214("acme", types.Load{}, movingShipment("Picked up", true), tracker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 2 || len(messages) != 1 || messages[0].StatusCode != "AF" || messages[0].ControlNumber != 2 {
+		t.Errorf("expected one AF for the departure and status change, got %+v", messages)
+	}
+	if got := service.Messages("SHP-1", "acme", "214"); len(got) != 2 {
+		t.Errorf("expected both 214s in the outbox, got %d", len(got))
+	}
+}
+
+func TestReport214RetriesAfterAFailedSave(t *testing.T) {
+	dir := t.TempDir()
+	service := newTestEDIService(dir)
+	tracker := NewShipmentTracker()
+	shipment := movingShipment("At pickup", false)
+
+	// A directory where the outbox file should be makes the save fail
+	service.outbox.path = filepath.Join(dir, "blocked")
+	if err := os.Mkdir(service.outbox.path, 0700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if _, _, err := service.Report214("acme", types.Load{}, shipment, tracker); err == nil {
+		t.Fatalf("expected the failed save to be reported")
+	}
+
+	service.outbox.path = filepath.Join(dir, "outbox.jsonl")
+	messages, _, err := service.Report214("acme", types.Load{}, shipment, tracker)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 1 || messages[0].StatusCode != "X3" {
+		t.Errorf("expected the arrival to be reported again, got %+v", messages)
+	}
+}
+
+func TestTenderedPartners(t *testing.T) {
+	dir := t.TempDir()
+	service := newTestEDIService(dir)
+	load := types.Load{ExternalTMSLoadID: "SHP-1"}
+
+	if _, err := service.Generate990("acme", load, true, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := service.TenderedPartners("SHP-1"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("expected acme's accepted tender, got %q", got)
+	}
+
+	// Accepted tenders are read back from the outbox file
+	reloaded := newTestEDIService(dir)
+	if got := reloaded.TenderedPartners("SHP-1"); len(got) != 1 {
+		t.Errorf("expected the tender to survive a restart, got %q", got)
+	}
+
+	if _, err := reloaded.Generate990("acme", load, false, "No capacity"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := reloaded.TenderedPartners("SHP-1"); len(got) != 0 {
+		t.Errorf("expected the declined tender to stop 214s, got %q", got)
+	}
+}
+
+func TestControlNumbers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls.json")
+	data, _ := json.Marshal(map[string]int{"acme": 999999998})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write control numbers: %v", err)
+	}
+	store := newControlNumberStore(path)
+
+	tests := []struct {
+		partner string
+		want    int
+	}{
+		{"acme", 999999999},
+		{"acme", 1},
+		{"acme", 2},
+		{"other", 1},
+	}
+	for _, test := range tests {
+		if peek := store.peek(test.partner); peek != test.want {
+			t.Errorf("%s: peek returned %d, want %d", test.partner, peek, test.want)
+		}
+		got, err := store.next(test.partner)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got control number %d, want %d", test.partner, got, test.want)
+		}
+	}
+
+	if reloaded := newControlNumberStore(path); reloaded.peek("acme") != 3 || reloaded.peek("other") != 2 {
+		t.Errorf("expected control numbers to be read back from the file")
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"turvo-app/types"
+)
+
+// Equipment types accepted on loads
+const (
+	EquipmentDryVan   = "dry van"
+	EquipmentReefer   = "reefer"
+	EquipmentFlatbed  = "flatbed"
+	EquipmentStepDeck = "step deck"
+)
+
+// equipmentLabels are the printed names of each equipment type
+var equipmentLabels = map[string]string{
+	EquipmentDryVan:   "Van",
+	EquipmentReefer:   "Reefer",
+	EquipmentFlatbed:  "Flatbed",
+	EquipmentStepDeck: "Step deck",
+}
+
+// equipmentAliases are the accepted spellings of each equipment type
+var equipmentAliases = map[string]string{
+	"dry van":      EquipmentDryVan,
+	"dryvan":       EquipmentDryVan,
+	"van":          EquipmentDryVan,
+	"v":            EquipmentDryVan,
+	"reefer":       EquipmentReefer,
+	"refrigerated": EquipmentReefer,
+	"r":            EquipmentReefer,
+	"flatbed":      EquipmentFlatbed,
+	"flat":         EquipmentFlatbed,
+	"f":            EquipmentFlatbed,
+	"step deck":    EquipmentStepDeck,
+	"stepdeck":     EquipmentStepDeck,
+	"drop deck":    EquipmentStepDeck,
+	"sd":           EquipmentStepDeck,
+}
+
+// trailerLengths are the trailer lengths in feet accepted on loads
+var trailerLengths = []int{20, 28, 40, 45, 48, 53}
+
+// supportedTrailerLength reports whether a trailer length is accepted on loads
+func supportedTrailerLength(lengthFt int) bool {
+	for _, length := range trailerLengths {
+		if length == lengthFt {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEquipmentType maps an equipment type or alias to its canonical
+// name. An empty type is a reefer when a temperature range is set and a dry
+// van otherwise.
+func normalizeEquipmentType(equipmentType string, specs types.Specifications) (string, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(equipmentType))
+	normalized = strings.NewReplacer("_", " ", "-", " ").Replace(normalized)
+	if normalized == "" {
+		if hasTemperatureRange(specs) {
+			return EquipmentReefer, true
+		}
+		return EquipmentDryVan, true
+	}
+	canonical, ok := equipmentAliases[normalized]
+	return canonical, ok
+}
+
+// hasTemperatureRange reports whether either end of a temperature range was given
+func hasTemperatureRange(specs types.Specifications) bool {
+	return specs.MinTempFahrenheit != nil || specs.MaxTempFahrenheit != nil
+}
+
+// temperatureRange returns the temperature range when both ends were given
+func temperatureRange(specs types.Specifications) (float64, float64, bool) {
+	if specs.MinTempFahrenheit == nil || specs.MaxTempFahrenheit == nil {
+		return 0, 0, false
+	}
+	return *specs.MinTempFahrenheit, *specs.MaxTempFahrenheit, true
+}
+
+// turvoEquipment converts a load's equipment to Turvo equipment. Reefers
+// carry the midpoint of the temperature range as their set point. Loads
+// whose equipment type has no Turvo code are sent without equipment.
+func turvoEquipment(load types.Load, codes *TurvoCodeTable) []types.TurvoEquipment {
+	equipmentType, ok := normalizeEquipmentType(load.Equipment.Type, load.Specifications)
+	if !ok {
+		return nil
+	}
+	equipmentCode, ok := codes.Equipment(equipmentType)
+	if !ok {
+		return nil
+	}
+
+	equipment := types.TurvoEquipment{
+		Operation: 0,
+		Type:      equipmentCode,
+	}
+	equipment.Size, _ = codes.TrailerSize(load.Equipment.TrailerLengthFt)
+	if minTemp, maxTemp, ok := temperatureRange(load.Specifications); ok && equipmentType == EquipmentReefer {
+		equipment.Temp = int(math.Round((minTemp + maxTemp) / 2))
+		equipment.TempUnits = codes.codes.TemperatureUnit
+	}
+	return []types.TurvoEquipment{equipment}
+}
+
+// itemTemperatures returns the Turvo item temperature range for reefer loads
+func itemTemperatures(load types.Load, codes *TurvoCodeTable) (types.TurvoTemperature, types.TurvoTemperature) {
+	equipmentType, _ := normalizeEquipmentType(load.Equipment.Type, load.Specifications)
+	minTemp, maxTemp, ok := temperatureRange(load.Specifications)
+	if equipmentType != EquipmentReefer || !ok {
+		return types.TurvoTemperature{}, types.TurvoTemperature{}
+	}
+	unit := codes.codes.TemperatureUnit
+	return types.TurvoTemperature{Temp: int(math.Round(minTemp)), TempUnit: unit},
+		types.TurvoTemperature{Temp: int(math.Round(maxTemp)), TempUnit: unit}
+}
+
+// EquipmentFromTurvo reads a load's equipment and temperature range back from
+// a Turvo shipment, leaving specs untouched when Turvo has no temperatures
+func EquipmentFromTurvo(shipment types.TurvoShipment, specs *types.Specifications, codes *TurvoCodeTable) types.Equipment {
+	equipment := types.Equipment{}
+	if len(shipment.Equipment) > 0 {
+		turvo := shipment.Equipment[0]
+		equipment.Type, _ = codeName(codes.codes.Equipment, turvo.Type.Key)
+		for length, code := range codes.codes.TrailerSizes {
+			if code.Key == turvo.Size.Key {
+				equipment.TrailerLengthFt = length
+			}
+		}
+	}
+
+	if len(shipment.CustomerOrder) > 0 {
+		for _, item := range shipment.CustomerOrder[0].Items {
+			if item.MinTemp.TempUnit.Key != "" || item.MaxTemp.TempUnit.Key != "" {
+				minTemp, maxTemp := float64(item.MinTemp.Temp), float64(item.MaxTemp.Temp)
+				specs.MinTempFahrenheit, specs.MaxTempFahrenheit = &minTemp, &maxTemp
+				break
+			}
+		}
+	}
+	return equipment
+}
+
+// equipmentLabel describes a load's equipment for printing, such as "Reefer 53 ft"
+func equipmentLabel(load types.Load) string {
+	equipmentType, ok := normalizeEquipmentType(load.Equipment.Type, load.Specifications)
+	if !ok {
+		return ""
+	}
+	label := equipmentLabels[equipmentType]
+	if load.Equipment.TrailerLengthFt > 0 {
+		label += fmt.Sprintf(" %d ft", load.Equipment.TrailerLengthFt)
+	}
+	return label
+}
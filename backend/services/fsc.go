@@ -0,0 +1,262 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// dieselDateFormats are the accepted date formats in the diesel price file
+var dieselDateFormats = []string{"2006-01-02", "01/02/2006", "1/2/2006", "Jan 02, 2006"}
+
+// FuelSurchargeService computes fuel surcharges from per-customer schedules
+// and the weekly DOE diesel price series
+type FuelSurchargeService struct {
+	schedules []types.FSCSchedule
+	prices    []types.DieselPrice
+}
+
+// NewFuelSurchargeService creates a new fuel surcharge service, loading schedules and diesel prices from the configured files
+func NewFuelSurchargeService(cfg *config.Config) *FuelSurchargeService {
+	service := &FuelSurchargeService{}
+
+	if cfg.FSCSchedulesFile != "" {
+		if err := readJSONFile(cfg.FSCSchedulesFile, &service.schedules); err != nil {
+			fmt.Printf("DEBUG: Failed to load FSC schedules from %s: %v\n", cfg.FSCSchedulesFile, err)
+		}
+		for i, schedule := range service.schedules {
+			if err := validateFSCSchedule(schedule); err != nil {
+				fmt.Printf("DEBUG: Ignoring FSC schedule %q: %v\n", schedule.ID, err)
+				service.schedules[i].Bands = nil
+			}
+		}
+	}
+
+	if cfg.DieselPricesFile != "" {
+		prices, err := readDieselPrices(cfg.DieselPricesFile)
+		if err != nil {
+			fmt.Printf("DEBUG: Failed to load diesel prices from %s: %v\n", cfg.DieselPricesFile, err)
+		}
+		service.prices = prices
+	}
+
+	fmt.Printf("DEBUG: Loaded %d FSC schedules and %d diesel prices\n", len(service.schedules), len(service.prices))
+	return service
+}
+
+// Quote returns the fuel surcharge that applies to a customer on a date. The
+// customer is matched by Turvo customer ID or name, falling back to the
+// default schedule. The diesel price is the latest published on or before date.
+func (s *FuelSurchargeService) Quote(customer string, date time.Time) (*types.FSCQuote, error) {
+	return s.quote(date, customer)
+}
+
+// quote returns the fuel surcharge for the first of customers with a schedule,
+// falling back to the default schedule
+func (s *FuelSurchargeService) quote(date time.Time, customers ...string) (*types.FSCQuote, error) {
+	schedule, customer, ok := s.scheduleFor(customers...)
+	if !ok {
+		return nil, fmt.Errorf("no FSC schedule for customer %q", firstKnown(customers...))
+	}
+
+	price, ok := s.dieselPrice(date)
+	if !ok {
+		return nil, fmt.Errorf("no diesel price published on or before %s", date.Format("2006-01-02"))
+	}
+
+	band, ok := fscBand(schedule, price.PriceUsd)
+	if !ok {
+		return nil, fmt.Errorf("diesel price %.3f is outside FSC schedule %q", price.PriceUsd, schedule.ID)
+	}
+
+	quote := &types.FSCQuote{
+		Customer:       customer,
+		Date:           date,
+		ScheduleID:     schedule.ID,
+		Method:         schedule.Method,
+		DieselPriceUsd: price.PriceUsd,
+		PriceDate:      price.Date,
+	}
+	if schedule.Method == types.FSCMethodPercent {
+		quote.FSCPercent = band.Value
+	} else {
+		// Per-mile bands are stored in cents
+		quote.FSCPerMile = band.Value / 100
+	}
+	return quote, nil
+}
+
+// ApplyToLoad fills the load's fuel surcharge from its customer's schedule
+// when neither FSC field was entered. The pickup appointment date selects the
+// diesel price, or today when the load has none.
+func (s *FuelSurchargeService) ApplyToLoad(load types.Load) (types.Load, *types.FSCQuote, error) {
+	if load.RateData.FSCPercent != 0 || load.RateData.FSCPerMile != 0 {
+		return load, nil, nil
+	}
+
+	date := load.Pickup.ApptTime
+	if date.IsZero() {
+		date = load.Pickup.ReadyTime
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	// A schedule keyed by the customer's Turvo ID wins over one keyed by its name
+	quote, err := s.applyToRates(&load.RateData, date, load.Customer.ExternalTMSId, load.Customer.Name)
+	return load, quote, err
+}
+
+// ApplyToRates fills FSCPercent or FSCPerMile on rates when neither is set.
+// It returns a nil quote without error when no schedule applies.
+func (s *FuelSurchargeService) ApplyToRates(rates *types.RateData, customer string, date time.Time) (*types.FSCQuote, error) {
+	return s.applyToRates(rates, date, customer)
+}
+
+// applyToRates fills the fuel surcharge from the first of customers with a
+// schedule, falling back to the default schedule
+func (s *FuelSurchargeService) applyToRates(rates *types.RateData, date time.Time, customers ...string) (*types.FSCQuote, error) {
+	if rates.FSCPercent != 0 || rates.FSCPerMile != 0 {
+		return nil, nil
+	}
+	if _, _, ok := s.scheduleFor(customers...); !ok {
+		return nil, nil
+	}
+
+	quote, err := s.quote(date, customers...)
+	if err != nil {
+		return nil, err
+	}
+	rates.FSCPercent = quote.FSCPercent
+	rates.FSCPerMile = quote.FSCPerMile
+	return quote, nil
+}
+
+// scheduleFor returns the schedule of the first of customers, IDs or names,
+// that has one, and the customer it matched. Without a match it falls back to
+// the default schedule and the first known customer.
+func (s *FuelSurchargeService) scheduleFor(customers ...string) (types.FSCSchedule, string, bool) {
+	for _, customer := range customers {
+		customer = knownValue(customer)
+		if customer == "" {
+			continue
+		}
+		for _, schedule := range s.schedules {
+			if len(schedule.Bands) == 0 {
+				continue
+			}
+			for _, name := range schedule.Customers {
+				if strings.EqualFold(strings.TrimSpace(name), customer) {
+					return schedule, customer, true
+				}
+			}
+		}
+	}
+	for _, schedule := range s.schedules {
+		if len(schedule.Bands) > 0 && schedule.Default {
+			return schedule, firstKnown(customers...), true
+		}
+	}
+	return types.FSCSchedule{}, "", false
+}
+
+// dieselPrice returns the latest diesel price published on or before date
+func (s *FuelSurchargeService) dieselPrice(date time.Time) (types.DieselPrice, bool) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	index := sort.Search(len(s.prices), func(i int) bool {
+		return s.prices[i].Date.After(day)
+	})
+	if index == 0 {
+		return types.DieselPrice{}, false
+	}
+	return s.prices[index-1], true
+}
+
+// fscBand finds the band containing price. Bands include their minimum and exclude their maximum.
+func fscBand(schedule types.FSCSchedule, price float64) (types.FSCBand, bool) {
+	for _, band := range schedule.Bands {
+		if price >= band.MinPriceUsd && (band.MaxPriceUsd == 0 || price < band.MaxPriceUsd) {
+			return band, true
+		}
+	}
+	return types.FSCBand{}, false
+}
+
+// validateFSCSchedule checks a schedule's method and band ranges
+func validateFSCSchedule(schedule types.FSCSchedule) error {
+	if schedule.Method != types.FSCMethodPercent && schedule.Method != types.FSCMethodPerMile {
+		return fmt.Errorf("method must be %q or %q", types.FSCMethodPercent, types.FSCMethodPerMile)
+	}
+	if len(schedule.Bands) == 0 {
+		return fmt.Errorf("schedule has no bands")
+	}
+	for _, band := range schedule.Bands {
+		if band.Value < 0 {
+			return fmt.Errorf("band starting at %.3f has a negative value", band.MinPriceUsd)
+		}
+		if band.MaxPriceUsd != 0 && band.MaxPriceUsd <= band.MinPriceUsd {
+			return fmt.Errorf("band starting at %.3f ends before it starts", band.MinPriceUsd)
+		}
+	}
+	return nil
+}
+
+// readDieselPrices reads a date,price CSV of weekly diesel prices sorted by date.
+// A header row and blank lines are skipped.
+func readDieselPrices(path string) ([]types.DieselPrice, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	prices := []types.DieselPrice{}
+	for i, record := range records {
+		if len(record) < 2 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		date, ok := parseDieselDate(record[0])
+		if !ok {
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid date %q", i+1, record[0])
+		}
+		price, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(record[1]), "$"), 64)
+		if err != nil || price <= 0 {
+			return nil, fmt.Errorf("line %d: invalid price %q", i+1, record[1])
+		}
+		prices = append(prices, types.DieselPrice{Date: date, PriceUsd: price})
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Date.Before(prices[j].Date)
+	})
+	return prices, nil
+}
+
+// parseDieselDate parses a date in any of the accepted formats
+func parseDieselDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, format := range dieselDateFormats {
+		if date, err := time.Parse(format, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"turvo-app/types"
+)
+
+// hazmatNotePrefix starts the item note line carrying a commodity's hazmat details
+const hazmatNotePrefix = "Hazmat: "
+
+// unNumberPattern matches a UN or NA identification number such as UN1993
+var unNumberPattern = regexp.MustCompile(`^(UN|NA)\d{4}$`)
+
+// hazardClassPattern matches a DOT hazard class or division, with a
+// compatibility group letter for explosives
+var hazardClassPattern = regexp.MustCompile(`^(1\.[1-6][A-L]?|2\.[1-3]|3|4\.[1-3]|5\.[12]|6\.[12]|7|8|9)$`)
+
+// packingGroups are the valid packing groups
+var packingGroups = map[string]bool{"I": true, "II": true, "III": true}
+
+// hazmatPlacards maps hazard classes and divisions to their placard names
+var hazmatPlacards = map[string]string{
+	"1":   "EXPLOSIVES",
+	"1.4": "EXPLOSIVES 1.4",
+	"1.5": "EXPLOSIVES 1.5",
+	"1.6": "EXPLOSIVES 1.6",
+	"2.1": "FLAMMABLE GAS",
+	"2.2": "NON-FLAMMABLE GAS",
+	"2.3": "POISON GAS",
+	"3":   "FLAMMABLE",
+	"4.1": "FLAMMABLE SOLID",
+	"4.2": "SPONTANEOUSLY COMBUSTIBLE",
+	"4.3": "DANGEROUS WHEN WET",
+	"5.1": "OXIDIZER",
+	"5.2": "ORGANIC PEROXIDE",
+	"6.1": "POISON",
+	"7":   "RADIOACTIVE",
+	"8":   "CORROSIVE",
+	"9":   "CLASS 9",
+}
+
+// normalizeHazmat tidies identification numbers and packing groups and fills
+// the placard name from the hazard class when a placard is required
+func normalizeHazmat(hazmat *types.Hazmat) {
+	hazmat.UNNumber = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(hazmat.UNNumber), " ", ""))
+	hazmat.ProperShippingName = strings.TrimSpace(hazmat.ProperShippingName)
+	hazmat.HazardClass = strings.ToUpper(strings.TrimSpace(hazmat.HazardClass))
+	hazmat.PackingGroup = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(hazmat.PackingGroup)), "PG ")
+	hazmat.Placard = strings.ToUpper(strings.TrimSpace(hazmat.Placard))
+	if hazmat.PlacardRequired && hazmat.Placard == "" {
+		hazmat.Placard = hazmatPlacard(hazmat.HazardClass)
+	}
+}
+
+// hazmatPlacard returns the placard name for a hazard class, checking the
+// division before the class
+func hazmatPlacard(hazardClass string) string {
+	division := hazardClass
+	if len(division) > 3 {
+		division = division[:3]
+	}
+	if placard, ok := hazmatPlacards[division]; ok {
+		return placard
+	}
+	return hazmatPlacards[strings.SplitN(hazardClass, ".", 2)[0]]
+}
+
+// requiresPackingGroup reports whether a hazard class is assigned a packing
+// group. Explosives, gases, infectious substances and radioactive material are not.
+func requiresPackingGroup(hazardClass string) bool {
+	switch {
+	case strings.HasPrefix(hazardClass, "1"), strings.HasPrefix(hazardClass, "2"),
+		hazardClass == "6.2", hazardClass == "7":
+		return false
+	}
+	return true
+}
+
+// hazmatDescription formats the shipping description printed on shipping
+// papers, such as "UN1993, Flammable liquid, n.o.s., 3, PG II"
+func hazmatDescription(hazmat types.Hazmat) string {
+	parts := nonEmpty(hazmat.UNNumber, hazmat.ProperShippingName, hazmat.HazardClass)
+	if hazmat.PackingGroup != "" {
+		parts = append(parts, "PG "+hazmat.PackingGroup)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hazmatNote formats hazmat details as an item note line, encoded as JSON so
+// that names and contacts may contain any punctuation
+func hazmatNote(hazmat types.Hazmat) string {
+	if !hazmat.PlacardRequired {
+		hazmat.Placard = ""
+	}
+	data, err := json.Marshal(hazmat)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to encode hazmat details: %v\n", err)
+		return hazmatNotePrefix
+	}
+	return hazmatNotePrefix + string(data)
+}
+
+// parseHazmatNote reads hazmat details back from item notes. Items flagged
+// hazmat without a readable details line return empty details.
+func parseHazmatNote(notes string) *types.Hazmat {
+	for _, line := range strings.Split(notes, "\n") {
+		if !strings.HasPrefix(line, hazmatNotePrefix) {
+			continue
+		}
+		hazmat := &types.Hazmat{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, hazmatNotePrefix)), hazmat); err != nil {
+			fmt.Printf("DEBUG: Ignoring unreadable hazmat details %q: %v\n", line, err)
+			continue
+		}
+		return hazmat
+	}
+	return &types.Hazmat{}
+}
+
+// validateHazmat checks that hazmat loads describe their dangerous goods and
+// that each commodity's details are complete enough for shipping papers
+func validateHazmat(load types.Load, add func(field, format string, args ...interface{})) {
+	hasDetails := false
+	for _, commodity := range load.Commodities {
+		if commodity.Hazmat != nil {
+			hasDetails = true
+		}
+	}
+	if load.Specifications.Hazmat && !hasDetails {
+		add("specifications.hazmat", "hazmat loads require hazmat details on at least one commodity")
+	}
+
+	for i, commodity := range load.Commodities {
+		if commodity.Hazmat == nil {
+			continue
+		}
+		hazmat := *commodity.Hazmat
+		normalizeHazmat(&hazmat)
+		field := func(name string) string {
+			return fmt.Sprintf("commodities[%d].hazmat.%s", i, name)
+		}
+
+		if !unNumberPattern.MatchString(hazmat.UNNumber) {
+			add(field("unNumber"), "must be UN or NA followed by 4 digits, such as UN1993")
+		}
+		if hazmat.ProperShippingName == "" {
+			add(field("properShippingName"), "is required")
+		}
+		if !hazardClassPattern.MatchString(hazmat.HazardClass) {
+			add(field("hazardClass"), "unsupported hazard class %q", commodity.Hazmat.HazardClass)
+		} else if requiresPackingGroup(hazmat.HazardClass) && hazmat.PackingGroup == "" {
+			add(field("packingGroup"), "is required for hazard class %s", hazmat.HazardClass)
+		}
+		if hazmat.PackingGroup != "" && !packingGroups[hazmat.PackingGroup] {
+			add(field("packingGroup"), "must be I, II or III")
+		}
+		if strings.TrimSpace(hazmat.EmergencyContact) == "" {
+			add(field("emergencyContact"), "is required")
+		}
+		if strings.TrimSpace(hazmat.EmergencyPhone) == "" {
+			add(field("emergencyPhone"), "is required")
+		}
+		if hazmat.PlacardRequired && hazmat.Placard == "" {
+			add(field("placard"), "is required when no placard is defined for hazard class %q", hazmat.HazardClass)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"turvo-app/types"
+)
+
+// LoadStore keeps the Drumkit view of loads created through this API, keyed
+// by Turvo shipment ID. Turvo does not hand back every Drumkit field on reads,
+// so the stored copy fills in what the shipment conversion cannot.
+type LoadStore struct {
+	mu    sync.RWMutex
+	loads map[string]types.Load
+}
+
+// NewLoadStore creates a new in-memory load store
+func NewLoadStore() *LoadStore {
+	return &LoadStore{
+		loads: make(map[string]types.Load),
+	}
+}
+
+// Save stores a load under its Turvo shipment ID
+func (s *LoadStore) Save(load types.Load) {
+	if load.ExternalTMSLoadID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads[load.ExternalTMSLoadID] = load
+}
+
+// Get returns the stored load for a Turvo shipment ID
+func (s *LoadStore) Get(shipmentID string) (types.Load, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	load, ok := s.loads[shipmentID]
+	return load, ok
+}
+
+// zeroTimeJSON is how an unset time.Time marshals
+const zeroTimeJSON = "0001-01-01T00:00:00Z"
+
+// MergeStoredLoad fills the fields a load converted from Turvo left empty
+// with the stored copy. Every value Turvo returns wins, so edits made in
+// Turvo after the load was created are kept.
+func MergeStoredLoad(load, stored types.Load) (types.Load, error) {
+	current, err := loadFields(load)
+	if err != nil {
+		return load, err
+	}
+	fallback, err := loadFields(stored)
+	if err != nil {
+		return load, err
+	}
+	fillUnknownFields(current, fallback)
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return load, fmt.Errorf("failed to marshal merged load: %w", err)
+	}
+	var merged types.Load
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return load, fmt.Errorf("failed to unmarshal merged load: %w", err)
+	}
+	return merged, nil
+}
+
+// loadFields returns a load as a JSON object
+func loadFields(load types.Load) (map[string]interface{}, error) {
+	data, err := json.Marshal(load)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal load: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal load: %w", err)
+	}
+	return fields, nil
+}
+
+// fillUnknownFields copies fallback values into fields of dst that are
+// missing or unknown, descending into nested objects
+func fillUnknownFields(dst, fallback map[string]interface{}) {
+	for key, value := range fallback {
+		existing, ok := dst[key]
+		if nested, isObject := existing.(map[string]interface{}); isObject {
+			if fallbackNested, isObject := value.(map[string]interface{}); isObject {
+				fillUnknownFields(nested, fallbackNested)
+			}
+			continue
+		}
+		if !ok || unknownJSONValue(existing) {
+			dst[key] = value
+		}
+	}
+}
+
+// unknownJSONValue reports whether a decoded JSON value carries no information
+func unknownJSONValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return knownValue(v) == "" || v == zeroTimeJSON
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
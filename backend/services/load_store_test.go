@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"turvo-app/types"
+)
+
+func TestMergeStoredLoad(t *testing.T) {
+	appt := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)
+	stored := types.Load{
+		ExternalTMSLoadID: "SHP-1",
+		Status:            "Tendered",
+		Customer:          types.Customer{ExternalTMSId: "C-42", Name: "Acme Foods", RefNumber: "REF-1"},
+		Carrier:           types.Carrier{Name: "Old Carrier", MCNumber: "MC123456", ExternalTMSTruckID: "T-1"},
+		Pickup:            types.Pickup{City: "Chicago", Contact: "Dana", ApptTime: appt.Add(-24 * time.Hour)},
+		RateData:          types.RateData{CustomerRateType: "flat", CustomerLhRateUsd: 1200},
+		Specifications:    types.Specifications{PONums: "PO-9", RouteMiles: 900},
+	}
+	turvo := types.Load{
+		ExternalTMSLoadID: "SHP-1",
+		Status:            "Delivered",
+		Customer:          types.Customer{ExternalTMSId: "N/A", Name: "Acme Foods Inc", RefNumber: "N/A"},
+		Carrier:           types.Carrier{Name: "New Carrier", ExternalTMSTruckID: "T-2"},
+		Pickup:            types.Pickup{City: "Joliet", Contact: "N/A", ApptTime: appt},
+		Specifications:    types.Specifications{PONums: "N/A", RouteMiles: 950},
+	}
+
+	merged, err := MergeStoredLoad(turvo, stored)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"status from Turvo", merged.Status, "Delivered"},
+		{"customer name from Turvo", merged.Customer.Name, "Acme Foods Inc"},
+		{"customer ID from the stored copy", merged.Customer.ExternalTMSId, "C-42"},
+		{"customer reference from the stored copy", merged.Customer.RefNumber, "REF-1"},
+		{"carrier from Turvo", merged.Carrier.Name, "New Carrier"},
+		{"truck from Turvo", merged.Carrier.ExternalTMSTruckID, "T-2"},
+		{"MC number from the stored copy", merged.Carrier.MCNumber, "MC123456"},
+		{"stop city from Turvo", merged.Pickup.City, "Joliet"},
+		{"stop contact from the stored copy", merged.Pickup.Contact, "Dana"},
+		{"appointment from Turvo", merged.Pickup.ApptTime.Equal(appt), true},
+		{"rates from the stored copy", merged.RateData.CustomerLhRateUsd, 1200.0},
+		{"PO numbers from the stored copy", merged.Specifications.PONums, "PO-9"},
+		{"route miles from Turvo", merged.Specifications.RouteMiles, 950.0},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	// An unset Turvo appointment keeps the stored one
+	turvo.Pickup.ApptTime = time.Time{}
+	merged, err = MergeStoredLoad(turvo, stored)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !merged.Pickup.ApptTime.Equal(stored.Pickup.ApptTime) {
+		t.Errorf("expected the stored appointment, got %v", merged.Pickup.ApptTime)
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"turvo-app/types"
+)
+
+// loadStreamBuffer is how many recent events are kept for resuming clients
+const loadStreamBuffer = 500
+
+// loadStreamQueue is how many events a slow client may fall behind before it
+// is disconnected to resume from the buffer
+const loadStreamQueue = 64
+
+// LoadStream fans load events out to Server-Sent Events clients and keeps the
+// most recent events so a reconnecting client can resume from its last event
+type LoadStream struct {
+	mu          sync.Mutex
+	lastID      int64
+	recent      []types.StreamedLoadEvent
+	subscribers map[*LoadStreamSubscription]bool
+}
+
+// LoadStreamSubscription receives the events matching its filter. Events is
+// closed when the client falls too far behind or unsubscribes.
+type LoadStreamSubscription struct {
+	Events chan types.StreamedLoadEvent
+	filter types.LoadStreamFilter
+}
+
+// NewLoadStream creates an empty load stream
+func NewLoadStream() *LoadStream {
+	return &LoadStream{
+		subscribers: make(map[*LoadStreamSubscription]bool),
+	}
+}
+
+// Subscribe registers a client. When resuming after lastEventID it returns
+// the buffered events the client missed, or reset=true when some are no
+// longer buffered. latestID is the sequence of the newest event.
+func (s *LoadStream) Subscribe(filter types.LoadStreamFilter, lastEventID int64, resume bool) (subscription *LoadStreamSubscription, missed []types.StreamedLoadEvent, reset bool, latestID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription = &LoadStreamSubscription{
+		Events: make(chan types.StreamedLoadEvent, loadStreamQueue),
+		filter: filter,
+	}
+	s.subscribers[subscription] = true
+
+	if resume {
+		oldest := s.lastID + 1
+		if len(s.recent) > 0 {
+			oldest = s.recent[0].Sequence
+		}
+		// A sequence ahead of ours means the server restarted since the client connected
+		reset = lastEventID+1 < oldest || lastEventID > s.lastID
+		for _, event := range s.recent {
+			if !reset && event.Sequence > lastEventID && streamMatches(filter, event.Event) {
+				missed = append(missed, event)
+			}
+		}
+	}
+	return subscription, missed, reset, s.lastID
+}
+
+// Unsubscribe removes a client and closes its channel
+func (s *LoadStream) Unsubscribe(subscription *LoadStreamSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop(subscription)
+}
+
+// Publish numbers an event, buffers it and sends it to every matching client
+func (s *LoadStream) Publish(event types.WebhookEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	streamed := types.StreamedLoadEvent{Sequence: s.lastID, Event: event}
+	s.recent = append(s.recent, streamed)
+	if len(s.recent) > loadStreamBuffer {
+		s.recent = s.recent[len(s.recent)-loadStreamBuffer:]
+	}
+
+	for subscription := range s.subscribers {
+		if !streamMatches(subscription.filter, event) {
+			continue
+		}
+		select {
+		case subscription.Events <- streamed:
+		default:
+			s.drop(subscription)
+		}
+	}
+}
+
+// drop removes a subscription once. Callers hold the lock.
+func (s *LoadStream) drop(subscription *LoadStreamSubscription) {
+	if s.subscribers[subscription] {
+		delete(s.subscribers, subscription)
+		close(subscription.Events)
+	}
+}
+
+// streamMatches reports whether an event belongs to the filter's tenant and
+// passes its customer and status filters. Status filters match
+// the previous status too, so clients see loads leave the filtered statuses.
+func streamMatches(filter types.LoadStreamFilter, event types.WebhookEvent) bool {
+	if event.TenantID != filter.TenantID {
+		return false
+	}
+	if filter.Customer != "" {
+		if event.Load == nil || !strings.EqualFold(strings.TrimSpace(event.Load.Customer.Name), strings.TrimSpace(filter.Customer)) {
+			return false
+		}
+	}
+	if len(filter.Statuses) == 0 {
+		return true
+	}
+	for _, status := range filter.Statuses {
+		if strings.EqualFold(status, event.Status) || (event.PreviousStatus != "" && strings.EqualFold(status, event.PreviousStatus)) {
+			return true
+		}
+	}
+	return false
+}
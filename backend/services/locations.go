@@ -0,0 +1,193 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"turvo-app/types"
+)
+
+// streetAbbreviations are the USPS abbreviations applied when comparing streets
+var streetAbbreviations = map[string]string{
+	"street": "st", "avenue": "ave", "boulevard": "blvd", "road": "rd", "drive": "dr",
+	"lane": "ln", "court": "ct", "place": "pl", "highway": "hwy", "parkway": "pkwy",
+	"circle": "cir", "terrace": "ter", "suite": "ste", "building": "bldg", "floor": "fl",
+	"north": "n", "south": "s", "east": "e", "west": "w",
+	"northeast": "ne", "northwest": "nw", "southeast": "se", "southwest": "sw",
+}
+
+// stopLocationRef is a stop's Turvo location to resolve: its externalTMSId
+// and the location built from its address
+type stopLocationRef struct {
+	ExternalID string
+	Location   types.TurvoLocation
+}
+
+// resolveStopLocations returns the Turvo location ID of each stop: its
+// externalTMSId when that is numeric, otherwise the location matched by
+// address. Locations are only created once every stop has been looked up,
+// and are deleted again when a later one cannot be created. The IDs of the
+// locations created are returned so the caller can remove them if the
+// shipment is not created.
+func (s *TurvoService) resolveStopLocations(stops []stopLocationRef) ([]int, []int, error) {
+	ids := make([]int, len(stops))
+	missing := []int{}
+	for i, stop := range stops {
+		if id, err := strconv.Atoi(strings.TrimSpace(stop.ExternalID)); err == nil {
+			ids[i] = id
+			continue
+		}
+		found, ok, err := s.FindLocation(stop.Location)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve location for %q: %w", stop.Location.Name, err)
+		}
+		if ok {
+			ids[i] = found.ID
+			continue
+		}
+		missing = append(missing, i)
+	}
+
+	created := []int{}
+	for _, i := range missing {
+		location, err := s.CreateLocation(stops[i].Location)
+		if err != nil {
+			s.DeleteLocations(created)
+			return nil, nil, err
+		}
+		ids[i] = location.ID
+		created = append(created, location.ID)
+	}
+	return ids, created, nil
+}
+
+// stopLocation builds the Turvo location for a stop's name, address and contact
+func stopLocation(name, line1, line2, city, state, zip, country, contact, phone, email string, geo *types.GeoPoint) types.TurvoLocation {
+	location := types.TurvoLocation{
+		Name:         knownValue(name),
+		AddressLine1: line1,
+		AddressLine2: knownValue(line2),
+		City:         city,
+		State:        state,
+		ZipCode:      zip,
+		Country:      country,
+		ContactName:  knownValue(contact),
+		Phone:        knownValue(phone),
+		Email:        knownValue(email),
+	}
+	if geo != nil {
+		location.Lat, location.Lng = geo.Lat, geo.Lng
+	}
+	return location
+}
+
+// FindLocation looks for a location in Turvo at the same normalized address,
+// preferring one with the same name
+func (s *TurvoService) FindLocation(location types.TurvoLocation) (types.TurvoLocation, bool, error) {
+	location = normalizeLocation(location)
+	key := locationKey(location)
+
+	query := url.Values{}
+	query.Set("zipCode[eq]", postalKey(location.ZipCode, location.Country))
+	body, err := s.turvoRequest("GET", fmt.Sprintf("%s/v1/locations/list?%s", s.config.TurvoBaseURL, query.Encode()), nil)
+	if err != nil {
+		return types.TurvoLocation{}, false, fmt.Errorf("failed to search locations: %w", err)
+	}
+	var found types.TurvoLocationsResponse
+	if err := json.Unmarshal(body, &found); err != nil {
+		return types.TurvoLocation{}, false, fmt.Errorf("failed to decode locations: %w", err)
+	}
+
+	var match *types.TurvoLocation
+	for i, candidate := range found.Details.Locations {
+		if candidate.ID == 0 || locationKey(normalizeLocation(candidate)) != key {
+			continue
+		}
+		if match == nil || strings.EqualFold(strings.TrimSpace(candidate.Name), location.Name) {
+			match = &found.Details.Locations[i]
+		}
+	}
+	if match == nil {
+		return types.TurvoLocation{}, false, nil
+	}
+	fmt.Printf("DEBUG: Matched %q to Turvo location %d\n", location.Name, match.ID)
+	return *match, true, nil
+}
+
+// CreateLocation creates a location in Turvo from its normalized address
+func (s *TurvoService) CreateLocation(location types.TurvoLocation) (types.TurvoLocation, error) {
+	location = normalizeLocation(location)
+	body, err := s.turvoRequest("POST", fmt.Sprintf("%s/v1/locations", s.config.TurvoBaseURL), location)
+	if err != nil {
+		return types.TurvoLocation{}, fmt.Errorf("failed to create location %q: %w", location.Name, err)
+	}
+	var created types.TurvoLocationResponse
+	if err := json.Unmarshal(body, &created); err != nil {
+		return types.TurvoLocation{}, fmt.Errorf("failed to decode created location: %w", err)
+	}
+	if created.Details.ID == 0 {
+		return types.TurvoLocation{}, fmt.Errorf("Turvo returned no ID for location %q", location.Name)
+	}
+	fmt.Printf("DEBUG: Created Turvo location %d for %q\n", created.Details.ID, location.Name)
+	location.ID = created.Details.ID
+	return location, nil
+}
+
+// DeleteLocations removes locations created for a shipment that was not
+// created. Failures are logged, since the caller is already handling an error.
+func (s *TurvoService) DeleteLocations(ids []int) {
+	for _, id := range ids {
+		if _, err := s.turvoRequest("DELETE", fmt.Sprintf("%s/v1/locations/%d", s.config.TurvoBaseURL, id), nil); err != nil {
+			fmt.Printf("DEBUG: Failed to delete orphaned Turvo location %d: %v\n", id, err)
+			continue
+		}
+		fmt.Printf("DEBUG: Deleted orphaned Turvo location %d\n", id)
+	}
+}
+
+// normalizeLocation returns a location with its name trimmed and its address
+// normalized, taking its position from the zip5 dataset when it has none
+func normalizeLocation(location types.TurvoLocation) types.TurvoLocation {
+	location.Name = strings.Join(strings.Fields(location.Name), " ")
+	var geo *types.GeoPoint
+	if location.Lat != 0 || location.Lng != 0 {
+		geo = &types.GeoPoint{Lat: location.Lat, Lng: location.Lng}
+	}
+	normalizeAddress(addressFields{&location.AddressLine1, &location.AddressLine2, &location.City,
+		&location.State, &location.ZipCode, &location.Country, &geo})
+	if geo != nil {
+		location.Lat, location.Lng = geo.Lat, geo.Lng
+	}
+	return location
+}
+
+// locationKey identifies a normalized address for matching: the street with
+// standard abbreviations, city, state and the 5-digit or unspaced postal code
+func locationKey(location types.TurvoLocation) string {
+	street := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(".,#", r) {
+			return -1
+		}
+		return r
+	}, strings.ToLower(location.AddressLine1))
+	words := strings.Fields(street)
+	for i, word := range words {
+		if abbreviation, ok := streetAbbreviations[word]; ok {
+			words[i] = abbreviation
+		}
+	}
+	return strings.Join([]string{strings.Join(words, " "), strings.ToLower(location.City), location.State,
+		postalKey(location.ZipCode, location.Country)}, "|")
+}
+
+// postalKey is a postal code without spaces, and without the +4 of a US zip
+func postalKey(zip, country string) string {
+	zip = strings.ReplaceAll(strings.ToUpper(zip), " ", "")
+	if country == CountryUS && len(zip) > 5 {
+		zip = zip[:5]
+	}
+	return zip
+}
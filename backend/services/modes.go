@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"turvo-app/types"
+)
+
+// Transportation modes accepted on loads
+const (
+	ModeTL  = "tl"
+	ModeLTL = "ltl"
+)
+
+// Service types accepted on loads
+const (
+	ServiceAny        = "any"
+	ServiceStandard   = "standard"
+	ServiceGuaranteed = "guaranteed"
+	ServiceExpedited  = "expedited"
+	ServiceVolume     = "volume"
+)
+
+// modeLabels are the printed names of each transportation mode
+var modeLabels = map[string]string{
+	ModeLTL: "LTL",
+	ModeTL:  "TL",
+}
+
+// serviceTypeLabels are the printed names of each service type
+var serviceTypeLabels = map[string]string{
+	ServiceStandard:   "Standard",
+	ServiceGuaranteed: "Guaranteed",
+	ServiceExpedited:  "Expedited",
+	ServiceVolume:     "Volume",
+	ServiceAny:        "Any",
+}
+
+// modeServiceTypes lists the service types allowed for each mode, default first
+var modeServiceTypes = map[string][]string{
+	ModeTL:  {ServiceAny, ServiceExpedited},
+	ModeLTL: {ServiceStandard, ServiceGuaranteed, ServiceExpedited, ServiceVolume},
+}
+
+// modeAliases are the accepted spellings of each transportation mode
+var modeAliases = map[string]string{
+	"":                    ModeTL,
+	"tl":                  ModeTL,
+	"ftl":                 ModeTL,
+	"truckload":           ModeTL,
+	"full truckload":      ModeTL,
+	"ltl":                 ModeLTL,
+	"less than truckload": ModeLTL,
+}
+
+// normalizeMode maps a transportation mode or its alias to its canonical name.
+// An empty mode is truckload.
+func normalizeMode(mode string) (string, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(mode))
+	normalized = strings.NewReplacer("_", " ", "-", " ").Replace(normalized)
+	canonical, ok := modeAliases[normalized]
+	return canonical, ok
+}
+
+// normalizeServiceType checks a service type against the mode's allowed
+// types. An empty service type is the mode's default.
+func normalizeServiceType(mode, serviceType string) (string, bool) {
+	allowed := modeServiceTypes[mode]
+	normalized := strings.ToLower(strings.TrimSpace(serviceType))
+	if normalized == "" && len(allowed) > 0 {
+		return allowed[0], true
+	}
+	for _, name := range allowed {
+		if name == normalized {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// ResolveMode returns a load's canonical transportation mode and service type
+func ResolveMode(load types.Load) (string, string, error) {
+	mode, ok := normalizeMode(load.Mode)
+	if !ok {
+		return "", "", fmt.Errorf("unsupported mode %q (expected %s or %s)", load.Mode, ModeTL, ModeLTL)
+	}
+	serviceType, ok := normalizeServiceType(mode, load.ServiceType)
+	if !ok {
+		return "", "", fmt.Errorf("unsupported %s service type %q (expected %s)",
+			mode, load.ServiceType, strings.Join(modeServiceTypes[mode], ", "))
+	}
+	return mode, serviceType, nil
+}
+
+// turvoTransportation converts a mode and service type to Turvo transportation codes
+func turvoTransportation(mode, serviceType string, codes *TurvoCodeTable) types.TurvoTransportation {
+	transportation := types.TurvoTransportation{}
+	transportation.Mode, _ = codes.Mode(mode)
+	transportation.ServiceType, _ = codes.ServiceType(serviceType)
+	return transportation
+}
+
+// ModeFromTurvo reads a shipment's transportation mode and service type from
+// its mode info, falling back to the first stop and then the LTL flag
+func ModeFromTurvo(shipment types.TurvoShipment, codes *TurvoCodeTable) (string, string) {
+	transportation := types.TurvoTransportation{}
+	if len(shipment.ModeInfo) > 0 {
+		transportation.Mode = shipment.ModeInfo[0].Mode
+		transportation.ServiceType = shipment.ModeInfo[0].ServiceType
+	} else if len(shipment.GlobalRoute) > 0 {
+		transportation = shipment.GlobalRoute[0].Transportation
+	}
+
+	mode := ModeTL
+	if shipment.LTLShipment {
+		mode = ModeLTL
+	}
+	if name, ok := codeName(codes.codes.Modes, transportation.Mode.Key); ok {
+		mode = name
+	}
+	serviceType := modeServiceTypes[mode][0]
+	if name, ok := codeName(codes.codes.ServiceTypes, transportation.ServiceType.Key); ok {
+		serviceType = name
+	}
+	return mode, serviceType
+}
+
+// validateMode checks the mode and service type and, for LTL loads, that every
+// commodity carries the freight class, handling units and dimensions carriers rate on
+func validateMode(load types.Load, add func(field, format string, args ...interface{})) {
+	mode, ok := normalizeMode(load.Mode)
+	if !ok {
+		add("mode", "unsupported mode %q (expected %s or %s)", load.Mode, ModeTL, ModeLTL)
+		return
+	}
+	if _, ok := normalizeServiceType(mode, load.ServiceType); !ok {
+		add("serviceType", "unsupported %s service type %q (expected %s)",
+			mode, load.ServiceType, strings.Join(modeServiceTypes[mode], ", "))
+	}
+	if mode != ModeLTL {
+		return
+	}
+
+	if len(load.Commodities) == 0 {
+		add("commodities", "LTL loads require at least one commodity")
+	}
+	for i, commodity := range load.Commodities {
+		field := func(name string) string {
+			return fmt.Sprintf("commodities[%d].%s", i, name)
+		}
+		if strings.TrimSpace(commodity.FreightClass) == "" {
+			add(field("freightClass"), "LTL commodities require a freight class")
+		}
+		if commodity.HandlingUnitCount <= 0 {
+			add(field("handlingUnitCount"), "LTL commodities require a handling unit count")
+		}
+		if commodity.LengthIn <= 0 || commodity.WidthIn <= 0 || commodity.HeightIn <= 0 {
+			add(field("lengthIn"), "LTL commodities require length, width and height")
+		}
+	}
+}
+
+// modeLabel describes a load's mode for printing, such as "LTL - Guaranteed"
+func modeLabel(load types.Load) string {
+	mode, serviceType, err := ResolveMode(load)
+	if err != nil {
+		return ""
+	}
+	label := modeLabels[mode]
+	if serviceType != ServiceAny {
+		label += " - " + serviceTypeLabels[serviceType]
+	}
+	return label
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page geometry for US Letter in PDF points
+const (
+	pdfPageWidth  = 612.0
+	pdfPageHeight = 792.0
+)
+
+// pdfDocument is a minimal PDF writer supporting text in the standard
+// Helvetica fonts, lines and rectangles. Coordinates passed to page methods
+// are measured from the top-left corner of the page.
+type pdfDocument struct {
+	pages []*pdfPage
+}
+
+// pdfPage holds the content stream for one page
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// newPDFDocument creates an empty PDF document
+func newPDFDocument() *pdfDocument {
+	return &pdfDocument{}
+}
+
+// addPage appends a new blank page to the document
+func (d *pdfDocument) addPage() *pdfPage {
+	page := &pdfPage{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// text draws a single line of text with its baseline at y
+func (p *pdfPage) text(x, y, size float64, bold bool, value string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pdfPageHeight-y, pdfEscape(value))
+}
+
+// textRight draws text right-aligned to x
+func (p *pdfPage) textRight(x, y, size float64, bold bool, value string) {
+	p.text(x-pdfTextWidth(value, size, bold), y, size, bold, value)
+}
+
+// textCentered draws text centered on x
+func (p *pdfPage) textCentered(x, y, size float64, bold bool, value string) {
+	p.text(x-pdfTextWidth(value, size, bold)/2, y, size, bold, value)
+}
+
+// paragraph draws text wrapped to the given width and returns the y below the last line
+func (p *pdfPage) paragraph(x, y, width, size float64, bold bool, value string) float64 {
+	lineHeight := size * 1.25
+	for _, line := range pdfWrap(value, width, size, bold) {
+		p.text(x, y, size, bold, line)
+		y += lineHeight
+	}
+	return y
+}
+
+// line draws a straight line
+func (p *pdfPage) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// rect draws a rectangle whose top-left corner is at x, y
+func (p *pdfPage) rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(&p.content, "0.75 w %.2f %.2f %.2f %.2f re %s\n", x, pdfPageHeight-y-h, w, h, op)
+}
+
+// shade fills a rectangle with a light gray background
+func (p *pdfPage) shade(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "q 0.9 g %.2f %.2f %.2f %.2f re f Q\n", x, pdfPageHeight-y-h, w, h)
+}
+
+// bytes serializes the document
+func (d *pdfDocument) bytes() []byte {
+	if len(d.pages) == 0 {
+		d.addPage()
+	}
+
+	var out bytes.Buffer
+	offsets := []int{}
+	writeObject := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then
+	// takes two objects: the page dictionary and its content stream
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+i*2))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.content.Len(), page.content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// pdfEscape escapes a string for use in a PDF literal string, replacing
+// characters outside WinAnsi with '?'
+func pdfEscape(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			builder.WriteRune('\\')
+			builder.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			builder.WriteByte(' ')
+		case r < 32:
+			continue
+		case r < 256:
+			builder.WriteByte(byte(r))
+		default:
+			builder.WriteByte('?')
+		}
+	}
+	return builder.String()
+}
+
+// pdfTextWidth approximates the rendered width of Helvetica text
+func pdfTextWidth(value string, size float64, bold bool) float64 {
+	width := 0.0
+	for _, r := range value {
+		switch {
+		case r == ' ' || r == '.' || r == ',' || r == ':' || r == ';' || r == 'i' || r == 'l' || r == 'I' || r == '|' || r == '\'':
+			width += 0.28
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			width += 0.85
+		case r >= 'A' && r <= 'Z':
+			width += 0.68
+		case r >= '0' && r <= '9':
+			width += 0.56
+		default:
+			width += 0.52
+		}
+	}
+	if bold {
+		width *= 1.06
+	}
+	return width * size
+}
+
+// pdfWrap splits text into lines that fit within width
+func pdfWrap(value string, width, size float64, bold bool) []string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(value, "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if current != "" && pdfTextWidth(candidate, size, bold) > width {
+				lines = append(lines, current)
+				candidate = word
+			}
+			current = candidate
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// pdfFit truncates text with an ellipsis so that it fits within width
+func pdfFit(value string, width, size float64, bold bool) string {
+	if pdfTextWidth(value, size, bold) <= width {
+		return value
+	}
+	runes := []rune(value)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"...", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
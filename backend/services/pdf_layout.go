@@ -0,0 +1,107 @@
+package services
+
+import "strings"
+
+// Document page layout in points
+const (
+	docMargin     = 36.0
+	docWidth      = pdfPageWidth - 2*docMargin
+	docGap        = 8.0
+	docHeaderSize = 13.0
+	docLabelSize  = 7.5
+	docBodySize   = 8.5
+	docLineHeight = 11.0
+	docPageBottom = pdfPageHeight - 48
+)
+
+// docColumn describes a column in a document table
+type docColumn struct {
+	Label string
+	Width float64
+	Right bool
+}
+
+// drawTableHeader draws a titled table header row and returns the y below it
+func drawTableHeader(page *pdfPage, y float64, title string, columns []docColumn) float64 {
+	page.shade(docMargin, y, docWidth, 14)
+	page.text(docMargin+4, y+10, docLabelSize, true, title)
+	y += 14
+	x := docMargin
+	for _, column := range columns {
+		drawCell(page, x, y+10, column, column.Label, true)
+		x += column.Width
+	}
+	y += 14
+	page.line(docMargin, y, docMargin+docWidth, y, 0.75)
+	return y
+}
+
+// drawCell draws a table cell value within a column
+func drawCell(page *pdfPage, x, y float64, column docColumn, value string, bold bool) {
+	if value == "" {
+		return
+	}
+	value = pdfFit(value, column.Width-6, docBodySize, bold)
+	if column.Right {
+		page.textRight(x+column.Width-3, y, docBodySize, bold, value)
+		return
+	}
+	page.text(x+3, y, docBodySize, bold, value)
+}
+
+// drawBox draws a titled box containing one value per line
+func drawBox(page *pdfPage, x, y, w, h float64, title string, lines []string) {
+	page.shade(x, y, w, 14)
+	page.rect(x, y, w, h, false)
+	page.text(x+4, y+10, docLabelSize, true, title)
+	lineY := y + 14 + docLineHeight
+	for _, line := range nonEmpty(lines...) {
+		page.text(x+4, lineY, docBodySize, false, pdfFit(line, w-8, docBodySize, false))
+		lineY += docLineHeight
+	}
+}
+
+// drawWrappedBox draws a full-width titled box with wrapped text and returns the y below it
+func drawWrappedBox(page *pdfPage, y float64, title, text string) float64 {
+	lines := pdfWrap(text, docWidth-8, docBodySize, false)
+	height := 14 + float64(len(lines))*docBodySize*1.25 + 8
+	page.shade(docMargin, y, docWidth, 14)
+	page.rect(docMargin, y, docWidth, height, false)
+	page.text(docMargin+4, y+10, docLabelSize, true, title)
+	page.paragraph(docMargin+4, y+14+docLineHeight, docWidth-8, docBodySize, false, text)
+	return y + height + docGap
+}
+
+// drawSignatures draws signature lines side by side
+func drawSignatures(page *pdfPage, y float64, labels []string) {
+	if len(labels) == 0 {
+		return
+	}
+	width := (docWidth - docGap*float64(len(labels)-1)) / float64(len(labels))
+	for i, label := range labels {
+		x := docMargin + float64(i)*(width+docGap)
+		page.line(x, y+24, x+width, y+24, 0.5)
+		page.text(x, y+34, docLabelSize, false, label)
+	}
+}
+
+// boxHeight returns the box height needed to print the non-empty lines
+func boxHeight(lines []string) float64 {
+	return 14 + float64(len(nonEmpty(lines...)))*docLineHeight + 8
+}
+
+// labeled prefixes a value with its label, or returns an empty string when the value is empty
+func labeled(label, value string) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	return label + ": " + value
+}
+
+// maxFloat returns the larger of two floats
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"turvo-app/types"
+)
+
+// Normalized rate types for customer and carrier rates
+const (
+	RateTypeFlat    = "Flat"
+	RateTypePerMile = "Per Mile"
+	RateTypePerHour = "Per Hour"
+)
+
+// ErrCarrierOverMaxRate is returned when the carrier total exceeds the load's carrier max rate
+var ErrCarrierOverMaxRate = errors.New("carrier rate exceeds max rate")
+
+// PriceLoad computes customer and carrier totals for a load's rate data.
+// Fuel surcharge is charged to the customer only, using FSCPercent of the
+// linehaul when set and FSCPerMile otherwise. Net profit is the customer
+// total less the carrier total; profit percent is margin on the customer total.
+// When only the max rate check fails the result is returned alongside
+// ErrCarrierOverMaxRate.
+func PriceLoad(rates types.RateData, routeMiles float64) (*types.PricingResult, error) {
+	result, err := PriceCustomer(rates, routeMiles)
+	if err != nil {
+		return nil, err
+	}
+	carrier, err := PriceCarrier(rates, routeMiles)
+	if carrier == nil {
+		return nil, err
+	}
+	result.CarrierRateType = carrier.CarrierRateType
+	result.CarrierQuantity = carrier.CarrierQuantity
+	result.CarrierTotalUsd = carrier.CarrierTotalUsd
+	updateProfit(result)
+	return result, err
+}
+
+// PriceCustomer computes the customer side of a load's pricing: linehaul,
+// fuel surcharge and total. Carrier rates are not checked, so documents billed
+// to the customer do not depend on them.
+func PriceCustomer(rates types.RateData, routeMiles float64) (*types.PricingResult, error) {
+	customerType, err := normalizeRateType(rates.CustomerRateType)
+	if err != nil {
+		return nil, fmt.Errorf("customer rate: %w", err)
+	}
+	if rates.CustomerLhRateUsd < 0 {
+		return nil, fmt.Errorf("customer rate cannot be negative")
+	}
+	if rates.FSCPercent < 0 || rates.FSCPerMile < 0 {
+		return nil, fmt.Errorf("fuel surcharge cannot be negative")
+	}
+	customerQty, err := rateQuantity(customerType, rates.CustomerNumHours, routeMiles)
+	if err != nil {
+		return nil, fmt.Errorf("customer rate: %w", err)
+	}
+
+	result := &types.PricingResult{
+		RouteMiles:          routeMiles,
+		CustomerRateType:    customerType,
+		CustomerQuantity:    customerQty,
+		CustomerLinehaulUsd: roundUsd(rates.CustomerLhRateUsd * customerQty),
+	}
+	if rates.FSCPercent > 0 {
+		result.FuelSurchargeUsd = roundUsd(result.CustomerLinehaulUsd * rates.FSCPercent / 100)
+	} else if rates.FSCPerMile > 0 {
+		if routeMiles <= 0 {
+			return nil, fmt.Errorf("fuel surcharge per mile requires route miles (set specifications.routeMiles when they cannot be estimated)")
+		}
+		result.FuelSurchargeUsd = roundUsd(rates.FSCPerMile * routeMiles)
+	}
+	result.CustomerTotalUsd = roundUsd(result.CustomerLinehaulUsd + result.FuelSurchargeUsd)
+	return result, nil
+}
+
+// PriceCarrier computes the carrier side of a load's pricing. Customer rates
+// and fuel surcharge are not checked, so carrier documents do not depend on
+// them. When the carrier total exceeds the max rate the result is returned
+// alongside ErrCarrierOverMaxRate.
+func PriceCarrier(rates types.RateData, routeMiles float64) (*types.PricingResult, error) {
+	carrierType, err := normalizeRateType(rates.CarrierRateType)
+	if err != nil {
+		return nil, fmt.Errorf("carrier rate: %w", err)
+	}
+	if rates.CarrierLhRateUsd < 0 {
+		return nil, fmt.Errorf("carrier rate cannot be negative")
+	}
+	carrierQty, err := rateQuantity(carrierType, rates.CarrierNumHours, routeMiles)
+	if err != nil {
+		return nil, fmt.Errorf("carrier rate: %w", err)
+	}
+
+	result := &types.PricingResult{
+		RouteMiles:      routeMiles,
+		CarrierRateType: carrierType,
+		CarrierQuantity: carrierQty,
+		CarrierTotalUsd: roundUsd(rates.CarrierLhRateUsd * carrierQty),
+	}
+	if rates.CarrierMaxRate > 0 && result.CarrierTotalUsd > rates.CarrierMaxRate {
+		return result, fmt.Errorf("%w: carrier total %.2f exceeds carrier max rate %.2f", ErrCarrierOverMaxRate, result.CarrierTotalUsd, rates.CarrierMaxRate)
+	}
+	return result, nil
+}
+
+// updateProfit recomputes net profit and margin from the customer and carrier totals
+func updateProfit(result *types.PricingResult) {
+	result.NetProfitUsd = roundUsd(result.CustomerTotalUsd - result.CarrierTotalUsd)
+	result.ProfitPercent = 0
+	if result.CustomerTotalUsd > 0 {
+		result.ProfitPercent = math.Round(result.NetProfitUsd/result.CustomerTotalUsd*10000) / 100
+	}
+}
+
+// ApplyPricing writes the computed profit figures back onto the rate data
+func ApplyPricing(rates types.RateData, pricing *types.PricingResult) types.RateData {
+	rates.CustomerRateType = pricing.CustomerRateType
+	rates.CarrierRateType = pricing.CarrierRateType
+	rates.NetProfitUsd = pricing.NetProfitUsd
+	rates.ProfitPercent = pricing.ProfitPercent
+	return rates
+}
+
+// normalizeRateType maps the accepted spellings of a rate type to its canonical name.
+// An empty rate type is treated as flat.
+func normalizeRateType(rateType string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(rateType))
+	normalized = strings.NewReplacer("_", " ", "-", " ").Replace(normalized)
+	switch normalized {
+	case "", "flat":
+		return RateTypeFlat, nil
+	case "per mile", "permile", "mile":
+		return RateTypePerMile, nil
+	case "per hour", "perhour", "hourly", "hour":
+		return RateTypePerHour, nil
+	}
+	return "", fmt.Errorf("unsupported rate type %q", rateType)
+}
+
+// rateQuantity returns the billed quantity for a normalized rate type: miles
+// for per-mile rates, hours for hourly rates and 1 for flat rates
+func rateQuantity(rateType string, hours, miles float64) (float64, error) {
+	switch rateType {
+	case RateTypePerMile:
+		if miles <= 0 {
+			return 0, fmt.Errorf("per mile rate requires route miles (set specifications.routeMiles when they cannot be estimated)")
+		}
+		return miles, nil
+	case RateTypePerHour:
+		if hours <= 0 {
+			return 0, fmt.Errorf("hourly rate requires a number of hours")
+		}
+		return hours, nil
+	}
+	return 1, nil
+}
+
+// validateRates checks rate types and amounts and that the rate fields agree:
+// hourly rates need hours and a carrier rate comes with a customer rate
+func validateRates(rates types.RateData, add func(field, format string, args ...interface{})) {
+	customerType, err := normalizeRateType(rates.CustomerRateType)
+	if err != nil {
+		add("rateData.customerRateType", "%v", err)
+	}
+	carrierType, err := normalizeRateType(rates.CarrierRateType)
+	if err != nil {
+		add("rateData.carrierRateType", "%v", err)
+	}
+
+	for _, amount := range []struct {
+		name  string
+		value float64
+	}{
+		{"customerLhRateUsd", rates.CustomerLhRateUsd},
+		{"customerNumHours", rates.CustomerNumHours},
+		{"carrierLhRateUsd", rates.CarrierLhRateUsd},
+		{"carrierNumHours", rates.CarrierNumHours},
+		{"carrierMaxRate", rates.CarrierMaxRate},
+		{"fscPercent", rates.FSCPercent},
+		{"fscPerMile", rates.FSCPerMile},
+	} {
+		if amount.value < 0 {
+			add("rateData."+amount.name, "cannot be negative")
+		}
+	}
+
+	if customerType == RateTypePerHour && rates.CustomerNumHours <= 0 {
+		add("rateData.customerNumHours", "is required for an hourly customer rate")
+	}
+	if carrierType == RateTypePerHour && rates.CarrierNumHours <= 0 {
+		add("rateData.carrierNumHours", "is required for an hourly carrier rate")
+	}
+	if rates.FSCPercent > 100 {
+		add("rateData.fscPercent", "must be at most 100")
+	}
+	if rates.CarrierLhRateUsd > 0 && rates.CustomerLhRateUsd <= 0 {
+		add("rateData.customerLhRateUsd", "is required when a carrier rate is set")
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"turvo-app/types"
+)
+
+func TestPriceLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		rates   types.RateData
+		miles   float64
+		want    types.PricingResult
+		wantErr error
+		failed  bool
+	}{
+		{
+			name:  "per mile customer with a percent fuel surcharge",
+			rates: types.RateData{CustomerRateType: "per-mile", CustomerLhRateUsd: 2.5, FSCPercent: 20, CarrierLhRateUsd: 1100},
+			miles: 500,
+			want: types.PricingResult{RouteMiles: 500, CustomerRateType: RateTypePerMile, CustomerQuantity: 500, CustomerLinehaulUsd: 1250,
+				FuelSurchargeUsd: 250, CustomerTotalUsd: 1500, CarrierRateType: RateTypeFlat, CarrierQuantity: 1, CarrierTotalUsd: 1100,
+				NetProfitUsd: 400, ProfitPercent: 26.67},
+		},
+		{
+			name: "hourly rates with a per mile fuel surcharge",
+			rates: types.RateData{CustomerRateType: "hourly", CustomerLhRateUsd: 80, CustomerNumHours: 10, FSCPerMile: 0.5,
+				CarrierRateType: "Per Hour", CarrierLhRateUsd: 60, CarrierNumHours: 10},
+			miles: 100,
+			want: types.PricingResult{RouteMiles: 100, CustomerRateType: RateTypePerHour, CustomerQuantity: 10, CustomerLinehaulUsd: 800,
+				FuelSurchargeUsd: 50, CustomerTotalUsd: 850, CarrierRateType: RateTypePerHour, CarrierQuantity: 10, CarrierTotalUsd: 600,
+				NetProfitUsd: 250, ProfitPercent: 29.41},
+		},
+		{
+			name:  "a percent fuel surcharge wins over per mile",
+			rates: types.RateData{CustomerLhRateUsd: 1000, FSCPercent: 10, FSCPerMile: 1},
+			miles: 300,
+			want: types.PricingResult{RouteMiles: 300, CustomerRateType: RateTypeFlat, CustomerQuantity: 1, CustomerLinehaulUsd: 1000,
+				FuelSurchargeUsd: 100, CustomerTotalUsd: 1100, CarrierRateType: RateTypeFlat, CarrierQuantity: 1,
+				NetProfitUsd: 1100, ProfitPercent: 100},
+		},
+		{
+			name:    "carrier over the max rate is priced and reported",
+			rates:   types.RateData{CustomerLhRateUsd: 1500, CarrierLhRateUsd: 1300, CarrierMaxRate: 1200},
+			want:    types.PricingResult{CustomerRateType: RateTypeFlat, CustomerQuantity: 1, CustomerLinehaulUsd: 1500, CustomerTotalUsd: 1500, CarrierRateType: RateTypeFlat, CarrierQuantity: 1, CarrierTotalUsd: 1300, NetProfitUsd: 200, ProfitPercent: 13.33},
+			wantErr: ErrCarrierOverMaxRate,
+		},
+		{name: "per mile without route miles", rates: types.RateData{CustomerRateType: "per mile", CustomerLhRateUsd: 2}, failed: true},
+		{name: "fuel surcharge per mile without route miles", rates: types.RateData{CustomerLhRateUsd: 1000, FSCPerMile: 0.4}, failed: true},
+		{name: "hourly without hours", rates: types.RateData{CustomerLhRateUsd: 1000, CarrierRateType: "hour", CarrierLhRateUsd: 50}, failed: true},
+		{name: "unknown rate type", rates: types.RateData{CustomerRateType: "per pallet"}, failed: true},
+		{name: "negative fuel surcharge", rates: types.RateData{CustomerLhRateUsd: 1000, FSCPercent: -5}, failed: true},
+	}
+	for _, test := range tests {
+		result, err := PriceLoad(test.rates, test.miles)
+		if test.failed {
+			if err == nil || result != nil {
+				t.Errorf("%s: expected an error and no result, got %+v, %v", test.name, result, err)
+			}
+			continue
+		}
+		if !errors.Is(err, test.wantErr) || (test.wantErr == nil && err != nil) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.wantErr)
+		}
+		if result == nil || !reflect.DeepEqual(*result, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, result, test.want)
+		}
+	}
+}
+
+func TestAddAccessorialsUpdatesMargin(t *testing.T) {
+	pricing, err := PriceLoad(types.RateData{CustomerLhRateUsd: 1250, FSCPercent: 20, CarrierLhRateUsd: 1100}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	AddAccessorials(pricing, []types.AccessorialCharge{{Flag: "liftgateDelivery", AmountUsd: 75}, {Flag: "tarps", AmountUsd: 50.5}})
+
+	if pricing.AccessorialsUsd != 125.5 || pricing.CustomerTotalUsd != 1625.5 || pricing.NetProfitUsd != 525.5 || pricing.ProfitPercent != 32.33 {
+		t.Errorf("unexpected pricing with accessorials %+v", pricing)
+	}
+
+	rates := ApplyPricing(types.RateData{CustomerLhRateUsd: 1250, NetProfitUsd: 9999, ProfitPercent: 99}, pricing)
+	if rates.NetProfitUsd != 525.5 || rates.ProfitPercent != 32.33 || rates.CustomerRateType != RateTypeFlat || rates.CarrierRateType != RateTypeFlat {
+		t.Errorf("expected the client's profit figures to be replaced, got %+v", rates)
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"turvo-app/types"
+)
+
+// rateConColumns are the carrier pay table columns, summing to docWidth
+var rateConColumns = []docColumn{
+	{Label: "Charge", Width: 240},
+	{Label: "Rate Type", Width: 100},
+	{Label: "Quantity", Width: 60, Right: true},
+	{Label: "Rate (USD)", Width: 70, Right: true},
+	{Label: "Amount (USD)", Width: 70, Right: true},
+}
+
+// RenderRateConfirmation renders the carrier rate confirmation PDF for a load
+// using the terms of the given broker profile. It refuses to render when the
+// carrier pay exceeds the load's max carrier rate.
+func (s *DocumentService) RenderRateConfirmation(load types.Load, brokerID string) ([]byte, error) {
+	broker, err := s.brokerProfileFor(brokerID)
+	if err != nil {
+		return nil, err
+	}
+	if knownValue(load.Carrier.Name) == "" {
+		return nil, fmt.Errorf("load has no carrier assigned")
+	}
+
+	rates := load.RateData
+	pricing, err := PriceCarrier(rates, load.Specifications.RouteMiles)
+	if err != nil {
+		return nil, err
+	}
+	quantity := pricing.CarrierQuantity
+	carrierPay := pricing.CarrierTotalUsd
+	if carrierPay <= 0 {
+		return nil, fmt.Errorf("carrier rate must be greater than zero")
+	}
+
+	doc := newPDFDocument()
+	page := doc.addPage()
+	loadNumber := firstKnown(load.ExternalTMSLoadID, load.FreightLoadID)
+
+	// Header
+	y := 44.0
+	if broker.Name != "" {
+		page.text(docMargin, y, 12, true, broker.Name)
+		y += 13
+	}
+	for _, line := range nonEmpty(broker.Address, strings.Join(nonEmpty(broker.Phone, broker.Email), "  |  "), labeled("MC", broker.MCNumber)) {
+		page.text(docMargin, y, docBodySize, false, line)
+		y += docLineHeight
+	}
+	right := docMargin + docWidth
+	page.textRight(right, 44, docHeaderSize, true, "CARRIER RATE CONFIRMATION")
+	page.textRight(right, 58, 10, true, "Load #: "+loadNumber)
+	page.textRight(right, 70, docBodySize, false, "Date: "+time.Now().Format("Jan 2, 2006"))
+	y = maxFloat(y, 80) + docGap
+
+	// Carrier
+	half := (docWidth - docGap) / 2
+	carrier := nonEmpty(
+		knownValue(load.Carrier.Name),
+		strings.Join(nonEmpty(labeled("MC", knownValue(load.Carrier.MCNumber)), labeled("DOT", knownValue(load.Carrier.DOTNumber)), labeled("SCAC", knownValue(load.Carrier.SCAC))), "   "),
+		contactLine("", load.Carrier.Phone, load.Carrier.Email),
+		labeled("Dispatcher", knownValue(load.Carrier.Dispatcher)),
+	)
+	equipment := nonEmpty(
+		labeled("Driver 1", strings.Join(nonEmpty(knownValue(load.Carrier.FirstDriverName), knownValue(load.Carrier.FirstDriverPhone)), "  ")),
+		labeled("Driver 2", strings.Join(nonEmpty(knownValue(load.Carrier.SecondDriverName), knownValue(load.Carrier.SecondDriverPhone)), "  ")),
+		labeled("Truck", knownValue(load.Carrier.ExternalTMSTruckID)),
+		labeled("Trailer", knownValue(load.Carrier.ExternalTMSTrailerID)),
+	)
+	if len(equipment) == 0 {
+		equipment = []string{"To be provided by carrier"}
+	}
+	height := maxFloat(boxHeight(carrier), boxHeight(equipment))
+	drawBox(page, docMargin, y, half, height, "CARRIER", carrier)
+	drawBox(page, docMargin+half+docGap, y, half, height, "DRIVERS & EQUIPMENT", equipment)
+	y += height + docGap
+
+	// Stops
+	pickup := append(addressLines(load.Pickup.Name, load.Pickup.AddressLine1, load.Pickup.AddressLine2,
+		load.Pickup.City, load.Pickup.State, load.Pickup.Zipcode, load.Pickup.Country),
+		contactLine(load.Pickup.Contact, load.Pickup.Phone, ""),
+		labeled(schedulingLabel(load.Pickup.SchedulingType), stopWindow(load.Pickup.ApptWindowStart, load.Pickup.ApptWindowEnd,
+			load.Carrier.PickupStart, load.Carrier.PickupEnd, load.Pickup.ApptTime)),
+		labeled("Pickup #", knownValue(load.Pickup.RefNumber)),
+		labeled("Hours", knownValue(load.Pickup.BusinessHours)),
+		labeled("Notes", knownValue(load.Pickup.ApptNote)),
+	)
+	delivery := append(addressLines(load.Consignee.Name, load.Consignee.AddressLine1, load.Consignee.AddressLine2,
+		load.Consignee.City, load.Consignee.State, load.Consignee.Zipcode, load.Consignee.Country),
+		contactLine(load.Consignee.Contact, load.Consignee.Phone, ""),
+		labeled(schedulingLabel(load.Consignee.SchedulingType), stopWindow(load.Consignee.ApptWindowStart, load.Consignee.ApptWindowEnd,
+			load.Carrier.DeliveryStart, load.Carrier.DeliveryEnd, load.Consignee.ApptTime)),
+		labeled("Delivery #", knownValue(load.Consignee.RefNumber)),
+		labeled("Hours", knownValue(load.Consignee.BusinessHours)),
+		labeled("Notes", knownValue(load.Consignee.ApptNote)),
+	)
+	height = maxFloat(boxHeight(pickup), boxHeight(delivery))
+	drawBox(page, docMargin, y, half, height, "STOP 1 - PICKUP", pickup)
+	drawBox(page, docMargin+half+docGap, y, half, height, "STOP 2 - DELIVERY", delivery)
+	y += height + docGap
+
+	// Load details and requirements
+	specs := load.Specifications
+	details := nonEmpty(
+		labeled("Mode", modeLabel(load)),
+		labeled("Equipment", equipmentLabel(load)),
+		labeled("Weight", formatQuantity(specs.TotalWeight, "lb")),
+		labeled("Linear ft", formatQuantity(specs.LinearFeet, "")),
+		labeled("Pallets", formatQuantity(float64(specs.InPalletCount), "")),
+		labeled("Miles", formatQuantity(specs.RouteMiles, "")),
+		labeled("PO #", knownValue(specs.PONums)),
+	)
+	if minTemp, maxTemp, ok := temperatureRange(specs); ok {
+		details = append(details, fmt.Sprintf("Temperature: %.0fF to %.0fF", minTemp, maxTemp))
+	}
+	y = drawWrappedBox(page, y, "LOAD DETAILS", strings.Join(details, "  |  "))
+
+	requirements := "None"
+	if services := specialServices(specs); len(services) > 0 {
+		requirements = strings.Join(services, ", ")
+	}
+	y = drawWrappedBox(page, y, "ACCESSORIAL REQUIREMENTS", requirements)
+
+	// Carrier pay
+	y = drawTableHeader(page, y, "CARRIER PAY", rateConColumns)
+	values := []string{"Linehaul", pricing.CarrierRateType, formatQuantity(quantity, ""), fmt.Sprintf("%.2f", rates.CarrierLhRateUsd), fmt.Sprintf("%.2f", carrierPay)}
+	x := docMargin
+	for i, column := range rateConColumns {
+		drawCell(page, x, y+docLineHeight, column, values[i], false)
+		x += column.Width
+	}
+	y += docLineHeight + 5
+	page.line(docMargin, y, docMargin+docWidth, y, 0.75)
+	page.textRight(docMargin+docWidth-3, y+docLineHeight+1, 10, true, fmt.Sprintf("TOTAL CARRIER PAY: $%.2f USD", carrierPay))
+	y += docLineHeight + 12
+
+	// Terms and signatures
+	termsHeight := float64(len(pdfWrap(broker.Terms, docWidth, 7, false)))*7*1.25 + 70
+	if y+termsHeight > docPageBottom {
+		page = doc.addPage()
+		y = docMargin
+	}
+	page.text(docMargin, y+4, docLabelSize, true, "TERMS AND CONDITIONS")
+	y = page.paragraph(docMargin, y+16, docWidth, 7, false, broker.Terms)
+	drawSignatures(page, y+8, []string{"Accepted by Carrier (Signature / Date)", "Printed Name", "Broker Representative"})
+
+	return doc.bytes(), nil
+}
+
+// appointmentWindow formats a from/to window, falling back to a single appointment time
+func appointmentWindow(from, to, appt time.Time) string {
+	switch {
+	case !from.IsZero() && !to.IsZero():
+		if from.Format("2006-01-02") == to.Format("2006-01-02") {
+			return documentTime(from) + " - " + to.Format("15:04")
+		}
+		return documentTime(from) + " - " + documentTime(to)
+	case !from.IsZero():
+		return documentTime(from)
+	}
+	return documentTime(appt)
+}
+
+// stopWindow prefers the stop's own appointment window over the carrier's planned times
+func stopWindow(windowStart, windowEnd, carrierStart, carrierEnd, appt time.Time) string {
+	if !windowStart.IsZero() {
+		return appointmentWindow(windowStart, windowEnd, appt)
+	}
+	return appointmentWindow(carrierStart, carrierEnd, appt)
+}
+
+// schedulingLabel labels a stop window by how it is scheduled
+func schedulingLabel(schedulingType string) string {
+	switch scheduling, _ := normalizeSchedulingType(schedulingType); scheduling {
+	case SchedulingFCFS:
+		return "FCFS"
+	case SchedulingOpen:
+		return "Open window"
+	}
+	return "Window"
+}
+
+// formatQuantity formats a positive quantity with an optional unit, or returns an empty string
+func formatQuantity(value float64, unit string) string {
+	if value <= 0 {
+		return ""
+	}
+	formatted := fmt.Sprintf("%.0f", value)
+	if value != float64(int64(value)) {
+		formatted = fmt.Sprintf("%.2f", value)
+	}
+	return strings.TrimSpace(formatted + " " + unit)
+}
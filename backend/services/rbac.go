@@ -0,0 +1,250 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// defaultRoles grants each built-in role its permissions. Viewers read,
+// dispatchers create, update and dispatch, and finance also sees and edits rates.
+var defaultRoles = map[string][]string{
+	types.RoleViewer:     {types.PermLoadsRead},
+	types.RoleDispatcher: {types.PermLoadsRead, types.PermLoadsWrite, types.PermLoadsDispatch, types.PermEDISend},
+	types.RoleFinance:    {types.PermLoadsRead, types.PermLoadsWrite, types.PermRatesRead, types.PermRatesWrite, types.PermEDISend},
+	types.RoleAdmin:      {types.PermAll},
+}
+
+// writeRestrictedFields are request body fields, by dotted JSON path from the
+// body root, that need one of the listed permissions to set. A rule on an
+// object covers every field inside it without a rule of its own. Customer
+// rates and margin need rates:write, while the carrier rate a dispatcher
+// agrees when booking a truck can also be set with loads:dispatch.
+var writeRestrictedFields = map[string][]string{
+	"rateData":                  {types.PermRatesWrite},
+	"rateData.carrierRateType":  {types.PermRatesWrite, types.PermLoadsDispatch},
+	"rateData.carrierLhRateUsd": {types.PermRatesWrite, types.PermLoadsDispatch},
+	"rateData.carrierNumHours":  {types.PermRatesWrite, types.PermLoadsDispatch},
+	"carrierRateType":           {types.PermRatesWrite, types.PermLoadsDispatch},
+	"carrierLhRateUsd":          {types.PermRatesWrite, types.PermLoadsDispatch},
+	"carrierNumHours":           {types.PermRatesWrite, types.PermLoadsDispatch},
+}
+
+// maskedRateFields are response fields that reveal carrier cost or margin,
+// removed for callers without rates:read
+var maskedRateFields = map[string]bool{
+	"carrierRateType":  true,
+	"carrierNumHours":  true,
+	"carrierLhRateUsd": true,
+	"carrierMaxRate":   true,
+	"carrierQuantity":  true,
+	"carrierTotalUsd":  true,
+	"netProfitUsd":     true,
+	"profitPercent":    true,
+}
+
+// AccessControl maps caller roles to permissions
+type AccessControl struct {
+	roles       map[string]map[string]bool
+	defaultRole string
+}
+
+// NewAccessControl creates the role table, replacing the built-in roles with
+// the configured roles file when one is set
+func NewAccessControl(cfg *config.Config) *AccessControl {
+	roles := defaultRoles
+	if cfg.RolesFile != "" {
+		var custom map[string][]string
+		if err := readJSONFile(cfg.RolesFile, &custom); err != nil {
+			fmt.Printf("DEBUG: Failed to load roles from %s, using built-in roles: %v\n", cfg.RolesFile, err)
+		} else {
+			roles = custom
+		}
+	}
+
+	access := &AccessControl{
+		roles:       make(map[string]map[string]bool),
+		defaultRole: strings.ToLower(strings.TrimSpace(cfg.DefaultRole)),
+	}
+	for role, permissions := range roles {
+		granted := make(map[string]bool)
+		for _, permission := range permissions {
+			granted[permission] = true
+		}
+		access.roles[strings.ToLower(role)] = granted
+	}
+	fmt.Printf("DEBUG: Loaded %d roles (default %q)\n", len(access.roles), access.defaultRole)
+	return access
+}
+
+// Can reports whether any of the caller's roles grants a permission. Callers
+// without roles get the default role.
+func (a *AccessControl) Can(identity types.Identity, permission string) bool {
+	for _, role := range a.identityRoles(identity) {
+		granted := a.roles[role]
+		if granted[permission] || granted[types.PermAll] {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions lists the caller's permissions
+func (a *AccessControl) Permissions(identity types.Identity) []string {
+	permissions := []string{}
+	seen := make(map[string]bool)
+	for _, role := range a.identityRoles(identity) {
+		for permission := range a.roles[role] {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// identityRoles returns the caller's roles, or the default role when it has none
+func (a *AccessControl) identityRoles(identity types.Identity) []string {
+	if len(identity.Roles) == 0 {
+		return []string{a.defaultRole}
+	}
+	roles := make([]string, len(identity.Roles))
+	for i, role := range identity.Roles {
+		roles[i] = strings.ToLower(strings.TrimSpace(role))
+	}
+	return roles
+}
+
+// RestrictedFields returns the paths of fields a JSON request body sets,
+// with a non-empty value, that the caller lacks permission to write
+func (a *AccessControl) RestrictedFields(identity types.Identity, body []byte) []string {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil
+	}
+	denied := []string{}
+	seen := make(map[string]bool)
+	walkJSON(value, "", func(path string, field interface{}) {
+		permissions, restricted := writeRestriction(path)
+		if !restricted || jsonIsZero(field) || seen[path] || a.canAny(identity, permissions) {
+			return
+		}
+		seen[path] = true
+		denied = append(denied, path)
+	})
+	sort.Strings(denied)
+	return denied
+}
+
+// writeRestriction returns the permissions needed to set the field at path,
+// from its own rule or the rule of the nearest object holding it
+func writeRestriction(path string) ([]string, bool) {
+	for {
+		if permissions, ok := writeRestrictedFields[path]; ok {
+			return permissions, true
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return nil, false
+		}
+		path = path[:i]
+	}
+}
+
+// canAny reports whether the caller has any of the permissions
+func (a *AccessControl) canAny(identity types.Identity, permissions []string) bool {
+	for _, permission := range permissions {
+		if a.Can(identity, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskRates removes carrier cost and margin fields, and the costs of Turvo
+// carrier orders, from a JSON document
+func MaskRates(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	maskRateValue(value, "")
+	return json.Marshal(value)
+}
+
+// maskRateValue deletes masked fields in place. parent is the key holding value.
+func maskRateValue(value interface{}, parent string) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			if maskedRateFields[key] || (parent == "carrierOrder" && key == "costs") {
+				delete(typed, key)
+				continue
+			}
+			maskRateValue(field, key)
+		}
+	case []interface{}:
+		for _, item := range typed {
+			maskRateValue(item, parent)
+		}
+	}
+}
+
+// walkJSON calls visit with the dotted path of every field that is not an
+// object. Array items share the path of the array.
+func walkJSON(value interface{}, path string, visit func(path string, field interface{})) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			walkJSON(field, fieldPath, visit)
+		}
+	case []interface{}:
+		for _, item := range typed {
+			walkJSON(item, path, visit)
+		}
+	default:
+		if path != "" {
+			visit(path, value)
+		}
+	}
+}
+
+// jsonIsZero reports whether a decoded JSON value is null, zero, empty, or
+// an object or array of such values
+func jsonIsZero(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !typed
+	case float64:
+		return typed == 0
+	case string:
+		return typed == ""
+	case map[string]interface{}:
+		for _, field := range typed {
+			if !jsonIsZero(field) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		for _, item := range typed {
+			if !jsonIsZero(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
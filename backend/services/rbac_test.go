@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+func TestRestrictedFields(t *testing.T) {
+	access := NewAccessControl(&config.Config{DefaultRole: types.RoleViewer})
+
+	createWithoutRates := `{"customer":{"name":"Acme"},"specifications":{"totalWeight":30000},"rateData":{"customerLhRateUsd":0,"carrierRateType":""}}`
+	createWithCarrierRate := `{"customer":{"name":"Acme"},"rateData":{"carrierRateType":"flat","carrierLhRateUsd":1500}}`
+	createWithRates := `{"customer":{"name":"Acme"},"rateData":{"customerRateType":"flat","customerLhRateUsd":2000,"fscPercent":12,"carrierRateType":"flat","carrierLhRateUsd":1500}}`
+	dispatch := `{"carrier":{"name":"Fast Freight","dotNumber":"123"},"carrierRateType":"flat","carrierLhRateUsd":1500,"carrierNumHours":0}`
+
+	allRates := "rateData.carrierLhRateUsd rateData.carrierRateType rateData.customerLhRateUsd rateData.customerRateType rateData.fscPercent"
+	customerRates := "rateData.customerLhRateUsd rateData.customerRateType rateData.fscPercent"
+
+	tests := []struct {
+		role string
+		body string
+		want string
+	}{
+		{types.RoleViewer, createWithoutRates, ""},
+		{types.RoleViewer, createWithCarrierRate, "rateData.carrierLhRateUsd rateData.carrierRateType"},
+		{types.RoleViewer, createWithRates, allRates},
+		{types.RoleViewer, dispatch, "carrierLhRateUsd carrierRateType"},
+
+		{types.RoleDispatcher, createWithoutRates, ""},
+		{types.RoleDispatcher, createWithCarrierRate, ""},
+		{types.RoleDispatcher, createWithRates, customerRates},
+		{types.RoleDispatcher, dispatch, ""},
+
+		{types.RoleFinance, createWithoutRates, ""},
+		{types.RoleFinance, createWithCarrierRate, ""},
+		{types.RoleFinance, createWithRates, ""},
+		{types.RoleFinance, dispatch, ""},
+
+		{types.RoleAdmin, createWithRates, ""},
+		{types.RoleAdmin, dispatch, ""},
+	}
+	for _, test := range tests {
+		denied := access.RestrictedFields(types.Identity{Roles: []string{test.role}}, []byte(test.body))
+		if got := strings.Join(denied, " "); got != test.want {
+			t.Errorf("%s %s: got %q, want %q", test.role, test.body, got, test.want)
+		}
+	}
+}
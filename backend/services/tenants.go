@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// ErrUnknownTenant is returned when a caller's tenant is not served here
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// TenantServices are the services bound to one tenant's Turvo account. Each
+// tenant has its own Turvo client and token cache, stored loads, stop
+// tracking and Turvo callback receiver, and its own EDI partners and control
+// numbers, document templates and broker terms, FSC schedules and vetting
+// override users.
+type TenantServices struct {
+	Tenant        types.Tenant
+	Turvo         *TurvoService
+	Loads         *LoadStore
+	Tracker       *ShipmentTracker
+	TurvoWebhooks *TurvoWebhookReceiver
+	EDI           *EDIService
+	Documents     *DocumentService
+	FSC           *FuelSurchargeService
+	Vetting       *CarrierVettingService
+}
+
+// TenantRegistry holds the tenants served by this deployment and resolves
+// callers to them
+type TenantRegistry struct {
+	tenants       map[string]*TenantServices
+	order         []string
+	defaultTenant string
+}
+
+// NewTenantRegistry creates a tenant for every entry in the configured
+// tenants file. Without one, a single default tenant uses the Turvo
+// credentials from the environment.
+func NewTenantRegistry(cfg *config.Config) *TenantRegistry {
+	registry := &TenantRegistry{
+		tenants:       make(map[string]*TenantServices),
+		defaultTenant: strings.TrimSpace(cfg.DefaultTenant),
+	}
+
+	var tenants []types.Tenant
+	if cfg.TenantsFile != "" {
+		if err := readJSONFile(cfg.TenantsFile, &tenants); err != nil {
+			fmt.Printf("DEBUG: Failed to load tenants from %s, using the environment's Turvo account: %v\n", cfg.TenantsFile, err)
+			tenants = nil
+		}
+	}
+	if len(tenants) == 0 {
+		registry.add(types.Tenant{ID: types.DefaultTenantID, Name: "Default"}, cfg)
+		if registry.defaultTenant == "" {
+			registry.defaultTenant = types.DefaultTenantID
+		}
+	}
+	for _, tenant := range tenants {
+		tenant.ID = strings.TrimSpace(tenant.ID)
+		if tenant.ID == "" {
+			fmt.Printf("DEBUG: Skipping tenant %q without an id\n", tenant.Name)
+			continue
+		}
+		if _, exists := registry.tenants[tenant.ID]; exists {
+			fmt.Printf("DEBUG: Skipping duplicate tenant %q\n", tenant.ID)
+			continue
+		}
+		registry.add(tenant, tenantConfig(cfg, tenant))
+	}
+	if registry.defaultTenant == "" && len(registry.order) == 1 {
+		registry.defaultTenant = registry.order[0]
+	}
+
+	fmt.Printf("DEBUG: Serving %d tenants (default %q)\n", len(registry.order), registry.defaultTenant)
+	return registry
+}
+
+// add creates a tenant's services from its configuration
+func (r *TenantRegistry) add(tenant types.Tenant, cfg *config.Config) {
+	r.tenants[tenant.ID] = &TenantServices{
+		Tenant:        tenant,
+		Turvo:         NewTurvoService(cfg),
+		Loads:         NewLoadStore(),
+		Tracker:       NewShipmentTracker(),
+		TurvoWebhooks: NewTurvoWebhookReceiver(cfg),
+		EDI:           NewEDIService(cfg),
+		Documents:     NewDocumentService(cfg),
+		FSC:           NewFuelSurchargeService(cfg),
+		Vetting:       NewCarrierVettingService(cfg),
+	}
+	r.order = append(r.order, tenant.ID)
+}
+
+// Get returns a tenant's services by ID
+func (r *TenantRegistry) Get(id string) (*TenantServices, bool) {
+	tenant, ok := r.tenants[id]
+	return tenant, ok
+}
+
+// All returns every tenant in the order they were configured
+func (r *TenantRegistry) All() []*TenantServices {
+	tenants := make([]*TenantServices, len(r.order))
+	for i, id := range r.order {
+		tenants[i] = r.tenants[id]
+	}
+	return tenants
+}
+
+// Resolve returns the tenant named by a caller's API key or token, or the
+// default tenant when the caller names none
+func (r *TenantRegistry) Resolve(identity types.Identity) (*TenantServices, error) {
+	id := strings.TrimSpace(identity.TenantID)
+	if id == "" {
+		id = r.defaultTenant
+	}
+	if id == "" {
+		return nil, fmt.Errorf("%w: caller has no tenant and no DEFAULT_TENANT is set", ErrUnknownTenant)
+	}
+	tenant, ok := r.tenants[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTenant, id)
+	}
+	return tenant, nil
+}
+
+// tenantConfig copies the deployment configuration with a tenant's Turvo
+// account, defaults and files. Credentials, EDI partners, document templates,
+// broker terms, FSC schedules and override users are never inherited from
+// the deployment; the base URL, OAuth scope and type, and defaults are when
+// left empty. Diesel prices and carrier compliance data are shared. Without
+// its own control-number or outbox file, a tenant numbers and keeps its
+// interchanges in a copy of the deployment's file named after it.
+func tenantConfig(cfg *config.Config, tenant types.Tenant) *config.Config {
+	tenantCfg := *cfg
+	tenantCfg.TurvoOAuthClientID = tenant.TurvoClientID
+	tenantCfg.TurvoOAuthClientSecret = tenant.TurvoClientSecret
+	tenantCfg.TurvoOAuthUsername = tenant.TurvoUsername
+	tenantCfg.TurvoOAuthPassword = tenant.TurvoPassword
+	tenantCfg.TurvoXApiKey = tenant.TurvoXApiKey
+	tenantCfg.TurvoWebhookSecret = tenant.TurvoWebhookSecret
+
+	tenantCfg.TurvoBaseURL = firstKnown(tenant.TurvoBaseURL, cfg.TurvoBaseURL)
+	tenantCfg.TurvoOAuthScope = firstKnown(tenant.TurvoOAuthScope, cfg.TurvoOAuthScope)
+	tenantCfg.TurvoOAuthType = firstKnown(tenant.TurvoOAuthType, cfg.TurvoOAuthType)
+	tenantCfg.DefaultTimezone = firstKnown(tenant.DefaultTimezone, cfg.DefaultTimezone)
+	tenantCfg.AccessorialsFile = firstKnown(tenant.AccessorialsFile, cfg.AccessorialsFile)
+	tenantCfg.TurvoCodesFile = firstKnown(tenant.TurvoCodesFile, cfg.TurvoCodesFile)
+	if tenant.DefaultStatus.Key != "" {
+		tenantCfg.DefaultStatusKey = tenant.DefaultStatus.Key
+		tenantCfg.DefaultStatusValue = tenant.DefaultStatus.Value
+	}
+
+	tenantCfg.EDIPartnersFile = tenant.EDIPartnersFile
+	tenantCfg.EDIControlNumbersFile = firstKnown(tenant.EDIControlNumbersFile, tenantFile(cfg.EDIControlNumbersFile, tenant.ID))
+	tenantCfg.EDIOutboxFile = firstKnown(tenant.EDIOutboxFile, tenantFile(cfg.EDIOutboxFile, tenant.ID))
+	tenantCfg.BOLTemplatesFile = tenant.BOLTemplatesFile
+	tenantCfg.RateConBrokersFile = tenant.RateConBrokersFile
+	tenantCfg.FSCSchedulesFile = tenant.FSCSchedulesFile
+	tenantCfg.VettingOverrideUsers = strings.Join(tenant.VettingOverrideUsers, ",")
+	return &tenantCfg
+}
+
+// tenantFile names a tenant's copy of a deployment file, such as
+// edi_control_numbers.acme.json for edi_control_numbers.json
+func tenantFile(path, tenantID string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + tenantID + ext
+}
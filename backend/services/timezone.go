@@ -0,0 +1,96 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	// Bundle the IANA database so zones resolve in minimal containers
+	_ "time/tzdata"
+)
+
+// timezonesCSV maps US state, Canadian province and Mexican state codes, plus
+// the 3-digit zip prefixes of US states split across zones, to IANA timezone names
+//
+//go:embed data/timezones.csv
+var timezonesCSV string
+
+// defaultStopTimezone is used when a stop's zone cannot be resolved
+const defaultStopTimezone = "America/New_York"
+
+var (
+	timezonesOnce sync.Once
+	timezoneNames map[string]string
+)
+
+// loadTimezones parses the bundled timezone lookup
+func loadTimezones() {
+	timezoneNames = map[string]string{}
+	records, err := csv.NewReader(strings.NewReader(timezonesCSV)).ReadAll()
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to parse timezone lookup: %v\n", err)
+		return
+	}
+	for _, record := range records[1:] {
+		if len(record) >= 2 {
+			timezoneNames[record[0]] = record[1]
+		}
+	}
+}
+
+// StopTimezone resolves a stop's timezone from an explicit IANA name when it
+// is valid, otherwise from its zip prefix (US stops only) and then its state
+// or province
+func StopTimezone(explicit, zip, state, country string) *time.Location {
+	return resolveStopTimezone(explicit, zip, state, country, "")
+}
+
+// resolveStopTimezone resolves a stop's timezone, using fallback, or
+// defaultStopTimezone when fallback is empty, for stops it cannot place
+func resolveStopTimezone(explicit, zip, state, country, fallback string) *time.Location {
+	if name := knownValue(explicit); name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+		fmt.Printf("DEBUG: Ignoring unknown timezone %q\n", name)
+	}
+
+	timezonesOnce.Do(loadTimezones)
+	name := defaultStopTimezone
+	if _, err := time.LoadLocation(fallback); fallback != "" && err == nil {
+		name = fallback
+	}
+
+	// Zip prefixes and zip-derived states only mean something for US postal codes
+	country, ok := normalizeCountry(country)
+	if !ok {
+		country = ""
+	}
+	zip = knownValue(zip)
+	if country == CountryUS && knownValue(state) == "" {
+		state = stateForZip(zip)
+	}
+	if code, ok := normalizeRegion(state, country); ok {
+		state = code
+	}
+	if country == CountryUS && len(zip) >= 3 && timezoneNames[zip[:3]] != "" {
+		name = timezoneNames[zip[:3]]
+	} else if zone := timezoneNames[strings.ToUpper(knownValue(state))]; country != "" && zone != "" {
+		name = zone
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to load timezone %q: %v\n", name, err)
+		return time.UTC
+	}
+	return location
+}
+
+// turvoDateTime formats an instant in the stop's zone with its UTC offset
+func turvoDateTime(t time.Time, location *time.Location) string {
+	return t.In(location).Format(time.RFC3339)
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"turvo-app/types"
+)
+
+// ShipmentTracker remembers the last observed state of each shipment per
+// consumer so that callers only see what changed since their previous look
+type ShipmentTracker struct {
+	mu        sync.Mutex
+	snapshots map[string]shipmentSnapshot
+}
+
+// shipmentSnapshot is the tracked subset of a shipment's state
+type shipmentSnapshot struct {
+	StatusKey   string
+	StatusValue string
+	StopStates  map[string]stopSnapshot
+}
+
+// stopSnapshot is the tracked subset of a stop's state
+type stopSnapshot struct {
+	Arrived  bool
+	Departed bool
+}
+
+// NewShipmentTracker creates a new shipment tracker
+func NewShipmentTracker() *ShipmentTracker {
+	return &ShipmentTracker{
+		snapshots: make(map[string]shipmentSnapshot),
+	}
+}
+
+// Track records the current state of a shipment for the given consumer and
+// returns the changes since that consumer last tracked it. The first
+// observation reports the current status and any stops already reached.
+func (t *ShipmentTracker) Track(consumer string, shipment types.TurvoShipment) []types.ShipmentChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := consumer + "|" + shipment.ShipmentID
+	previous, seen := t.snapshots[key]
+	current := snapshotShipment(shipment)
+	t.snapshots[key] = current
+	return shipmentChanges(shipment, previous, current, seen)
+}
+
+// Take passes the changes since the consumer last tracked a shipment to
+// handle and records the shipment's current state once handle succeeds, so
+// a failure leaves the changes to be reported again. The tracker stays
+// locked throughout, so concurrent calls never hand out the same changes.
+func (t *ShipmentTracker) Take(consumer string, shipment types.TurvoShipment, handle func([]types.ShipmentChange) error) ([]types.ShipmentChange, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := consumer + "|" + shipment.ShipmentID
+	previous, seen := t.snapshots[key]
+	current := snapshotShipment(shipment)
+	changes := shipmentChanges(shipment, previous, current, seen)
+	if err := handle(changes); err != nil {
+		return nil, err
+	}
+	t.snapshots[key] = current
+	return changes, nil
+}
+
+// shipmentChanges lists the stop and status changes between two snapshots of a shipment
+func shipmentChanges(shipment types.TurvoShipment, previous, current shipmentSnapshot, seen bool) []types.ShipmentChange {
+	now := time.Now()
+	changes := []types.ShipmentChange{}
+
+	for i := range shipment.GlobalRoute {
+		stop := shipment.GlobalRoute[i]
+		before := previous.StopStates[stopKey(stop)]
+		after := current.StopStates[stopKey(stop)]
+
+		if after.Arrived && !before.Arrived {
+			changes = append(changes, types.ShipmentChange{
+				ShipmentID: shipment.ShipmentID,
+				Type:       types.ChangeStopArrived,
+				Status:     shipment.Status,
+				Stop:       &stop,
+				OccurredAt: stopEventTime(stop.Arrival, now),
+			})
+		}
+		if after.Departed && !before.Departed {
+			changes = append(changes, types.ShipmentChange{
+				ShipmentID: shipment.ShipmentID,
+				Type:       types.ChangeStopDeparted,
+				Status:     shipment.Status,
+				Stop:       &stop,
+				OccurredAt: stopEventTime(stop.Departed, now),
+			})
+		}
+	}
+
+	if !seen || previous.StatusKey != current.StatusKey || previous.StatusValue != current.StatusValue {
+		changes = append(changes, types.ShipmentChange{
+			ShipmentID: shipment.ShipmentID,
+			Type:       types.ChangeStatus,
+			Status:     shipment.Status,
+			PreviousStatus: types.TurvoStatus{
+				Code: types.TurvoCode{Key: previous.StatusKey, Value: previous.StatusValue},
+			},
+			OccurredAt: now,
+		})
+	}
+
+	return changes
+}
+
+// snapshotShipment extracts the tracked state from a shipment
+func snapshotShipment(shipment types.TurvoShipment) shipmentSnapshot {
+	snapshot := shipmentSnapshot{
+		StatusKey:   shipment.Status.Code.Key,
+		StatusValue: shipment.Status.Code.Value,
+		StopStates:  make(map[string]stopSnapshot),
+	}
+	for _, stop := range shipment.GlobalRoute {
+		snapshot.StopStates[stopKey(stop)] = stopSnapshot{
+			Arrived:  stopArrived(stop),
+			Departed: stopDeparted(stop),
+		}
+	}
+	return snapshot
+}
+
+// stopKey identifies a stop within a shipment's route
+func stopKey(stop types.TurvoGlobalRoute) string {
+	if stop.GlobalShipLocationSourceId != "" {
+		return stop.GlobalShipLocationSourceId
+	}
+	return stop.StopType.Key + "-" + strings.TrimSpace(stop.Name)
+}
+
+// stopArrived reports whether the carrier has arrived at the stop
+func stopArrived(stop types.TurvoGlobalRoute) bool {
+	if stop.Arrival != nil && stop.Arrival.Date != "" {
+		return true
+	}
+	switch strings.ToUpper(stop.State) {
+	case "ARRIVED", "AT_STOP", "AT_LOCATION", "DEPARTED", "COMPLETED":
+		return true
+	}
+	return false
+}
+
+// stopDeparted reports whether the carrier has left the stop
+func stopDeparted(stop types.TurvoGlobalRoute) bool {
+	if stop.Departed != nil && stop.Departed.Date != "" {
+		return true
+	}
+	switch strings.ToUpper(stop.State) {
+	case "DEPARTED", "COMPLETED":
+		return true
+	}
+	return false
+}
+
+// stopEventTime parses a Turvo stop timestamp, falling back to the given time
+func stopEventTime(date *types.TurvoDate, fallback time.Time) time.Time {
+	if date == nil || date.Date == "" {
+		return fallback
+	}
+	if parsed, err := time.Parse(time.RFC3339, date.Date); err == nil {
+		return parsed
+	}
+	return fallback
+}
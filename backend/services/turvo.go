@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"turvo-app/config"
@@ -15,10 +17,19 @@ import (
 
 // TurvoService handles Turvo API interactions
 type TurvoService struct {
-	config      *config.Config
-	client      *http.Client
+	config *config.Config
+	client *http.Client
+	token  *turvoToken
+
+	accessorials *AccessorialTable
+	codes        *TurvoCodeTable
+}
+
+// turvoToken is the cached OAuth token, shared by a service and its traced copies
+type turvoToken struct {
+	mu          sync.Mutex
 	accessToken string
-	tokenExpiry time.Time
+	expiry      time.Time
 }
 
 // NewTurvoService creates a new Turvo service instance
@@ -28,13 +39,49 @@ func NewTurvoService(cfg *config.Config) *TurvoService {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		token:        &turvoToken{},
+		accessorials: NewAccessorialTable(cfg),
+		codes:        NewTurvoCodeTable(cfg),
 	}
 }
 
+// Traced returns a copy of the service that records a summary of each Turvo
+// call it makes to trace. The copy shares the token cache.
+func (s *TurvoService) Traced(trace *TurvoTrace) *TurvoService {
+	base := s.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	traced := *s
+	traced.client = &http.Client{
+		Timeout:   s.client.Timeout,
+		Transport: &tracingTransport{base: base, trace: trace},
+	}
+	return &traced
+}
+
+// Accessorials returns the accessorial code table used when mapping loads
+func (s *TurvoService) Accessorials() *AccessorialTable {
+	return s.accessorials
+}
+
+// Codes returns the Turvo code table used when mapping loads
+func (s *TurvoService) Codes() *TurvoCodeTable {
+	return s.codes
+}
+
+// StopTimezone resolves a stop's timezone like the package-level StopTimezone
+// but falls back to the configured default timezone
+func (s *TurvoService) StopTimezone(explicit, zip, state, country string) *time.Location {
+	return resolveStopTimezone(explicit, zip, state, country, s.config.DefaultTimezone)
+}
+
 // getAccessToken fetches and caches a valid OAuth token from Turvo
 func (s *TurvoService) getAccessToken() (string, error) {
-	if s.accessToken != "" && time.Now().Before(s.tokenExpiry) {
-		return s.accessToken, nil
+	s.token.mu.Lock()
+	defer s.token.mu.Unlock()
+	if s.token.accessToken != "" && time.Now().Before(s.token.expiry) {
+		return s.token.accessToken, nil
 	}
 
 	tokenURL := s.config.TurvoBaseURL + "/v1/oauth/token"
@@ -87,20 +134,51 @@ func (s *TurvoService) getAccessToken() (string, error) {
 		return "", err
 	}
 
-	s.accessToken = result.AccessToken
-	s.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn-60) * time.Second) // 1 min buffer
+	s.token.accessToken = result.AccessToken
+	s.token.expiry = time.Now().Add(time.Duration(result.ExpiresIn-60) * time.Second) // 1 min buffer
 	fmt.Printf("DEBUG: Obtained new Turvo OAuth token, expires in %d seconds\n", result.ExpiresIn)
-	return s.accessToken, nil
+	return s.token.accessToken, nil
 }
 
-// CreateShipment creates a new shipment in Turvo
-func (s *TurvoService) CreateShipment(drumkitLoad types.Load) (*types.TurvoShipmentResponse, error) {
+// CreateShipment creates a new shipment in Turvo from a validated load and its pricing
+func (s *TurvoService) CreateShipment(drumkitLoad types.Load, pricing *types.PricingResult) (*types.TurvoShipmentResponse, error) {
 	// Transform Drumkit load to Turvo format
-	turvoRequest, err := s.transformDrumkitToTurvo(drumkitLoad)
+	turvoRequest, err := s.transformDrumkitToTurvo(drumkitLoad, pricing)
 	if err != nil {
 		return nil, fmt.Errorf("failed to transform load data: %w", err)
 	}
 
+	// Get OAuth token
+	token, err := s.getAccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Turvo OAuth token: %w", err)
+	}
+
+	// Match or create the stops' Turvo locations once the load has been
+	// validated and transformed, just before the shipment is created.
+	// Locations created here are removed again if the shipment is not.
+	locationIDs, createdLocations, err := s.resolveStopLocations([]stopLocationRef{
+		{drumkitLoad.Pickup.ExternalTMSId, stopLocation(drumkitLoad.Pickup.Name,
+			drumkitLoad.Pickup.AddressLine1, drumkitLoad.Pickup.AddressLine2, drumkitLoad.Pickup.City, drumkitLoad.Pickup.State,
+			drumkitLoad.Pickup.Zipcode, drumkitLoad.Pickup.Country, drumkitLoad.Pickup.Contact, drumkitLoad.Pickup.Phone,
+			drumkitLoad.Pickup.Email, drumkitLoad.Pickup.Geo)},
+		{drumkitLoad.Consignee.ExternalTMSId, stopLocation(drumkitLoad.Consignee.Name,
+			drumkitLoad.Consignee.AddressLine1, drumkitLoad.Consignee.AddressLine2, drumkitLoad.Consignee.City, drumkitLoad.Consignee.State,
+			drumkitLoad.Consignee.Zipcode, drumkitLoad.Consignee.Country, drumkitLoad.Consignee.Contact, drumkitLoad.Consignee.Phone,
+			drumkitLoad.Consignee.Email, drumkitLoad.Consignee.Geo)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve stop locations: %w", err)
+	}
+	turvoRequest.GlobalRoute[0].Location.ID = locationIDs[0]
+	turvoRequest.GlobalRoute[1].Location.ID = locationIDs[1]
+	shipmentCreated := false
+	defer func() {
+		if !shipmentCreated {
+			s.DeleteLocations(createdLocations)
+		}
+	}()
+
 	// Convert request to JSON
 	jsonData, err := json.Marshal(turvoRequest)
 	if err != nil {
@@ -109,12 +187,6 @@ func (s *TurvoService) CreateShipment(drumkitLoad types.Load) (*types.TurvoShipm
 
 	fmt.Printf("DEBUG: Turvo request JSON: %s\n", string(jsonData))
 
-	// Get OAuth token
-	token, err := s.getAccessToken()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get Turvo OAuth token: %w", err)
-	}
-
 	// Create HTTP request
 	url := fmt.Sprintf("%s/v1/shipments", s.config.TurvoBaseURL)
 	fmt.Printf("DEBUG: Turvo URL: %s\n", url)
@@ -158,6 +230,7 @@ func (s *TurvoService) CreateShipment(drumkitLoad types.Load) (*types.TurvoShipm
 		return &turvoResponse, fmt.Errorf("Turvo API error: %s - %s", resp.Status, turvoResponse.Error)
 	}
 
+	shipmentCreated = true
 	return &turvoResponse, nil
 }
 
@@ -237,6 +310,47 @@ func (s *TurvoService) GetShipments(page int) ([]types.TurvoShipment, *types.Tur
 
 // GetShipmentDetails fetches detailed information about a specific shipment
 func (s *TurvoService) GetShipmentDetails(shipmentID string) (map[string]interface{}, error) {
+	bodyBytes, err := s.fetchShipmentDetails(shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse response as generic map to handle the complex structure
+	var response map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	fmt.Printf("DEBUG: Retrieved shipment details from Turvo\n")
+	
+	return response, nil
+}
+
+// GetShipment fetches a single shipment from Turvo decoded into typed structs
+func (s *TurvoService) GetShipment(shipmentID string) (*types.TurvoShipment, error) {
+	bodyBytes, err := s.fetchShipmentDetails(shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var response types.TurvoShipmentDetailsResponse
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	shipment := response.Details
+	if shipment.ShipmentID == "" {
+		shipment.ShipmentID = shipmentID
+		if shipment.ID != 0 {
+			shipment.ShipmentID = fmt.Sprintf("%d", shipment.ID)
+		}
+	}
+
+	return &shipment, nil
+}
+
+// fetchShipmentDetails performs the GET /shipments/:id call and returns the raw body
+func (s *TurvoService) fetchShipmentDetails(shipmentID string) ([]byte, error) {
 	// Get OAuth token
 	token, err := s.getAccessToken()
 	if err != nil {
@@ -269,24 +383,13 @@ func (s *TurvoService) GetShipmentDetails(shipmentID string) (map[string]interfa
 	// Read and log the response body for debugging
 	bodyBytes, _ := io.ReadAll(resp.Body)
 	fmt.Printf("DEBUG: Turvo GET shipment details response body: %s\n", string(bodyBytes))
-	
-	// Create a new reader for the JSON decoder since we consumed the body
-	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 
 	// Check for HTTP errors
 	if resp.StatusCode >= 400 {
 		return nil, fmt.Errorf("Turvo API error: %s - %s", resp.Status, string(bodyBytes))
 	}
 
-	// Parse response as generic map to handle the complex structure
-	var response map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	fmt.Printf("DEBUG: Retrieved shipment details from Turvo\n")
-	
-	return response, nil
+	return bodyBytes, nil
 }
 
 // convertShipmentDataToTurvoShipment converts TurvoShipmentData to TurvoShipment
@@ -335,7 +438,7 @@ func convertShipmentDataToTurvoShipment(data types.TurvoShipmentData) types.Turv
 			Date:     data.Updated,
 			TimeZone: "UTC",
 		},
-		LTLShipment: false,
+		LTLShipment: data.LTLShipment,
 	}
 
 	// Add carrier order if available
@@ -354,54 +457,74 @@ func convertShipmentDataToTurvoShipment(data types.TurvoShipmentData) types.Turv
 	return shipment
 }
 
-// transformDrumkitToTurvo transforms a Drumkit load to Turvo shipment format
-func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShipmentRequest, error) {
-	// Calculate start and end dates from pickup and delivery times
-	startDate := load.Pickup.ApptTime
-	endDate := load.Consignee.ApptTime
-
-	// If appointment times are not set, use ready time or current time
-	if startDate.IsZero() {
-		if !load.Pickup.ReadyTime.IsZero() {
-			startDate = load.Pickup.ReadyTime
-		} else {
-			startDate = time.Now().Add(24 * time.Hour) // Default to tomorrow
+// transformDrumkitToTurvo transforms a Drumkit load to Turvo shipment format.
+// The load must already have been normalized, validated and priced.
+func (s *TurvoService) transformDrumkitToTurvo(load types.Load, pricing *types.PricingResult) (*types.TurvoShipmentRequest, error) {
+	mode, serviceType, err := ResolveMode(load)
+	if err != nil {
+		return nil, err
+	}
+	transportation := turvoTransportation(mode, serviceType, s.codes)
+
+	// Use the client's route miles for the stop-to-stop mileage, falling back to
+	// an estimate, and leave the calculation to Turvo when neither is available
+	legs := []float64{0, math.Round(load.Specifications.RouteMiles)}
+	if legs[1] <= 0 {
+		estimated, err := LoadRouteLegs(load)
+		if err != nil {
+			fmt.Printf("DEBUG: Route mileage unavailable, Turvo will calculate distances: %v\n", err)
+			estimated = []float64{0, 0}
 		}
+		legs = estimated
 	}
 
-	if endDate.IsZero() {
-		endDate = startDate.Add(72 * time.Hour) // Default to 3 days after pickup
+	// Resolve appointment windows, defaulting pickup to the ready time or
+	// tomorrow and delivery to 3 days after pickup
+	pickupFallback := load.Pickup.ReadyTime
+	if pickupFallback.IsZero() {
+		pickupFallback = time.Now().Add(24 * time.Hour)
 	}
-
-	// Ensure end date is after start date
-	if endDate.Before(startDate) {
-		endDate = startDate.Add(24 * time.Hour)
+	pickupAppt, err := resolveStopAppointment(load.Pickup.SchedulingType, load.Pickup.ApptWindowStart, load.Pickup.ApptWindowEnd,
+		load.Pickup.ApptTime, pickupFallback, load.Pickup.ApptFlexMinutes, 3600)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pickup appointment: %w", err)
+	}
+	deliveryAppt, err := resolveStopAppointment(load.Consignee.SchedulingType, load.Consignee.ApptWindowStart, load.Consignee.ApptWindowEnd,
+		load.Consignee.ApptTime, pickupAppt.To.Add(72*time.Hour), load.Consignee.ApptFlexMinutes, 14400)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delivery appointment: %w", err)
+	}
+	if deliveryAppt.To.Before(pickupAppt.From) {
+		return nil, fmt.Errorf("delivery appointment is before pickup")
 	}
 
-	// Format dates in RFC3339 format with timezone - use the same format as the sample
-	startDateStr := startDate.Format("2006-01-02T15:04:05Z")
-	endDateStr := endDate.Format("2006-01-02T15:04:05Z")
+	// Format dates in RFC3339 with each stop's local offset
+	pickupZone := s.StopTimezone(load.Pickup.Timezone, load.Pickup.Zipcode, load.Pickup.State, load.Pickup.Country)
+	deliveryZone := s.StopTimezone(load.Consignee.Timezone, load.Consignee.Zipcode, load.Consignee.State, load.Consignee.Country)
+	startDateStr := turvoDateTime(pickupAppt.From, pickupZone)
+	endDateStr := turvoDateTime(deliveryAppt.To, deliveryZone)
 
 	fmt.Printf("DEBUG: Start date: %s, End date: %s\n", startDateStr, endDateStr)
 
 	// Create Turvo request - simplified to match sample structure
 	turvoRequest := &types.TurvoShipmentRequest{
-		LTLShipment: false, // Default to FTL
+		LTLShipment: mode == ModeLTL,
+		Equipment:   turvoEquipment(load, s.codes),
 		StartDate: types.TurvoDate{
 			Date:     startDateStr,
-			TimeZone: "America/New_York", // Default timezone
+			TimeZone: pickupZone.String(),
 		},
 		EndDate: types.TurvoDate{
 			Date:     endDateStr,
-			TimeZone: "America/New_York", // Default timezone
+			TimeZone: deliveryZone.String(),
 		},
 		Status: types.TurvoStatus{
 			Code: types.TurvoCode{
-				Value: "Covered",
-				Key:   "2102",
+				Value: s.config.DefaultStatusValue,
+				Key:   s.config.DefaultStatusKey,
 			},
 			Notes:       "Created via Drumkit integration",
-			Description: "Covered",
+			Description: s.config.DefaultStatusValue,
 		},
 		Lane: types.TurvoLane{
 			Start: fmt.Sprintf("%s, %s", load.Pickup.City, load.Pickup.State),
@@ -409,21 +532,18 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 		},
 	
 		
-		SkipDistanceCalculation: true,
+		SkipDistanceCalculation: legs[1] > 0,
 		GlobalRoute: []types.TurvoGlobalRoute{
 			// Pickup stop
 			{
 				GlobalShipLocationSourceId: "pickup-1",
 				Name:                       fmt.Sprintf("%s: %s", load.Pickup.Contact, load.Pickup.RefNumber),
-				SchedulingType: types.TurvoCode{
-					Key:   "9401",
-					Value: "By appointment",
-				},
+				SchedulingType: pickupAppt.turvoSchedulingType(s.codes),
 				StopType: types.TurvoCode{
 					Key:   "1500",
 					Value: "Pickup",
 				},
-				Timezone:        "America/New_York",
+				Timezone:        pickupZone.String(),
 				SegmentSequence: 0,
 				LayoverTime: types.TurvoLayoverTime{
 					Value: 1,
@@ -434,68 +554,22 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 				},
 				Sequence:                0,
 				State:                   "OPEN",
-				AppointmentConfirmation: true,
-				PlannedAppointmentDate: types.TurvoPlannedAppointment{
-					SchedulingType: types.TurvoCode{
-						Key:   "9401",
-						Value: "By appointment",
-					},
-					Appointment: types.TurvoPlannedAppointmentDetail{
-						From: types.TurvoAppointment{
-							Date:     startDateStr,
-							Timezone: "America/New_York",
-							Flex:     3600,
-							HasTime:  true,
-						},
-						To: types.TurvoAppointment{
-							Date:     endDateStr,
-							Timezone: "America/New_York",
-							Flex:     3600,
-							HasTime:  true,
-						},
-					},
-				},
-				Appointment: types.TurvoAppointment{
-					Date:     startDateStr,
-					Timezone: "America/New_York",
-					Flex:     3600, // 1 hour flex
-					HasTime:  true,
-				},
-				Services: []types.TurvoCode{
-					{
-						Key:   "21307",
-						Value: "After hours",
-					},
-				},
+				AppointmentConfirmation: pickupAppt.Scheduling == SchedulingAppointment,
+				PlannedAppointmentDate:  pickupAppt.turvoPlannedAppointment(pickupZone, s.codes),
+				Appointment:             pickupAppt.turvoAppointment(pickupZone),
+				Services:  s.accessorials.StopServices(load.Specifications, types.AccessorialStopPickup),
 				PONumbers: []string{load.Specifications.PONums},
 				Notes:     load.Pickup.ApptNote,
-				Location: types.TurvoLocation{
-					ID:   func() int {
-						if id, err := strconv.Atoi(load.Pickup.ExternalTMSId); err == nil {
-							return id
-						}
-						return 1 // Default fallback ID
-					}(),
-				},
-				Transportation: types.TurvoTransportation{
-					Mode: types.TurvoCode{
-						Key:   "24105",
-						Value: "TL",
-					},
-					ServiceType: types.TurvoCode{
-						Key:   "24304",
-						Value: "Any",
-					},
-				},
+				Transportation: transportation,
 				FragmentDistance: types.TurvoDistance{
-					Value: 120,
+					Value: int(legs[0]),
 					Units: types.TurvoCode{
 						Key:   "1540",
 						Value: "mi",
 					},
 				},
 				Distance: types.TurvoDistance{
-					Value: 0,
+					Value: int(legs[0]),
 					Units: types.TurvoCode{
 						Key:   "1540",
 						Value: "mi",
@@ -506,15 +580,12 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 			{
 				GlobalShipLocationSourceId: "delivery-1",
 				Name:                       fmt.Sprintf("%s: %s", load.Consignee.Contact, load.Consignee.RefNumber),
-				SchedulingType: types.TurvoCode{
-					Key:   "9401",
-					Value: "By appointment",
-				},
+				SchedulingType: deliveryAppt.turvoSchedulingType(s.codes),
 				StopType: types.TurvoCode{
 					Key:   "1501",
 					Value: "Delivery",
 				},
-				Timezone:        "America/New_York",
+				Timezone:        deliveryZone.String(),
 				SegmentSequence: 0,
 				LayoverTime: types.TurvoLayoverTime{
 					Value: 1,
@@ -525,80 +596,36 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 				},
 				Sequence:                1,
 				State:                   "OPEN",
-				AppointmentConfirmation: true,
-				PlannedAppointmentDate: types.TurvoPlannedAppointment{
-					SchedulingType: types.TurvoCode{
-						Key:   "9401",
-						Value: "By appointment",
-					},
-					Appointment: types.TurvoPlannedAppointmentDetail{
-						From: types.TurvoAppointment{
-							Date:     startDateStr,
-							Timezone: "America/New_York",
-							Flex:     3600,
-							HasTime:  true,
-						},
-						To: types.TurvoAppointment{
-							Date:     endDateStr,
-							Timezone: "America/New_York",
-							Flex:     3600,
-							HasTime:  true,
-						},
-					},
-				},
-				Appointment: types.TurvoAppointment{
-					Date:     endDateStr,
-					Timezone: "America/New_York",
-					Flex:     14400, // 4 hours flex
-					HasTime:  true,
-				},
-				Services: []types.TurvoCode{
-					{
-						Key:   "21407",
-						Value: "Delivery Appointment",
-					},
-				},
+				AppointmentConfirmation: deliveryAppt.Scheduling == SchedulingAppointment,
+				PlannedAppointmentDate:  deliveryAppt.turvoPlannedAppointment(deliveryZone, s.codes),
+				Appointment:             deliveryAppt.turvoAppointment(deliveryZone),
+				Services:  s.accessorials.StopServices(load.Specifications, types.AccessorialStopDelivery),
 				PONumbers: []string{load.Specifications.PONums},
 				Notes:     load.Consignee.ApptNote,
-				Location: types.TurvoLocation{
-					ID:   func() int {
-						if id, err := strconv.Atoi(load.Consignee.ExternalTMSId); err == nil {
-							return id
-						}
-						return 1 // Default fallback ID
-					}(),				},
-				Transportation: types.TurvoTransportation{
-					Mode: types.TurvoCode{
-						Key:   "24105",
-						Value: "TL",
-					},
-					ServiceType: types.TurvoCode{
-						Key:   "24304",
-						Value: "Any",
+				Transportation: transportation,
+				FragmentDistance: types.TurvoDistance{
+					Value: int(legs[1]),
+					Units: types.TurvoCode{
+						Key:   "1540",
+						Value: "mi",
 					},
 				},
-				FragmentDistance: types.TurvoDistance{
-					Value: 120,
+				Distance: types.TurvoDistance{
+					Value: int(legs[0] + legs[1]),
 					Units: types.TurvoCode{
 						Key:   "1540",
 						Value: "mi",
 					},
 				},
-				StopLevelFragmentDistance: 120,
+				StopLevelFragmentDistance: int(legs[1]),
 			},
 		},
 		ModeInfo: []types.TurvoModeInfo{
 			{
 				Operation:              0,
 				SourceSegmentSequence: "0",
-				Mode: types.TurvoCode{
-					Key:   "24105",
-					Value: "TL",
-				},
-				ServiceType: types.TurvoCode{
-					Key:   "24304",
-					Value: "Any",
-				},
+				Mode:                  transportation.Mode,
+				ServiceType:           transportation.ServiceType,
 				TotalSegmentValue: types.TurvoSegmentValue{
 					Sync:  true,
 					Value: 0,
@@ -620,47 +647,8 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 						return 1 // Default fallback ID
 					}(), // Convert string to int with fallback
 				},
-				Items: []types.TurvoItem{
-					{
-						ItemCategory: types.TurvoCode{
-							Key:   "22300",
-							Value: "Other",
-						},
-						Qty:  load.Specifications.InPalletCount,
-						Unit: types.TurvoCode{
-							Key:   "6003",
-							Value: "Pallets",
-						},
-						Name: "Freight",
-						Notes: fmt.Sprintf("PO: %s, Operator: %s", 
-							load.Specifications.PONums, load.Specifications.Operator),
-						Operation: 0,
-						IsHazmat:  load.Specifications.Hazmat,
-						Stackable: true,
-						Value:     int(load.RateData.CustomerLhRateUsd * 100), // Convert to cents
-						TotalValue: int(load.RateData.CustomerLhRateUsd * float64(load.Specifications.InPalletCount) * 100),
-						Currency: types.TurvoCode{
-							Key:   "1550",
-							Value: "USD",
-						},
-					},
-				},
-				Costs: types.TurvoCosts{
-					TotalAmount: int(load.RateData.CustomerLhRateUsd * 100), // Convert to cents
-					LineItem: []types.TurvoLineItem{
-						{
-							Code: types.TurvoCode{
-								Key:   "1600",
-								Value: "Freight - flat",
-							},
-							Qty:      1,
-							Price:    int(load.RateData.CustomerLhRateUsd * 100),
-							Amount:   int(load.RateData.CustomerLhRateUsd * 100),
-							Billable: true,
-							Notes:    "Freight charges",
-						},
-					},
-				},
+				Items: turvoItems(load, s.codes),
+				Costs: customerOrderCosts(pricing, s.codes),
 				ExternalIDs: []types.TurvoExternalID{
 					{
 						Type: types.TurvoCode{
@@ -678,6 +666,7 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 
 	// Add carrier information if available
 	if load.Carrier.Name != "" {
+		carrierCosts := carrierOrderCosts(pricing)
 		turvoRequest.CarrierOrder = []types.TurvoCarrierOrder{
 			{
 				CarrierOrderSourceID: 626, // Use sample ID
@@ -689,13 +678,10 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 						return 1 // Default fallback ID
 					}(), // Convert string to int with fallback
 				},
-				Drivers: []types.TurvoDriver{
-					{
-						DriverID:        1, // Default ID
-						Operation:       0,
-						SegmentSequence: 0,
-					},
-				},
+				// Drivers are resolved in Turvo when the load is dispatched
+				Costs:         &carrierCosts,
+				TractorNumber: knownValue(load.Carrier.ExternalTMSTruckID),
+				TrailerNumber: knownValue(load.Carrier.ExternalTMSTrailerID),
 			},
 		}
 	}
@@ -703,4 +689,63 @@ func (s *TurvoService) transformDrumkitToTurvo(load types.Load) (*types.TurvoShi
 	return turvoRequest, nil
 }
 
- 
\ No newline at end of file
+// turvoFreightFlatCode is the Turvo cost line item code for linehaul. The fuel
+// surcharge code differs between accounts and comes from the code table.
+var turvoFreightFlatCode = types.TurvoCode{Key: "1600", Value: "Freight - flat"}
+
+// customerOrderCosts builds the Turvo customer order costs from computed pricing
+func customerOrderCosts(pricing *types.PricingResult, codes *TurvoCodeTable) types.TurvoCosts {
+	costs := types.TurvoCosts{
+		TotalAmount: usdToCents(pricing.CustomerTotalUsd), // Convert to cents
+		LineItem: []types.TurvoLineItem{
+			{
+				Code:     turvoFreightFlatCode,
+				Qty:      1,
+				Price:    usdToCents(pricing.CustomerLinehaulUsd),
+				Amount:   usdToCents(pricing.CustomerLinehaulUsd),
+				Billable: true,
+				Notes:    "Freight charges",
+			},
+		},
+	}
+
+	if pricing.FuelSurchargeUsd > 0 {
+		costs.LineItem = append(costs.LineItem, types.TurvoLineItem{
+			Code:     codes.codes.FuelSurcharge,
+			Qty:      1,
+			Price:    usdToCents(pricing.FuelSurchargeUsd),
+			Amount:   usdToCents(pricing.FuelSurchargeUsd),
+			Billable: true,
+			Notes:    "Fuel surcharge",
+		})
+	}
+	for _, charge := range pricing.Accessorials {
+		costs.LineItem = append(costs.LineItem, types.TurvoLineItem{
+			Code:     charge.Code,
+			Qty:      1,
+			Price:    usdToCents(charge.AmountUsd),
+			Amount:   usdToCents(charge.AmountUsd),
+			Billable: true,
+			Notes:    "Accessorial",
+		})
+	}
+
+	return costs
+}
+
+// carrierOrderCosts builds the Turvo carrier order costs from the computed carrier total
+func carrierOrderCosts(pricing *types.PricingResult) types.TurvoCosts {
+	return types.TurvoCosts{
+		TotalAmount: usdToCents(pricing.CarrierTotalUsd), // Convert to cents
+		LineItem: []types.TurvoLineItem{
+			{
+				Code:     turvoFreightFlatCode,
+				Qty:      1,
+				Price:    usdToCents(pricing.CarrierTotalUsd),
+				Amount:   usdToCents(pricing.CarrierTotalUsd),
+				Billable: false,
+				Notes:    "Carrier linehaul",
+			},
+		},
+	}
+}
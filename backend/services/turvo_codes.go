@@ -0,0 +1,259 @@
+package services
+
+import (
+	"fmt"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// TurvoCodeTable maps load fields to the Turvo codes of one account
+type TurvoCodeTable struct {
+	codes types.TurvoCodes
+}
+
+// defaultTurvoCodes are the codes the integration has always sent: truckload
+// with any service, appointment scheduling and pallets
+func defaultTurvoCodes() types.TurvoCodes {
+	return types.TurvoCodes{
+		Equipment:      map[string]types.TurvoCode{},
+		TrailerSizes:   map[int]types.TurvoCode{},
+		HandlingUnits:  map[string]types.TurvoCode{HandlingPallet: {Key: "6003", Value: "Pallets"}},
+		FreightClasses: map[string]types.TurvoCode{},
+		Modes:          map[string]types.TurvoCode{ModeTL: {Key: "24105", Value: "TL"}},
+		ServiceTypes:   map[string]types.TurvoCode{ServiceAny: {Key: "24304", Value: "Any"}},
+		Scheduling:     map[string]types.TurvoCode{SchedulingAppointment: {Key: "9401", Value: "By appointment"}},
+	}
+}
+
+// NewTurvoCodeTable creates the code table from the configured file. Codes
+// for equipment, trailer sizes, units, handling units other than pallets,
+// freight classes, LTL, service types other than any, scheduling other than
+// by appointment and the fuel surcharge differ between accounts, so without
+// a file loads that need them are rejected.
+func NewTurvoCodeTable(cfg *config.Config) *TurvoCodeTable {
+	table := &TurvoCodeTable{codes: defaultTurvoCodes()}
+	if cfg.TurvoCodesFile == "" {
+		fmt.Printf("DEBUG: TURVO_CODES_FILE is not set, only the default Turvo codes are available\n")
+		return table
+	}
+
+	var codes types.TurvoCodes
+	if err := readJSONFile(cfg.TurvoCodesFile, &codes); err != nil {
+		fmt.Printf("DEBUG: Failed to load Turvo codes from %s, only the default Turvo codes are available: %v\n", cfg.TurvoCodesFile, err)
+		return table
+	}
+	table.merge(codes)
+	return table
+}
+
+// merge adds the codes read from a file to the table. Entries without a key
+// or for values loads cannot carry are ignored.
+func (t *TurvoCodeTable) merge(codes types.TurvoCodes) {
+	mergeCodes("equipment", t.codes.Equipment, codes.Equipment, func(name string) bool {
+		_, ok := equipmentLabels[name]
+		return ok
+	})
+	for length, code := range codes.TrailerSizes {
+		if code.Key == "" || !supportedTrailerLength(length) {
+			fmt.Printf("DEBUG: Ignoring Turvo trailer size code for %d ft\n", length)
+			continue
+		}
+		t.codes.TrailerSizes[length] = code
+	}
+	mergeCodes("handling unit", t.codes.HandlingUnits, codes.HandlingUnits, func(name string) bool {
+		canonical, ok := normalizeHandlingUnit(name)
+		return ok && canonical == name
+	})
+	mergeCodes("freight class", t.codes.FreightClasses, codes.FreightClasses, func(name string) bool {
+		class, ok := normalizeFreightClass(name)
+		return ok && class == name
+	})
+	mergeCodes("mode", t.codes.Modes, codes.Modes, func(name string) bool {
+		_, ok := modeServiceTypes[name]
+		return ok
+	})
+	mergeCodes("service type", t.codes.ServiceTypes, codes.ServiceTypes, func(name string) bool {
+		_, ok := serviceTypeLabels[name]
+		return ok
+	})
+	mergeCodes("scheduling", t.codes.Scheduling, codes.Scheduling, func(name string) bool {
+		canonical, err := normalizeSchedulingType(name)
+		return err == nil && canonical == name
+	})
+
+	for _, unit := range []struct {
+		dst *types.TurvoCode
+		src types.TurvoCode
+	}{
+		{&t.codes.TemperatureUnit, codes.TemperatureUnit},
+		{&t.codes.DimensionUnit, codes.DimensionUnit},
+		{&t.codes.WeightUnit, codes.WeightUnit},
+		{&t.codes.FuelSurcharge, codes.FuelSurcharge},
+		{&t.codes.RateConfirmationSent, codes.RateConfirmationSent},
+	} {
+		if unit.src.Key != "" {
+			*unit.dst = unit.src
+		}
+	}
+}
+
+// mergeCodes copies the codes for known names from src to dst
+func mergeCodes(kind string, dst, src map[string]types.TurvoCode, known func(string) bool) {
+	for name, code := range src {
+		if code.Key == "" || !known(name) {
+			fmt.Printf("DEBUG: Ignoring Turvo %s code for %q\n", kind, name)
+			continue
+		}
+		dst[name] = code
+	}
+}
+
+// Equipment returns the Turvo code for a canonical equipment type
+func (t *TurvoCodeTable) Equipment(equipmentType string) (types.TurvoCode, bool) {
+	code, ok := t.codes.Equipment[equipmentType]
+	return code, ok
+}
+
+// TrailerSize returns the Turvo code for a trailer length in feet
+func (t *TurvoCodeTable) TrailerSize(lengthFt int) (types.TurvoCode, bool) {
+	code, ok := t.codes.TrailerSizes[lengthFt]
+	return code, ok
+}
+
+// HandlingUnit returns the Turvo item unit code for a canonical handling unit
+func (t *TurvoCodeTable) HandlingUnit(handlingUnit string) (types.TurvoCode, bool) {
+	code, ok := t.codes.HandlingUnits[handlingUnit]
+	return code, ok
+}
+
+// FreightClass returns the Turvo code for a freight class such as "77.5".
+// An empty class returns an empty code.
+func (t *TurvoCodeTable) FreightClass(class string) (types.TurvoCode, bool) {
+	normalized, ok := normalizeFreightClass(class)
+	if !ok || normalized == "" {
+		return types.TurvoCode{}, ok && normalized == ""
+	}
+	code, ok := t.codes.FreightClasses[normalized]
+	return code, ok
+}
+
+// Mode returns the Turvo code for a canonical transportation mode
+func (t *TurvoCodeTable) Mode(mode string) (types.TurvoCode, bool) {
+	code, ok := t.codes.Modes[mode]
+	return code, ok
+}
+
+// ServiceType returns the Turvo code for a canonical service type
+func (t *TurvoCodeTable) ServiceType(serviceType string) (types.TurvoCode, bool) {
+	code, ok := t.codes.ServiceTypes[serviceType]
+	return code, ok
+}
+
+// Scheduling returns the Turvo code for a canonical scheduling type
+func (t *TurvoCodeTable) Scheduling(scheduling string) (types.TurvoCode, bool) {
+	code, ok := t.codes.Scheduling[scheduling]
+	return code, ok
+}
+
+// RateConfirmationSent returns the carrier order external ID type that
+// records when the rate confirmation was sent
+func (t *TurvoCodeTable) RateConfirmationSent() (types.TurvoCode, bool) {
+	code := t.codes.RateConfirmationSent
+	return code, code.Key != ""
+}
+
+// codeName returns the name whose code has the given key
+func codeName(codes map[string]types.TurvoCode, key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for name, code := range codes {
+		if code.Key == key {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Check reports the fields of a priced load that need a Turvo code this
+// account has not configured. Loads are rejected rather than sent to Turvo
+// with a code left out.
+func (t *TurvoCodeTable) Check(load types.Load, pricing *types.PricingResult) types.ValidationErrors {
+	var errs types.ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, types.ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	specs := load.Specifications
+	equipmentType, ok := normalizeEquipmentType(load.Equipment.Type, specs)
+	if ok && (knownValue(load.Equipment.Type) != "" || hasTemperatureRange(specs)) {
+		if _, ok := t.Equipment(equipmentType); !ok {
+			add("equipment.type", "no Turvo code is configured for %s equipment", equipmentType)
+		}
+	}
+	if length := load.Equipment.TrailerLengthFt; length != 0 {
+		if _, ok := t.TrailerSize(length); !ok {
+			add("equipment.trailerLengthFt", "no Turvo code is configured for a %d ft trailer", length)
+		}
+	}
+	if equipmentType == EquipmentReefer && hasTemperatureRange(specs) && t.codes.TemperatureUnit.Key == "" {
+		add("specifications.minTempFahrenheit", "no Turvo temperature unit code is configured")
+	}
+
+	if mode, serviceType, err := ResolveMode(load); err == nil {
+		if _, ok := t.Mode(mode); !ok {
+			add("mode", "no Turvo code is configured for %s", mode)
+		}
+		if _, ok := t.ServiceType(serviceType); !ok {
+			add("serviceType", "no Turvo code is configured for the %s service type", serviceType)
+		}
+	}
+
+	for _, stop := range []struct{ field, schedulingType string }{
+		{"pickup.schedulingType", load.Pickup.SchedulingType},
+		{"consignee.schedulingType", load.Consignee.SchedulingType},
+	} {
+		if scheduling, err := normalizeSchedulingType(stop.schedulingType); err == nil {
+			if _, ok := t.Scheduling(scheduling); !ok {
+				add(stop.field, "no Turvo code is configured for %s scheduling", scheduling)
+			}
+		}
+	}
+
+	for i, commodity := range load.Commodities {
+		field := func(name string) string {
+			return fmt.Sprintf("commodities[%d].%s", i, name)
+		}
+		if handlingUnit, ok := normalizeHandlingUnit(commodity.HandlingUnit); ok {
+			if _, ok := t.HandlingUnit(handlingUnit); !ok {
+				add(field("handlingUnit"), "no Turvo code is configured for %s handling units", handlingUnit)
+			}
+		}
+		if commodity.PieceCount > 0 {
+			if _, ok := t.HandlingUnit(HandlingPiece); !ok {
+				add(field("pieceCount"), "no Turvo code is configured for pieces")
+			}
+		}
+		if class, ok := normalizeFreightClass(commodity.FreightClass); ok && class != "" {
+			if _, ok := t.FreightClass(class); !ok {
+				add(field("freightClass"), "no Turvo code is configured for freight class %s", class)
+			}
+		}
+		if commodity.WeightLbs > 0 && t.codes.WeightUnit.Key == "" {
+			add(field("weightLbs"), "no Turvo weight unit code is configured")
+		}
+		if (commodity.LengthIn > 0 || commodity.WidthIn > 0 || commodity.HeightIn > 0) && t.codes.DimensionUnit.Key == "" {
+			add(field("lengthIn"), "no Turvo dimension unit code is configured")
+		}
+	}
+
+	if pricing != nil && pricing.FuelSurchargeUsd > 0 && t.codes.FuelSurcharge.Key == "" {
+		field := "rateData.fscPercent"
+		if load.RateData.FSCPercent == 0 {
+			field = "rateData.fscPerMile"
+		}
+		add(field, "no Turvo fuel surcharge code is configured")
+	}
+	return errs
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"turvo-app/types"
+)
+
+// fahrenheit returns a temperature for a load's specifications
+func fahrenheit(temp float64) *float64 {
+	return &temp
+}
+
+// testCodes is an account code table with a code for every value the tests send
+func testCodes() *TurvoCodeTable {
+	table := &TurvoCodeTable{codes: defaultTurvoCodes()}
+	table.merge(types.TurvoCodes{
+		Equipment:       map[string]types.TurvoCode{EquipmentDryVan: {Key: "E1", Value: "Van"}, EquipmentReefer: {Key: "E2", Value: "Reefer"}},
+		TrailerSizes:    map[int]types.TurvoCode{53: {Key: "S53", Value: "53 ft"}},
+		TemperatureUnit: types.TurvoCode{Key: "TF", Value: "°F"},
+		DimensionUnit:   types.TurvoCode{Key: "DIN", Value: "in"},
+		WeightUnit:      types.TurvoCode{Key: "WLB", Value: "lb"},
+		HandlingUnits:   map[string]types.TurvoCode{HandlingPiece: {Key: "H2", Value: "Pieces"}, HandlingSkid: {Key: "H4", Value: "Skids"}},
+		FreightClasses:  map[string]types.TurvoCode{"77.5": {Key: "C775", Value: "77.5"}, "100": {Key: "C100", Value: "100"}},
+		Modes:           map[string]types.TurvoCode{ModeLTL: {Key: "M1", Value: "LTL"}},
+		ServiceTypes:    map[string]types.TurvoCode{ServiceStandard: {Key: "V1", Value: "Standard"}},
+		Scheduling:      map[string]types.TurvoCode{SchedulingFCFS: {Key: "Q1", Value: "First come first serve"}},
+		FuelSurcharge:   types.TurvoCode{Key: "F1", Value: "Fuel surcharge"},
+
+		RateConfirmationSent: types.TurvoCode{Key: "X1", Value: "Rate confirmation sent"},
+	})
+	return table
+}
+
+func TestTurvoCodeTableMerge(t *testing.T) {
+	table := &TurvoCodeTable{codes: defaultTurvoCodes()}
+	table.merge(types.TurvoCodes{
+		Equipment:      map[string]types.TurvoCode{EquipmentFlatbed: {Key: "E3"}, "boxcar": {Key: "E9"}, EquipmentReefer: {}},
+		TrailerSizes:   map[int]types.TurvoCode{48: {Key: "S48"}, 57: {Key: "S57"}},
+		HandlingUnits:  map[string]types.TurvoCode{"pallets": {Key: "H9"}, HandlingPallet: {Key: "H3"}},
+		FreightClasses: map[string]types.TurvoCode{"077.5": {Key: "C1"}, "85": {Key: "C85"}},
+		Modes:          map[string]types.TurvoCode{"intermodal": {Key: "M9"}},
+	})
+
+	tests := []struct {
+		name   string
+		lookup func() (types.TurvoCode, bool)
+		want   string
+	}{
+		{"configured equipment", func() (types.TurvoCode, bool) { return table.Equipment(EquipmentFlatbed) }, "E3"},
+		{"equipment without a key is ignored", func() (types.TurvoCode, bool) { return table.Equipment(EquipmentReefer) }, ""},
+		{"supported trailer length", func() (types.TurvoCode, bool) { return table.TrailerSize(48) }, "S48"},
+		{"unsupported trailer length is ignored", func() (types.TurvoCode, bool) { return table.TrailerSize(57) }, ""},
+		{"file overrides the default pallet code", func() (types.TurvoCode, bool) { return table.HandlingUnit(HandlingPallet) }, "H3"},
+		{"freight class by NMFC spelling", func() (types.TurvoCode, bool) { return table.FreightClass("85.0") }, "C85"},
+		{"class keyed by another spelling is ignored", func() (types.TurvoCode, bool) { return table.FreightClass("77.5") }, ""},
+		{"default truckload code", func() (types.TurvoCode, bool) { return table.Mode(ModeTL) }, "24105"},
+		{"unknown mode is ignored", func() (types.TurvoCode, bool) { return table.Mode("intermodal") }, ""},
+		{"default appointment code", func() (types.TurvoCode, bool) { return table.Scheduling(SchedulingAppointment) }, "9401"},
+		{"no built-in FCFS code", func() (types.TurvoCode, bool) { return table.Scheduling(SchedulingFCFS) }, ""},
+	}
+	for _, test := range tests {
+		code, ok := test.lookup()
+		if code.Key != test.want || ok != (test.want != "") {
+			t.Errorf("%s: got %q (%v), want %q", test.name, code.Key, ok, test.want)
+		}
+	}
+}
+
+func TestTurvoCodeCheck(t *testing.T) {
+	reefer := types.Load{
+		Equipment:      types.Equipment{Type: "reefer", TrailerLengthFt: 53},
+		Specifications: types.Specifications{MinTempFahrenheit: fahrenheit(34), MaxTempFahrenheit: fahrenheit(38)},
+	}
+	ltl := types.Load{
+		Mode:        "ltl",
+		ServiceType: "standard",
+		Pickup:      types.Pickup{SchedulingType: "fcfs"},
+		Commodities: []types.Commodity{
+			{PieceCount: 10, HandlingUnit: "skid", HandlingUnitCount: 2, WeightLbs: 800, LengthIn: 48, WidthIn: 40, HeightIn: 40, FreightClass: "77.5"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		codes      *TurvoCodeTable
+		load       types.Load
+		pricing    *types.PricingResult
+		wantFields []string
+	}{
+		{name: "default truckload needs no file", codes: &TurvoCodeTable{codes: defaultTurvoCodes()}, load: types.Load{}},
+		{name: "a default dry van is sent without equipment", codes: &TurvoCodeTable{codes: defaultTurvoCodes()}, load: types.Load{Equipment: types.Equipment{Type: "N/A"}}},
+		{
+			name: "reefer without codes", codes: &TurvoCodeTable{codes: defaultTurvoCodes()}, load: reefer,
+			wantFields: []string{"equipment.type", "equipment.trailerLengthFt", "specifications.minTempFahrenheit"},
+		},
+		{name: "reefer with codes", codes: testCodes(), load: reefer},
+		{
+			name: "LTL without codes", codes: &TurvoCodeTable{codes: defaultTurvoCodes()}, load: ltl,
+			wantFields: []string{"mode", "serviceType", "pickup.schedulingType",
+				"commodities[0].handlingUnit", "commodities[0].pieceCount", "commodities[0].freightClass",
+				"commodities[0].weightLbs", "commodities[0].lengthIn"},
+		},
+		{name: "LTL with codes", codes: testCodes(), load: ltl},
+		{
+			name: "fuel surcharge without a code", codes: &TurvoCodeTable{codes: defaultTurvoCodes()},
+			load:    types.Load{RateData: types.RateData{FSCPerMile: 0.4}},
+			pricing: &types.PricingResult{FuelSurchargeUsd: 160}, wantFields: []string{"rateData.fscPerMile"},
+		},
+		{name: "fuel surcharge with a code", codes: testCodes(), pricing: &types.PricingResult{FuelSurchargeUsd: 160}},
+	}
+	for _, test := range tests {
+		errs := test.codes.Check(test.load, test.pricing)
+		fields := []string{}
+		for _, err := range errs {
+			fields = append(fields, err.Field)
+		}
+		if strings.Join(fields, " ") != strings.Join(test.wantFields, " ") {
+			t.Errorf("%s: got errors on %q, want %q", test.name, fields, test.wantFields)
+		}
+	}
+}
+
+func TestTurvoCodesRoundTrip(t *testing.T) {
+	codes := testCodes()
+	load := types.Load{
+		Mode:           ModeLTL,
+		ServiceType:    ServiceStandard,
+		Equipment:      types.Equipment{Type: EquipmentReefer, TrailerLengthFt: 53},
+		Specifications: types.Specifications{MinTempFahrenheit: fahrenheit(34), MaxTempFahrenheit: fahrenheit(38)},
+	}
+
+	equipment := turvoEquipment(load, codes)
+	if len(equipment) != 1 || equipment[0].Type.Key != "E2" || equipment[0].Size.Key != "S53" || equipment[0].Temp != 36 || equipment[0].TempUnits.Key != "TF" {
+		t.Fatalf("unexpected Turvo equipment %+v", equipment)
+	}
+	if got := turvoEquipment(types.Load{Equipment: types.Equipment{Type: EquipmentFlatbed}}, codes); got != nil {
+		t.Errorf("expected equipment without a code to be left off, got %+v", got)
+	}
+
+	minTemp, maxTemp := itemTemperatures(load, codes)
+	transportation := turvoTransportation(ModeLTL, ServiceStandard, codes)
+	shipment := types.TurvoShipment{
+		Equipment:     equipment,
+		ModeInfo:      []types.TurvoModeInfo{{Mode: transportation.Mode, ServiceType: transportation.ServiceType}},
+		CustomerOrder: []types.TurvoCustomerOrder{{Items: []types.TurvoItem{{MinTemp: minTemp, MaxTemp: maxTemp}}}},
+	}
+
+	var specs types.Specifications
+	readBack := EquipmentFromTurvo(shipment, &specs, codes)
+	if readBack.Type != EquipmentReefer || readBack.TrailerLengthFt != 53 || *specs.MinTempFahrenheit != 34 || *specs.MaxTempFahrenheit != 38 {
+		t.Errorf("unexpected equipment read back: %+v %+v", readBack, specs)
+	}
+	frozen := types.Load{Specifications: types.Specifications{MinTempFahrenheit: fahrenheit(0), MaxTempFahrenheit: fahrenheit(0)}}
+	minTemp, maxTemp = itemTemperatures(frozen, codes)
+	if minTemp.TempUnit.Key != "TF" || maxTemp.TempUnit.Key != "TF" {
+		t.Fatalf("expected a 0F range to be sent, got %+v %+v", minTemp, maxTemp)
+	}
+	specs = types.Specifications{}
+	shipment.CustomerOrder[0].Items[0] = types.TurvoItem{MinTemp: minTemp, MaxTemp: maxTemp}
+	EquipmentFromTurvo(shipment, &specs, codes)
+	if specs.MinTempFahrenheit == nil || *specs.MinTempFahrenheit != 0 || specs.MaxTempFahrenheit == nil || *specs.MaxTempFahrenheit != 0 {
+		t.Errorf("expected a 0F range to be read back, got %+v", specs)
+	}
+
+	if mode, serviceType := ModeFromTurvo(shipment, codes); mode != ModeLTL || serviceType != ServiceStandard {
+		t.Errorf("expected ltl standard, got %s %s", mode, serviceType)
+	}
+	if mode, serviceType := ModeFromTurvo(types.TurvoShipment{}, codes); mode != ModeTL || serviceType != ServiceAny {
+		t.Errorf("expected a shipment without mode info to be truckload, got %s %s", mode, serviceType)
+	}
+}
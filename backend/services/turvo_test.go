@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"turvo-app/types"
+)
+
+func TestTransformRouteMiles(t *testing.T) {
+	chicago := types.Pickup{City: "Chicago", State: "IL", Zipcode: "60601", Country: "US"}
+	dallas := types.Consignee{City: "Dallas", State: "TX", Zipcode: "75201", Country: "US"}
+	nearby := types.Consignee{City: "Chicago", State: "IL", Zipcode: "60602", Country: "US"}
+
+	tests := []struct {
+		name      string
+		consignee types.Consignee
+		miles     float64
+		wantMiles int
+		wantSkip  bool
+	}{
+		{name: "client route miles are sent", consignee: dallas, miles: 967.6, wantMiles: 968, wantSkip: true},
+		{name: "estimated without route miles", consignee: dallas, wantSkip: true},
+		{name: "Turvo calculates when no distance is known", consignee: nearby, wantMiles: 0, wantSkip: false},
+	}
+	for _, test := range tests {
+		service, _ := newFakeTurvo(t, func(method, path string) (int, string) { return 200, `{}` })
+		load := types.Load{Pickup: chicago, Consignee: test.consignee, Specifications: types.Specifications{RouteMiles: test.miles}}
+
+		request, err := service.transformDrumkitToTurvo(load, &types.PricingResult{})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		delivery := request.GlobalRoute[1]
+		if request.SkipDistanceCalculation != test.wantSkip {
+			t.Errorf("%s: got skipDistanceCalculation %v, want %v", test.name, request.SkipDistanceCalculation, test.wantSkip)
+		}
+		if test.wantMiles != 0 && delivery.StopLevelFragmentDistance != test.wantMiles {
+			t.Errorf("%s: got %d miles, want %d", test.name, delivery.StopLevelFragmentDistance, test.wantMiles)
+		}
+		if test.wantSkip && delivery.Distance.Value == 0 {
+			t.Errorf("%s: expected a delivery distance when Turvo is told to skip its calculation", test.name)
+		}
+		if !test.wantSkip && delivery.Distance.Value != 0 {
+			t.Errorf("%s: expected no distance, got %d", test.name, delivery.Distance.Value)
+		}
+	}
+}
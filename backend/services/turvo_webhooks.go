@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// TurvoSignatureHeader carries the hex HMAC-SHA256 of the callback body,
+// optionally prefixed with "sha256="
+const TurvoSignatureHeader = "X-Turvo-Signature"
+
+// turvoEventRetention is how long delivered event IDs are remembered for de-duplication
+const turvoEventRetention = 24 * time.Hour
+
+// Errors returned for callbacks that are rejected before decoding
+var (
+	ErrTurvoWebhookNotConfigured = errors.New("Turvo webhook secret is not configured")
+	ErrTurvoWebhookSignature     = errors.New("invalid Turvo webhook signature")
+)
+
+// TurvoEventListener handles a verified, first-seen Turvo event
+type TurvoEventListener func(event types.TurvoWebhookEvent)
+
+// TurvoWebhookReceiver verifies Turvo callbacks, drops redelivered events and
+// fans each new event out to the registered listeners
+type TurvoWebhookReceiver struct {
+	mu        sync.Mutex
+	secret    string
+	seen      map[string]time.Time
+	listeners []TurvoEventListener
+}
+
+// NewTurvoWebhookReceiver creates a receiver that verifies callbacks with the configured secret
+func NewTurvoWebhookReceiver(cfg *config.Config) *TurvoWebhookReceiver {
+	if cfg.TurvoWebhookSecret == "" {
+		fmt.Printf("DEBUG: TURVO_WEBHOOK_SECRET is not set, Turvo webhook callbacks will be rejected\n")
+	}
+	return &TurvoWebhookReceiver{
+		secret: cfg.TurvoWebhookSecret,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// Listen registers a listener for new Turvo events. Listeners run in the
+// order registered, on the request that delivered the event.
+func (r *TurvoWebhookReceiver) Listen(listener TurvoEventListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// Receive verifies and decodes a callback body. It returns duplicate=true
+// without notifying listeners when the event was already received.
+func (r *TurvoWebhookReceiver) Receive(body []byte, signature string) (types.TurvoWebhookEvent, bool, error) {
+	var event types.TurvoWebhookEvent
+	if r.secret == "" {
+		return event, false, ErrTurvoWebhookNotConfigured
+	}
+	if !r.verify(body, signature) {
+		return event, false, ErrTurvoWebhookSignature
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return event, false, fmt.Errorf("failed to decode Turvo event: %w", err)
+	}
+
+	// Events without an ID are de-duplicated by their exact body
+	key := event.ID
+	if key == "" {
+		sum := sha256.Sum256(body)
+		key = "body:" + hex.EncodeToString(sum[:])
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	for id, receivedAt := range r.seen {
+		if now.Sub(receivedAt) > turvoEventRetention {
+			delete(r.seen, id)
+		}
+	}
+	if _, ok := r.seen[key]; ok {
+		r.mu.Unlock()
+		fmt.Printf("DEBUG: Ignoring duplicate Turvo event %s\n", key)
+		return event, true, nil
+	}
+	r.seen[key] = now
+	listeners := append([]TurvoEventListener{}, r.listeners...)
+	r.mu.Unlock()
+
+	fmt.Printf("DEBUG: Received Turvo event %s (%s)\n", key, event.EventType)
+	for _, listener := range listeners {
+		listener(event)
+	}
+	return event, false, nil
+}
+
+// verify checks a hex HMAC-SHA256 signature of the body
+func (r *TurvoWebhookReceiver) verify(body []byte, signature string) bool {
+	expected, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(r.secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
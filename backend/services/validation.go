@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"turvo-app/types"
+)
+
+// maxLoadWeightLbs is the heaviest load accepted without permits
+const maxLoadWeightLbs = 80000
+
+// maxPalletCount is the most pallets a load can carry, a 53 ft trailer double-stacked
+const maxPalletCount = 60
+
+// ValidateLoad checks a load before it is sent to Turvo and returns every
+// failure with the JSON path of the offending field, or nil when valid
+func ValidateLoad(load types.Load) types.ValidationErrors {
+	var errs types.ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, types.ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, id := range []struct{ name, value string }{
+		{"externalTMSLoadID", load.ExternalTMSLoadID},
+		{"freightLoadID", load.FreightLoadID},
+		{"status", load.Status},
+	} {
+		if strings.TrimSpace(id.value) == "" {
+			add(id.name, "is required")
+		}
+	}
+	validateParties(load, add)
+	validateSchedule(load, add)
+	validateSpecifications(load.Specifications, add)
+	validateRates(load.RateData, add)
+
+	specs := load.Specifications
+	equipmentType, ok := normalizeEquipmentType(load.Equipment.Type, specs)
+	if !ok {
+		add("equipment.type", "unsupported equipment type %q (expected %s, %s, %s or %s)",
+			load.Equipment.Type, EquipmentDryVan, EquipmentReefer, EquipmentFlatbed, EquipmentStepDeck)
+	}
+	if length := load.Equipment.TrailerLengthFt; length != 0 {
+		if !supportedTrailerLength(length) {
+			add("equipment.trailerLengthFt", "unsupported trailer length %d ft (expected 20, 28, 40, 45, 48 or 53)", length)
+		}
+	}
+
+	if equipmentType == EquipmentReefer && !hasTemperatureRange(specs) {
+		add("specifications.minTempFahrenheit", "reefer loads require a temperature range")
+	}
+	if hasTemperatureRange(specs) {
+		if ok && equipmentType != EquipmentReefer {
+			add("specifications.minTempFahrenheit", "temperature range requires reefer equipment")
+		}
+		minTemp, maxTemp, complete := temperatureRange(specs)
+		switch {
+		case specs.MinTempFahrenheit == nil:
+			add("specifications.minTempFahrenheit", "required with maxTempFahrenheit")
+		case specs.MaxTempFahrenheit == nil:
+			add("specifications.maxTempFahrenheit", "required with minTempFahrenheit")
+		}
+		if complete && minTemp > maxTemp {
+			add("specifications.maxTempFahrenheit", "must not be below minTempFahrenheit")
+		}
+		if complete && (minTemp < -40 || maxTemp > 100) {
+			add("specifications.minTempFahrenheit", "temperature range must be within -40F to 100F")
+		}
+	}
+
+	validateCommodities(load.Commodities, add)
+	validateMode(load, add)
+	validateHazmat(load, add)
+
+	return errs
+}
+
+// validateSpecifications checks that weights, pallet counts and miles are in range
+func validateSpecifications(specs types.Specifications, add func(field, format string, args ...interface{})) {
+	if specs.TotalWeight < 0 {
+		add("specifications.totalWeight", "cannot be negative")
+	} else if specs.TotalWeight > maxLoadWeightLbs && !specs.Permits {
+		add("specifications.totalWeight", "must be at most %d lbs without permits", maxLoadWeightLbs)
+	}
+	if specs.BillableWeight < 0 {
+		add("specifications.billableWeight", "cannot be negative")
+	}
+	for _, count := range []struct {
+		name  string
+		value int
+	}{
+		{"inPalletCount", specs.InPalletCount},
+		{"outPalletCount", specs.OutPalletCount},
+	} {
+		if count.value < 0 {
+			add("specifications."+count.name, "cannot be negative")
+		} else if count.value > maxPalletCount {
+			add("specifications."+count.name, "must be at most %d", maxPalletCount)
+		}
+	}
+	if specs.NumCommodities < 0 {
+		add("specifications.numCommodities", "cannot be negative")
+	}
+	if specs.RouteMiles < 0 {
+		add("specifications.routeMiles", "cannot be negative")
+	}
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"turvo-app/types"
+)
+
+// validLoad returns a truckload that passes validation
+func validLoad() types.Load {
+	pickupAt := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)
+	return types.Load{
+		ExternalTMSLoadID: "LOAD-1",
+		FreightLoadID:     "FL-1",
+		Status:            "Tendered",
+		Customer:          types.Customer{Name: "Acme Foods", Country: "US"},
+		BillTo:            types.BillTo{Name: "Acme Foods AP", Country: "US"},
+		Pickup: types.Pickup{Name: "Acme DC", AddressLine1: "100 W Randolph St", City: "Chicago", State: "IL", Zipcode: "60601", Country: "US",
+			ReadyTime: pickupAt.Add(-2 * time.Hour), ApptTime: pickupAt},
+		Consignee: types.Consignee{Name: "Grocer", AddressLine1: "1 Main St", City: "Dallas", State: "TX", Zipcode: "75201", Country: "US",
+			ApptTime: pickupAt.Add(48 * time.Hour)},
+		RateData:       types.RateData{CustomerLhRateUsd: 2000, CarrierLhRateUsd: 1600},
+		Specifications: types.Specifications{TotalWeight: 30000, InPalletCount: 20, OutPalletCount: 20, RouteMiles: 960},
+	}
+}
+
+func TestValidateLoad(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*types.Load)
+		want   string
+	}{
+		{"valid", func(l *types.Load) {}, ""},
+		{"missing identifiers", func(l *types.Load) { l.ExternalTMSLoadID, l.Status = "", " " }, "externalTMSLoadID status"},
+		{"pickup without an address", func(l *types.Load) { l.Pickup.AddressLine1, l.Pickup.City = "N/A", "" }, "pickup.addressLine1 pickup.city"},
+		{"zip in another state", func(l *types.Load) { l.Consignee.Zipcode = "60602" }, "consignee.zipcode"},
+		{"unsupported country", func(l *types.Load) { l.Customer.Country = "DE" }, "customer.country"},
+		{"pickup before ready time", func(l *types.Load) { l.Pickup.ReadyTime = l.Pickup.ApptTime.Add(time.Hour) }, "pickup.apptTime"},
+		{"delivery before pickup", func(l *types.Load) { l.Consignee.ApptTime = l.Pickup.ApptTime }, "consignee.apptTime"},
+		{"overweight without permits", func(l *types.Load) { l.Specifications.TotalWeight = 80001 }, "specifications.totalWeight"},
+		{"overweight with permits", func(l *types.Load) { l.Specifications.TotalWeight, l.Specifications.Permits = 90000, true }, ""},
+		{"too many pallets", func(l *types.Load) { l.Specifications.InPalletCount = 61 }, "specifications.inPalletCount"},
+		{"negative miles", func(l *types.Load) { l.Specifications.RouteMiles = -1 }, "specifications.routeMiles"},
+		{"carrier rate without a customer rate", func(l *types.Load) { l.RateData.CustomerLhRateUsd = 0 }, "rateData.customerLhRateUsd"},
+		{"hourly without hours", func(l *types.Load) { l.RateData.CarrierRateType = "hourly" }, "rateData.carrierNumHours"},
+		{"fuel surcharge over 100 percent", func(l *types.Load) { l.RateData.FSCPercent = 120 }, "rateData.fscPercent"},
+		{"unsupported equipment", func(l *types.Load) { l.Equipment.Type = "tanker" }, "equipment.type"},
+		{"unsupported trailer length", func(l *types.Load) { l.Equipment.TrailerLengthFt = 57 }, "equipment.trailerLengthFt"},
+		{"reefer without temperatures", func(l *types.Load) { l.Equipment.Type = "reefer" }, "specifications.minTempFahrenheit"},
+		{"reefer at exactly 0F", func(l *types.Load) {
+			l.Equipment.Type = "reefer"
+			l.Specifications.MinTempFahrenheit, l.Specifications.MaxTempFahrenheit = fahrenheit(0), fahrenheit(0)
+		}, ""},
+		{"reefer with only a maximum", func(l *types.Load) {
+			l.Equipment.Type = "reefer"
+			l.Specifications.MaxTempFahrenheit = fahrenheit(38)
+		}, "specifications.minTempFahrenheit"},
+		{"reversed temperatures", func(l *types.Load) {
+			l.Specifications.MinTempFahrenheit, l.Specifications.MaxTempFahrenheit = fahrenheit(40), fahrenheit(34)
+		}, "specifications.maxTempFahrenheit"},
+		{"temperatures on a dry van", func(l *types.Load) {
+			l.Equipment.Type = "dry van"
+			l.Specifications.MinTempFahrenheit, l.Specifications.MaxTempFahrenheit = fahrenheit(34), fahrenheit(38)
+		}, "specifications.minTempFahrenheit"},
+		{"LTL without commodities", func(l *types.Load) { l.Mode = "ltl" }, "commodities"},
+		{"unsupported mode", func(l *types.Load) { l.Mode = "rail" }, "mode"},
+		{"hazmat without details", func(l *types.Load) { l.Specifications.Hazmat = true }, "specifications.hazmat"},
+	}
+	for _, test := range tests {
+		load := validLoad()
+		test.modify(&load)
+		fields := []string{}
+		for _, err := range ValidateLoad(load) {
+			fields = append(fields, err.Field)
+		}
+		if got := strings.Join(fields, " "); got != test.want {
+			t.Errorf("%s: got errors on %q, want %q", test.name, got, test.want)
+		}
+	}
+}
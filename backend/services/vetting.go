@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// Errors returned when a carrier fails vetting without an accepted override
+var (
+	ErrCarrierVettingFailed        = errors.New("carrier failed compliance vetting")
+	ErrVettingOverrideNotPermitted = errors.New("carrier failed compliance vetting and the override user is not permitted to override it")
+)
+
+// insuranceWarningDays is how close to expiry insurance is flagged as a warning
+const insuranceWarningDays = 30
+
+// Carrier number formats: an MC number with an optional MC prefix, and a USDOT number
+var (
+	mcNumberPattern  = regexp.MustCompile(`^(?:MC)?(\d{1,8})$`)
+	dotNumberPattern = regexp.MustCompile(`^(?:USDOT|DOT)?(\d{1,8})$`)
+)
+
+// CarrierComplianceProvider looks up carrier compliance data. Lookup returns
+// a nil record without error when the provider has no data for the carrier.
+type CarrierComplianceProvider interface {
+	Name() string
+	Lookup(mcNumber, dotNumber string) (*types.CarrierComplianceRecord, error)
+}
+
+// FileComplianceProvider serves compliance data from a local JSON snapshot
+type FileComplianceProvider struct {
+	records []types.CarrierComplianceRecord
+}
+
+// NewFileComplianceProvider loads a compliance snapshot from a JSON array of records
+func NewFileComplianceProvider(path string) (*FileComplianceProvider, error) {
+	provider := &FileComplianceProvider{}
+	if err := readJSONFile(path, &provider.records); err != nil {
+		return nil, fmt.Errorf("failed to load carrier compliance data from %s: %w", path, err)
+	}
+	for i, record := range provider.records {
+		provider.records[i].MCNumber, _ = normalizeMCNumber(record.MCNumber)
+		provider.records[i].DOTNumber, _ = normalizeDOTNumber(record.DOTNumber)
+	}
+	return provider, nil
+}
+
+// Name identifies the provider in vetting results
+func (p *FileComplianceProvider) Name() string {
+	return "file"
+}
+
+// Lookup finds a record by DOT number, then by MC number
+func (p *FileComplianceProvider) Lookup(mcNumber, dotNumber string) (*types.CarrierComplianceRecord, error) {
+	for _, record := range p.records {
+		if dotNumber != "" && record.DOTNumber == dotNumber {
+			found := record
+			return &found, nil
+		}
+	}
+	for _, record := range p.records {
+		if mcNumber != "" && record.MCNumber == mcNumber {
+			found := record
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// CarrierVettingService checks carriers against a compliance provider before dispatch
+type CarrierVettingService struct {
+	provider      CarrierComplianceProvider
+	overrideUsers map[string]bool
+}
+
+// NewCarrierVettingService creates the vetting service with the configured
+// provider. Without compliance data only number formats are checked.
+func NewCarrierVettingService(cfg *config.Config) *CarrierVettingService {
+	service := &CarrierVettingService{overrideUsers: map[string]bool{}}
+	for _, user := range strings.Split(cfg.VettingOverrideUsers, ",") {
+		if user = strings.ToLower(strings.TrimSpace(user)); user != "" {
+			service.overrideUsers[user] = true
+		}
+	}
+
+	switch cfg.CarrierVettingProvider {
+	case "file", "":
+		if cfg.CarrierComplianceFile == "" {
+			break
+		}
+		provider, err := NewFileComplianceProvider(cfg.CarrierComplianceFile)
+		if err != nil {
+			fmt.Printf("DEBUG: %v\n", err)
+			break
+		}
+		service.provider = provider
+		fmt.Printf("DEBUG: Loaded %d carrier compliance records\n", len(provider.records))
+	default:
+		fmt.Printf("DEBUG: Unknown carrier vetting provider %q, checking number formats only\n", cfg.CarrierVettingProvider)
+	}
+	return service
+}
+
+// SetProvider replaces the compliance provider, such as with a remote lookup service
+func (s *CarrierVettingService) SetProvider(provider CarrierComplianceProvider) {
+	s.provider = provider
+}
+
+// Vet checks a carrier's MC and DOT numbers and its authority, insurance and
+// safety rating. Any failed check fails the carrier; warnings do not.
+func (s *CarrierVettingService) Vet(carrier types.Carrier) types.VettingResult {
+	result := types.VettingResult{
+		Status:    types.VettingStatusPass,
+		Findings:  []types.VettingFinding{},
+		CheckedAt: time.Now(),
+	}
+	add := func(severity, code, format string, args ...interface{}) {
+		result.Findings = append(result.Findings, types.VettingFinding{
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+		if severity == types.VettingStatusFail {
+			result.Status = types.VettingStatusFail
+		} else if result.Status == types.VettingStatusPass {
+			result.Status = types.VettingStatusWarn
+		}
+	}
+
+	mcNumber, mcOK := normalizeMCNumber(carrier.MCNumber)
+	dotNumber, dotOK := normalizeDOTNumber(carrier.DOTNumber)
+	result.MCNumber, result.DOTNumber = mcNumber, dotNumber
+	if !mcOK {
+		add(types.VettingStatusFail, "invalid_mc", "MC number %q must be up to 8 digits with an optional MC prefix", carrier.MCNumber)
+	}
+	if !dotOK {
+		add(types.VettingStatusFail, "invalid_dot", "DOT number %q must be up to 8 digits", carrier.DOTNumber)
+	}
+	if mcOK && dotOK && mcNumber == "" && dotNumber == "" {
+		add(types.VettingStatusFail, "missing_numbers", "carrier has no MC or DOT number")
+	}
+	if result.Status == types.VettingStatusFail {
+		return result
+	}
+
+	if s.provider == nil {
+		add(types.VettingStatusWarn, "no_provider", "no carrier compliance data is configured; authority and insurance were not checked")
+		return result
+	}
+	result.Provider = s.provider.Name()
+	record, err := s.provider.Lookup(mcNumber, dotNumber)
+	if err != nil {
+		add(types.VettingStatusFail, "lookup_failed", "compliance lookup failed: %v", err)
+		return result
+	}
+	if record == nil {
+		add(types.VettingStatusFail, "not_found", "carrier was not found in %s compliance data", result.Provider)
+		return result
+	}
+	result.Record = record
+	checkComplianceRecord(*record, mcNumber, dotNumber, result.CheckedAt, add)
+	return result
+}
+
+// CheckOverride returns nil when a carrier may be dispatched: it passed or
+// warned, or it failed and a permitted user acknowledged the risk with a
+// reason. permitted is set when the caller holds the override permission;
+// otherwise the user must be listed in the override users.
+func (s *CarrierVettingService) CheckOverride(result types.VettingResult, override *types.VettingOverride, permitted bool) error {
+	if result.Status != types.VettingStatusFail {
+		return nil
+	}
+	if override == nil {
+		return ErrCarrierVettingFailed
+	}
+	if !override.Acknowledged {
+		return fmt.Errorf("%w: override must acknowledge the risk", ErrCarrierVettingFailed)
+	}
+	if strings.TrimSpace(override.Reason) == "" {
+		return fmt.Errorf("%w: override requires a reason", ErrCarrierVettingFailed)
+	}
+	if !permitted && !s.overrideUsers[strings.ToLower(strings.TrimSpace(override.User))] {
+		return fmt.Errorf("%w: %q", ErrVettingOverrideNotPermitted, override.User)
+	}
+	fmt.Printf("DEBUG: Carrier vetting override by %s for MC %s / DOT %s: %s\n",
+		override.User, result.MCNumber, result.DOTNumber, override.Reason)
+	return nil
+}
+
+// checkComplianceRecord flags a record's number mismatches, authority,
+// out-of-service orders, insurance expiry and safety rating
+func checkComplianceRecord(record types.CarrierComplianceRecord, mcNumber, dotNumber string, now time.Time, add func(severity, code, format string, args ...interface{})) {
+	if mcNumber != "" && record.MCNumber != "" && record.MCNumber != mcNumber {
+		add(types.VettingStatusFail, "mc_mismatch", "MC number %s does not match MC %s on file for DOT %s", mcNumber, record.MCNumber, record.DOTNumber)
+	}
+	if dotNumber != "" && record.DOTNumber != "" && record.DOTNumber != dotNumber {
+		add(types.VettingStatusFail, "dot_mismatch", "DOT number %s does not match DOT %s on file for MC %s", dotNumber, record.DOTNumber, record.MCNumber)
+	}
+
+	if authority := strings.ToLower(strings.TrimSpace(record.AuthorityStatus)); authority != "active" {
+		add(types.VettingStatusFail, "authority_inactive", "operating authority is %s", firstKnown(authority, "unknown"))
+	}
+	if record.OutOfService {
+		add(types.VettingStatusFail, "out_of_service", "carrier is under an out-of-service order")
+	}
+
+	expires, err := time.Parse("2006-01-02", strings.TrimSpace(record.InsuranceExpiresOn))
+	switch {
+	case err != nil:
+		add(types.VettingStatusFail, "insurance_unknown", "insurance expiry %q is missing or invalid", record.InsuranceExpiresOn)
+	case expires.Before(now.Truncate(24 * time.Hour)):
+		add(types.VettingStatusFail, "insurance_expired", "insurance expired on %s", record.InsuranceExpiresOn)
+	case expires.Before(now.AddDate(0, 0, insuranceWarningDays)):
+		add(types.VettingStatusWarn, "insurance_expiring", "insurance expires on %s", record.InsuranceExpiresOn)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(record.SafetyRating)) {
+	case "unsatisfactory":
+		add(types.VettingStatusFail, "safety_unsatisfactory", "safety rating is unsatisfactory")
+	case "conditional":
+		add(types.VettingStatusWarn, "safety_conditional", "safety rating is conditional")
+	}
+}
+
+// normalizeMCNumber strips spaces, dashes and the MC prefix. An empty number is valid.
+func normalizeMCNumber(mcNumber string) (string, bool) {
+	return normalizeCarrierNumber(mcNumber, mcNumberPattern)
+}
+
+// normalizeDOTNumber strips spaces, dashes and any USDOT prefix. An empty number is valid.
+func normalizeDOTNumber(dotNumber string) (string, bool) {
+	return normalizeCarrierNumber(dotNumber, dotNumberPattern)
+}
+
+// normalizeCarrierNumber matches a carrier number against pattern and returns its digits
+func normalizeCarrierNumber(number string, pattern *regexp.Regexp) (string, bool) {
+	number = strings.ToUpper(knownValue(number))
+	number = strings.NewReplacer(" ", "", "-", "", "#", "").Replace(number)
+	if number == "" {
+		return "", true
+	}
+	match := pattern.FindStringSubmatch(number)
+	if match == nil {
+		return number, false
+	}
+	return strings.TrimLeft(match[1], "0"), true
+}
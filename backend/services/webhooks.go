@@ -0,0 +1,817 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// Headers sent with every webhook delivery. The signature is the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the subscription secret.
+const (
+	webhookHeaderID        = "X-Webhook-Id"
+	webhookHeaderEvent     = "X-Webhook-Event"
+	webhookHeaderTimestamp = "X-Webhook-Timestamp"
+	webhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// maxWebhookRetryDelay caps the exponential backoff between delivery attempts
+const maxWebhookRetryDelay = time.Hour
+
+// maxDeadLetters is how many failed deliveries are kept for replay
+const maxDeadLetters = 1000
+
+// webhookWorkers is how many deliveries are attempted at once
+const webhookWorkers = 4
+
+// maxObservedShipments is how many shipments ObserveShipment remembers. The
+// least recently observed shipment is forgotten first, and is then treated
+// as new the next time it is seen.
+const maxObservedShipments = 10000
+
+// webhookEventTypes are the events subscribers can register for
+var webhookEventTypes = map[string]bool{
+	types.EventLoadCreated:       true,
+	types.EventLoadUpdated:       true,
+	types.EventLoadStatusChanged: true,
+	types.EventLoadDelivered:     true,
+	types.EventLoadStopArrived:   true,
+	types.EventLoadStopDeparted:  true,
+	types.WebhookEventAll:        true,
+}
+
+// blockedWebhookNetworks are address ranges webhooks may not target on top
+// of loopback, private, link-local, multicast and unspecified addresses
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved
+	"64:ff9b::/96",  // NAT64, which can map to internal IPv4 hosts
+)
+
+// webhookTrackerConsumer is the ShipmentTracker consumer used for stop changes
+const webhookTrackerConsumer = "webhooks"
+
+// WebhookService signs and delivers load lifecycle events to subscribers.
+// Deliveries wait in a queue, persisted when a file is configured, that Run
+// drains; failed deliveries are retried with exponential backoff before moving
+// to a dead-letter list that can be replayed. Every published event is also
+// passed to in-process listeners.
+type WebhookService struct {
+	mu            sync.Mutex
+	subscriptions []types.WebhookSubscription
+	queue         []types.WebhookDelivery
+	inFlight      map[string]bool
+	deadLetters   []types.WebhookDelivery
+	observed      map[string]observedLoad
+	tracker       *ShipmentTracker
+	listeners     []func(types.WebhookEvent)
+	wake          chan struct{}
+
+	subscriptionsFile string
+	queueFile         *snapshotFile
+	deadLettersFile   *snapshotFile
+	maxAttempts       int
+	retryBase         time.Duration
+	client            *http.Client
+}
+
+// observedLoad is the last state of a Turvo shipment seen by a sync
+type observedLoad struct {
+	Status      string
+	Fingerprint string
+	ObservedAt  time.Time
+}
+
+// snapshotFile writes copies of a list taken under the service lock after the
+// lock is released. Snapshots are numbered when taken so a slow write cannot
+// replace a newer snapshot with an older one.
+type snapshotFile struct {
+	path  string
+	taken int64 // guarded by the service lock
+
+	mu      sync.Mutex
+	written int64
+}
+
+// snapshot numbers a copy of deliveries for writing. Callers hold the service lock.
+func (f *snapshotFile) snapshot(deliveries []types.WebhookDelivery) webhookSnapshot {
+	f.taken++
+	return webhookSnapshot{file: f, version: f.taken, deliveries: append([]types.WebhookDelivery{}, deliveries...)}
+}
+
+// webhookSnapshot is a numbered copy of a delivery list
+type webhookSnapshot struct {
+	file       *snapshotFile
+	version    int64
+	deliveries []types.WebhookDelivery
+}
+
+// save writes the snapshot unless a newer one was written first
+func (w webhookSnapshot) save() error {
+	if w.file.path == "" {
+		return nil
+	}
+	w.file.mu.Lock()
+	defer w.file.mu.Unlock()
+	if w.version <= w.file.written {
+		return nil
+	}
+	if err := writeJSONFile(w.file.path, w.deliveries); err != nil {
+		return err
+	}
+	w.file.written = w.version
+	return nil
+}
+
+// NewWebhookService creates the webhook service, loading subscriptions, queued
+// deliveries and dead letters from the configured files. Deliveries are sent
+// once Run is started.
+func NewWebhookService(cfg *config.Config) *WebhookService {
+	service := &WebhookService{
+		subscriptions:     []types.WebhookSubscription{},
+		queue:             []types.WebhookDelivery{},
+		inFlight:          make(map[string]bool),
+		deadLetters:       []types.WebhookDelivery{},
+		observed:          make(map[string]observedLoad),
+		tracker:           NewShipmentTracker(),
+		wake:              make(chan struct{}, 1),
+		subscriptionsFile: cfg.WebhookSubscriptionsFile,
+		queueFile:         &snapshotFile{path: cfg.WebhookQueueFile},
+		deadLettersFile:   &snapshotFile{path: cfg.WebhookDeadLettersFile},
+		maxAttempts:       cfg.WebhookMaxAttempts,
+		retryBase:         time.Duration(cfg.WebhookRetryBaseSeconds) * time.Second,
+		client:            newWebhookClient(),
+	}
+	if service.maxAttempts < 1 {
+		service.maxAttempts = 1
+	}
+
+	if service.subscriptionsFile != "" {
+		if err := readJSONFile(service.subscriptionsFile, &service.subscriptions); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("DEBUG: Failed to load webhook subscriptions: %v\n", err)
+		}
+	}
+	if service.queueFile.path != "" {
+		if err := readJSONFile(service.queueFile.path, &service.queue); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("DEBUG: Failed to load webhook queue: %v\n", err)
+		}
+	}
+	if service.deadLettersFile.path != "" {
+		if err := readJSONFile(service.deadLettersFile.path, &service.deadLetters); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("DEBUG: Failed to load webhook dead letters: %v\n", err)
+		}
+	}
+
+	// Subscriptions and events saved before tenants existed belong to the default tenant
+	for i := range service.subscriptions {
+		if service.subscriptions[i].TenantID == "" {
+			service.subscriptions[i].TenantID = types.DefaultTenantID
+		}
+	}
+	for i := range service.deadLetters {
+		if service.deadLetters[i].Event.TenantID == "" {
+			service.deadLetters[i].Event.TenantID = types.DefaultTenantID
+		}
+	}
+
+	fmt.Printf("DEBUG: Loaded %d webhook subscriptions, %d queued deliveries and %d dead letters\n",
+		len(service.subscriptions), len(service.queue), len(service.deadLetters))
+	return service
+}
+
+// Subscriptions returns a tenant's subscriptions without their secrets
+func (s *WebhookService) Subscriptions(tenantID string) []types.WebhookSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := []types.WebhookSubscription{}
+	for _, subscription := range s.subscriptions {
+		if subscription.TenantID == tenantID {
+			subscription.Secret = ""
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions
+}
+
+// Subscribe registers a subscriber URL for the given events of a tenant and
+// returns the subscription with its signing secret
+func (s *WebhookService) Subscribe(tenantID string, req types.WebhookSubscriptionRequest) (types.WebhookSubscription, error) {
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return types.WebhookSubscription{}, fmt.Errorf("webhook url must be an absolute http or https URL: %q", req.URL)
+	}
+	if err := checkWebhookHost(target.Hostname()); err != nil {
+		return types.WebhookSubscription{}, err
+	}
+	if len(req.Events) == 0 {
+		return types.WebhookSubscription{}, fmt.Errorf("at least one event is required")
+	}
+	events := []string{}
+	for _, event := range req.Events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !webhookEventTypes[event] {
+			return types.WebhookSubscription{}, fmt.Errorf("unsupported webhook event %q", event)
+		}
+		events = append(events, event)
+	}
+
+	subscription := types.WebhookSubscription{
+		ID:        newWebhookID("whsub"),
+		TenantID:  tenantID,
+		URL:       target.String(),
+		Events:    events,
+		Secret:    strings.TrimSpace(req.Secret),
+		CreatedAt: time.Now(),
+	}
+	if subscription.Secret == "" {
+		subscription.Secret = newWebhookID("whsec")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscriptions = append(s.subscriptions, subscription)
+	if err := s.saveSubscriptions(); err != nil {
+		return types.WebhookSubscription{}, err
+	}
+	fmt.Printf("DEBUG: Registered webhook %s for %s on tenant %s (%s)\n", subscription.ID, subscription.URL, tenantID, strings.Join(events, ", "))
+	return subscription, nil
+}
+
+// Unsubscribe removes one of a tenant's subscriptions. A delivery already in
+// flight still finishes; queued retries for it are dropped.
+func (s *WebhookService) Unsubscribe(tenantID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, subscription := range s.subscriptions {
+		if subscription.ID == id && subscription.TenantID == tenantID {
+			s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			return s.saveSubscriptions()
+		}
+	}
+	return fmt.Errorf("unknown webhook subscription %q", id)
+}
+
+// DeadLetters returns a tenant's deliveries that exhausted their retries
+func (s *WebhookService) DeadLetters(tenantID string) []types.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadLetters := []types.WebhookDelivery{}
+	for _, delivery := range s.deadLetters {
+		if delivery.Event.TenantID == tenantID {
+			deadLetters = append(deadLetters, delivery)
+		}
+	}
+	return deadLetters
+}
+
+// Replay moves one of a tenant's dead letters back to the delivery queue with
+// a fresh set of retries. The event keeps its ID so subscribers can discard
+// duplicates.
+func (s *WebhookService) Replay(tenantID, deliveryID string) (types.WebhookDelivery, error) {
+	s.mu.Lock()
+	index := -1
+	for i, delivery := range s.deadLetters {
+		if delivery.ID == deliveryID && delivery.Event.TenantID == tenantID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		s.mu.Unlock()
+		return types.WebhookDelivery{}, fmt.Errorf("unknown dead letter %q", deliveryID)
+	}
+	delivery := s.deadLetters[index]
+	subscription, ok := s.subscription(delivery.SubscriptionID)
+	if !ok {
+		s.mu.Unlock()
+		return types.WebhookDelivery{}, fmt.Errorf("webhook subscription %q no longer exists", delivery.SubscriptionID)
+	}
+	s.deadLetters = append(s.deadLetters[:index], s.deadLetters[index+1:]...)
+
+	delivery.URL = subscription.URL
+	delivery.Attempts = 0
+	delivery.LastStatusCode = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeadLetteredAt = time.Time{}
+	s.queue = append(s.queue, delivery)
+	deadLetters := s.deadLettersFile.snapshot(s.deadLetters)
+	queue := s.queueFile.snapshot(s.queue)
+	s.mu.Unlock()
+
+	// Queue the delivery before dropping the dead letter, so a failed write
+	// can repeat the delivery but not lose it
+	if err := queue.save(); err != nil {
+		fmt.Printf("DEBUG: Failed to save webhook queue: %v\n", err)
+	}
+	if err := deadLetters.save(); err != nil {
+		fmt.Printf("DEBUG: Failed to save webhook dead letters: %v\n", err)
+	}
+	s.wakeWorker()
+	return delivery, nil
+}
+
+// Listen registers an in-process listener for every published event.
+// Listeners run on the publishing goroutine and must not block.
+func (s *WebhookService) Listen(listener func(types.WebhookEvent)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// LoadEvent builds a webhook event for a tenant's load. Carrier cost and
+// margin are masked when the event is delivered to subscribers.
+func LoadEvent(tenantID, eventType string, load types.Load) types.WebhookEvent {
+	return types.WebhookEvent{
+		Type:       eventType,
+		TenantID:   tenantID,
+		ShipmentID: load.ExternalTMSLoadID,
+		Status:     load.Status,
+		OccurredAt: time.Now(),
+		Load:       &load,
+	}
+}
+
+// Publish queues an event for every subscription of its tenant registered
+// for its type and passes it to the listeners. Run delivers the queue.
+func (s *WebhookService) Publish(event types.WebhookEvent) {
+	if event.ID == "" {
+		event.ID = newWebhookID("evt")
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	s.mu.Lock()
+	queued := false
+	for _, subscription := range s.subscriptions {
+		if subscription.TenantID != event.TenantID || !subscribedTo(subscription, event.Type) {
+			continue
+		}
+		now := time.Now()
+		s.queue = append(s.queue, types.WebhookDelivery{
+			ID:             newWebhookID("whdel"),
+			SubscriptionID: subscription.ID,
+			URL:            subscription.URL,
+			Event:          event,
+			CreatedAt:      now,
+			NextAttemptAt:  now,
+		})
+		queued = true
+	}
+	var queue webhookSnapshot
+	if queued {
+		queue = s.queueFile.snapshot(s.queue)
+	}
+	listeners := append([]func(types.WebhookEvent){}, s.listeners...)
+	s.mu.Unlock()
+
+	if queued {
+		if err := queue.save(); err != nil {
+			fmt.Printf("DEBUG: Failed to save webhook queue: %v\n", err)
+		}
+		s.wakeWorker()
+	}
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// ObserveShipment compares a shipment read from Turvo, and its converted
+// load, with the last time a sync saw it and publishes updated, status
+// change, delivered and stop events. The first observation of a shipment
+// only records its state. Shipments are tracked per tenant.
+func (s *WebhookService) ObserveShipment(tenantID string, shipment types.TurvoShipment, load types.Load) {
+	if load.ExternalTMSLoadID == "" {
+		return
+	}
+	stopChanges := s.tracker.Track(webhookTrackerConsumer+":"+tenantID, shipment)
+
+	data, err := json.Marshal(load)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(data)
+	current := observedLoad{Status: load.Status, Fingerprint: hex.EncodeToString(sum[:]), ObservedAt: time.Now()}
+
+	s.mu.Lock()
+	key := tenantID + "|" + load.ExternalTMSLoadID
+	previous, seen := s.observed[key]
+	s.observed[key] = current
+	if !seen && len(s.observed) > maxObservedShipments {
+		s.forgetOldestObserved()
+	}
+	s.mu.Unlock()
+
+	if !seen {
+		return
+	}
+	for _, change := range stopChanges {
+		if change.Stop == nil {
+			continue
+		}
+		eventType := types.EventLoadStopArrived
+		if change.Type == types.ChangeStopDeparted {
+			eventType = types.EventLoadStopDeparted
+		}
+		event := LoadEvent(tenantID, eventType, load)
+		event.StopType = stopTypeName(change.Stop.StopType.Key)
+		event.StopName = change.Stop.Name
+		event.OccurredAt = change.OccurredAt
+		s.Publish(event)
+	}
+	if previous.Fingerprint == current.Fingerprint {
+		return
+	}
+	s.Publish(LoadEvent(tenantID, types.EventLoadUpdated, load))
+	if previous.Status != current.Status {
+		event := LoadEvent(tenantID, types.EventLoadStatusChanged, load)
+		event.PreviousStatus = previous.Status
+		s.Publish(event)
+		if strings.EqualFold(strings.TrimSpace(load.Status), "delivered") {
+			s.Publish(LoadEvent(tenantID, types.EventLoadDelivered, load))
+		}
+	}
+}
+
+// Run delivers queued webhooks until stop is closed, attempting up to
+// webhookWorkers deliveries at once. Deliveries still in the queue file from
+// before a restart are sent again.
+func (s *WebhookService) Run(stop <-chan struct{}) {
+	slots := make(chan struct{}, webhookWorkers)
+	for {
+		for _, delivery := range s.takeDue(time.Now(), cap(slots)-len(slots)) {
+			slots <- struct{}{}
+			go func(delivery types.WebhookDelivery) {
+				defer func() {
+					<-slots
+					s.wakeWorker()
+				}()
+				s.attempt(delivery)
+			}(delivery)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-s.wake:
+		case <-time.After(s.untilNextDue(time.Now())):
+		}
+	}
+}
+
+// wakeWorker tells Run to look at the queue again
+func (s *WebhookService) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takeDue marks up to limit queued deliveries that are due as in flight and
+// returns them
+func (s *WebhookService) takeDue(now time.Time, limit int) []types.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []types.WebhookDelivery{}
+	for _, delivery := range s.queue {
+		if len(due) >= limit {
+			break
+		}
+		if s.inFlight[delivery.ID] || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		s.inFlight[delivery.ID] = true
+		due = append(due, delivery)
+	}
+	return due
+}
+
+// untilNextDue returns how long until the next queued delivery is due,
+// waiting at most maxWebhookRetryDelay
+func (s *WebhookService) untilNextDue(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := maxWebhookRetryDelay
+	for _, delivery := range s.queue {
+		if s.inFlight[delivery.ID] {
+			continue
+		}
+		if until := delivery.NextAttemptAt.Sub(now); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// attempt posts a queued delivery, with carrier cost and margin masked, and
+// then removes it from the queue, schedules its next attempt with twice the
+// previous delay, or dead-letters it once the attempts run out. Deliveries
+// whose subscription was removed are dropped.
+func (s *WebhookService) attempt(delivery types.WebhookDelivery) {
+	s.mu.Lock()
+	subscription, subscribed := s.subscription(delivery.SubscriptionID)
+	s.mu.Unlock()
+
+	var err error
+	if subscribed {
+		delivery.Attempts++
+		delivery.LastStatusCode, err = s.send(delivery, subscription.Secret)
+	} else {
+		fmt.Printf("DEBUG: Dropping webhook delivery %s, subscription %s was removed\n", delivery.ID, delivery.SubscriptionID)
+	}
+
+	s.mu.Lock()
+	delete(s.inFlight, delivery.ID)
+	queue := s.queue[:0]
+	for _, queued := range s.queue {
+		if queued.ID != delivery.ID {
+			queue = append(queue, queued)
+		}
+	}
+	s.queue = queue
+
+	var deadLetters *webhookSnapshot
+	switch {
+	case !subscribed:
+	case err == nil:
+		fmt.Printf("DEBUG: Delivered webhook %s (%s) to %s on attempt %d\n", delivery.Event.ID, delivery.Event.Type, delivery.URL, delivery.Attempts)
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.LastError = err.Error()
+		s.deadLetter(delivery)
+		snapshot := s.deadLettersFile.snapshot(s.deadLetters)
+		deadLetters = &snapshot
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(s.retryDelay(delivery.Attempts))
+		s.queue = append(s.queue, delivery)
+		fmt.Printf("DEBUG: Webhook delivery %s to %s failed on attempt %d, retrying at %s: %v\n",
+			delivery.ID, delivery.URL, delivery.Attempts, delivery.NextAttemptAt.Format(time.RFC3339), err)
+	}
+	queueSnapshot := s.queueFile.snapshot(s.queue)
+	s.mu.Unlock()
+
+	// Write the dead letter before dropping the delivery from the queue file
+	if deadLetters != nil {
+		if err := deadLetters.save(); err != nil {
+			fmt.Printf("DEBUG: Failed to save webhook dead letters: %v\n", err)
+		}
+	}
+	if err := queueSnapshot.save(); err != nil {
+		fmt.Printf("DEBUG: Failed to save webhook queue: %v\n", err)
+	}
+}
+
+// retryDelay returns the wait after a failed attempt, doubling from the
+// configured base and capped at maxWebhookRetryDelay
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.retryBase
+	for i := 1; i < attempts && delay < maxWebhookRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxWebhookRetryDelay {
+		delay = maxWebhookRetryDelay
+	}
+	return delay
+}
+
+// send masks an event's carrier cost and margin and posts it once
+func (s *WebhookService) send(delivery types.WebhookDelivery, secret string) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err == nil {
+		body, err = MaskRates(body)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return s.post(delivery, secret, body)
+}
+
+// post sends one signed delivery attempt and treats non-2xx responses as failures
+func (s *WebhookService) post(delivery types.WebhookDelivery, secret string, body []byte) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest("POST", delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookHeaderID, delivery.Event.ID)
+	req.Header.Set(webhookHeaderEvent, delivery.Event.Type)
+	req.Header.Set(webhookHeaderTimestamp, timestamp)
+	req.Header.Set(webhookHeaderSignature, "sha256="+SignWebhook(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhookClient returns an HTTP client that refuses to connect to
+// internal addresses. The check runs on the resolved address of every
+// connection, so DNS changes and redirects cannot reach internal hosts.
+// Proxies are not used, since the proxy's own address would be checked.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkWebhookIP(net.ParseIP(host))
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// checkWebhookHost rejects webhook hosts that are, or resolve to, internal addresses
+func checkWebhookHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return checkWebhookIP(ip)
+	}
+	if strings.EqualFold(strings.TrimSuffix(host, "."), "localhost") || strings.HasSuffix(strings.ToLower(strings.TrimSuffix(host, ".")), ".localhost") {
+		return fmt.Errorf("webhook url must not target localhost")
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host %q: %w", host, err)
+	}
+	for _, ip := range ips {
+		if err := checkWebhookIP(ip); err != nil {
+			return fmt.Errorf("webhook host %q resolves to %s: %w", host, ip, err)
+		}
+	}
+	return nil
+}
+
+// checkWebhookIP rejects loopback, private, link-local (including cloud
+// metadata), multicast, unspecified and reserved addresses
+func checkWebhookIP(ip net.IP) error {
+	if ip == nil {
+		return fmt.Errorf("webhook target is not an IP address")
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook target %s is not a public address", ip)
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("webhook target %s is not a public address", ip)
+		}
+	}
+	return nil
+}
+
+// mustParseCIDRs parses fixed CIDR blocks, panicking on a typo
+func mustParseCIDRs(blocks ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(blocks))
+	for _, block := range blocks {
+		_, network, err := net.ParseCIDR(block)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deadLetter records a delivery that exhausted its retries, dropping the
+// oldest dead letters beyond the cap. Callers hold the lock and save the
+// dead letters after releasing it.
+func (s *WebhookService) deadLetter(delivery types.WebhookDelivery) {
+	delivery.NextAttemptAt = time.Time{}
+	delivery.DeadLetteredAt = time.Now()
+	fmt.Printf("DEBUG: Webhook delivery %s to %s dead-lettered after %d attempts\n", delivery.ID, delivery.URL, delivery.Attempts)
+
+	s.deadLetters = append(s.deadLetters, delivery)
+	if len(s.deadLetters) > maxDeadLetters {
+		s.deadLetters = s.deadLetters[len(s.deadLetters)-maxDeadLetters:]
+	}
+}
+
+// forgetOldestObserved drops the least recently observed shipment. Callers hold the lock.
+func (s *WebhookService) forgetOldestObserved() {
+	oldestKey := ""
+	var oldest time.Time
+	for key, observed := range s.observed {
+		if oldestKey == "" || observed.ObservedAt.Before(oldest) {
+			oldestKey, oldest = key, observed.ObservedAt
+		}
+	}
+	delete(s.observed, oldestKey)
+}
+
+// subscription finds a subscription by ID. Callers hold the lock.
+func (s *WebhookService) subscription(id string) (types.WebhookSubscription, bool) {
+	for _, subscription := range s.subscriptions {
+		if subscription.ID == id {
+			return subscription, true
+		}
+	}
+	return types.WebhookSubscription{}, false
+}
+
+// saveSubscriptions persists subscriptions when a file is configured. Callers hold the lock.
+func (s *WebhookService) saveSubscriptions() error {
+	if s.subscriptionsFile == "" {
+		return nil
+	}
+	if err := writeJSONFile(s.subscriptionsFile, s.subscriptions); err != nil {
+		return fmt.Errorf("failed to save webhook subscriptions: %w", err)
+	}
+	return nil
+}
+
+// subscribedTo reports whether a subscription receives an event type
+func subscribedTo(subscription types.WebhookSubscription, eventType string) bool {
+	for _, event := range subscription.Events {
+		if event == eventType || event == types.WebhookEventAll {
+			return true
+		}
+	}
+	return false
+}
+
+// stopTypeName names a Turvo stop type for event payloads
+func stopTypeName(key string) string {
+	switch key {
+	case "1500":
+		return types.AccessorialStopPickup
+	case "1501":
+		return types.AccessorialStopDelivery
+	}
+	return key
+}
+
+// newWebhookID returns a random identifier with the given prefix
+func newWebhookID(prefix string) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	}
+	return prefix + "_" + hex.EncodeToString(buf)
+}
+
+// writeJSONFile writes a value as indented JSON, readable only by the owner
+// since the files hold secrets and rates. Files written before are narrowed too.
+func writeJSONFile(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
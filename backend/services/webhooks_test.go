@@ -0,0 +1,163 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"turvo-app/config"
+	"turvo-app/types"
+)
+
+// newTestWebhookService returns a webhook service with one subscription to a
+// test server that answers with the statuses in order, then 200
+func newTestWebhookService(t *testing.T, queueFile string, statuses ...int) (*WebhookService, func() int) {
+	var mu sync.Mutex
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		status := http.StatusOK
+		if received < len(statuses) {
+			status = statuses[received]
+		}
+		received++
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	service := NewWebhookService(&config.Config{
+		WebhookQueueFile:        queueFile,
+		WebhookDeadLettersFile:  filepath.Join(filepath.Dir(queueFile), "dead_letters.json"),
+		WebhookMaxAttempts:      3,
+		WebhookRetryBaseSeconds: 0,
+	})
+	service.retryBase = time.Millisecond
+	service.client = server.Client()
+	service.subscriptions = []types.WebhookSubscription{{
+		ID: "whsub_1", TenantID: types.DefaultTenantID, URL: server.URL, Events: []string{types.WebhookEventAll}, Secret: "secret",
+	}}
+	return service, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+}
+
+// waitFor polls until done reports true or a second passes
+func waitFor(t *testing.T, what string, done func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookQueueSurvivesRestart(t *testing.T) {
+	queueFile := filepath.Join(t.TempDir(), "queue.json")
+	service, received := newTestWebhookService(t, queueFile)
+	service.Publish(types.WebhookEvent{Type: types.EventLoadCreated, TenantID: types.DefaultTenantID})
+
+	// The delivery is in the file before any worker runs
+	restarted, _ := newTestWebhookService(t, queueFile)
+	if len(restarted.queue) != 1 {
+		t.Fatalf("expected the queued delivery to be loaded, got %d", len(restarted.queue))
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go restarted.Run(stop)
+
+	waitFor(t, "the queued delivery", func() bool {
+		restarted.mu.Lock()
+		defer restarted.mu.Unlock()
+		return received() == 1 && len(restarted.queue) == 0
+	})
+	var saved []types.WebhookDelivery
+	if err := readJSONFile(queueFile, &saved); err != nil || len(saved) != 0 {
+		t.Errorf("expected an empty queue file, got %v (%v)", saved, err)
+	}
+}
+
+func TestWebhookRetriesAndDeadLetters(t *testing.T) {
+	tests := []struct {
+		name            string
+		statuses        []int
+		wantReceived    int
+		wantDeadLetters int
+	}{
+		{name: "delivered after a retry", statuses: []int{500}, wantReceived: 2},
+		{name: "dead-lettered when attempts run out", statuses: []int{500, 502, 503}, wantReceived: 3, wantDeadLetters: 1},
+	}
+	for _, test := range tests {
+		service, received := newTestWebhookService(t, filepath.Join(t.TempDir(), "queue.json"), test.statuses...)
+		stop := make(chan struct{})
+		go service.Run(stop)
+		service.Publish(types.WebhookEvent{Type: types.EventLoadUpdated, TenantID: types.DefaultTenantID})
+
+		waitFor(t, test.name, func() bool {
+			service.mu.Lock()
+			defer service.mu.Unlock()
+			return received() == test.wantReceived && len(service.queue) == 0 && len(service.inFlight) == 0
+		})
+		close(stop)
+		if got := service.DeadLetters(types.DefaultTenantID); len(got) != test.wantDeadLetters {
+			t.Errorf("%s: got %d dead letters, want %d", test.name, len(got), test.wantDeadLetters)
+		} else if len(got) == 1 && (got[0].Attempts != 3 || got[0].LastStatusCode != 503) {
+			t.Errorf("%s: unexpected dead letter %+v", test.name, got[0])
+		}
+	}
+}
+
+func TestWebhookReplayQueuesDeadLetter(t *testing.T) {
+	service, received := newTestWebhookService(t, filepath.Join(t.TempDir(), "queue.json"))
+	service.deadLetters = []types.WebhookDelivery{{
+		ID: "whdel_1", SubscriptionID: "whsub_1", Attempts: 3, LastError: "subscriber returned 500",
+		Event: types.WebhookEvent{ID: "evt_1", TenantID: types.DefaultTenantID},
+	}}
+
+	if _, err := service.Replay("other", "whdel_1"); err == nil {
+		t.Errorf("expected another tenant's dead letter to be unknown")
+	}
+	replayed, err := service.Replay(types.DefaultTenantID, "whdel_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed.Attempts != 0 || replayed.LastError != "" || len(service.DeadLetters(types.DefaultTenantID)) != 0 {
+		t.Errorf("expected a fresh delivery and no dead letter, got %+v", replayed)
+	}
+	var saved []types.WebhookDelivery
+	if err := readJSONFile(service.queueFile.path, &saved); err != nil || len(saved) != 1 || saved[0].Event.ID != "evt_1" {
+		t.Errorf("expected the replay to be saved to the queue, got %v (%v)", saved, err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go service.Run(stop)
+	waitFor(t, "the replayed delivery", func() bool { return received() == 1 })
+}
+
+func TestObserveShipmentForgetsOldest(t *testing.T) {
+	service := NewWebhookService(&config.Config{})
+	observe := func(id int) {
+		service.ObserveShipment(types.DefaultTenantID, types.TurvoShipment{}, types.Load{ExternalTMSLoadID: strconv.Itoa(id)})
+	}
+	for id := 0; id <= maxObservedShipments; id++ {
+		observe(id)
+	}
+
+	if len(service.observed) != maxObservedShipments {
+		t.Fatalf("expected %d observed shipments, got %d", maxObservedShipments, len(service.observed))
+	}
+	if _, ok := service.observed[types.DefaultTenantID+"|0"]; ok {
+		t.Errorf("expected the oldest shipment to be forgotten")
+	}
+	if _, ok := service.observed[types.DefaultTenantID+"|"+strconv.Itoa(maxObservedShipments)]; !ok {
+		t.Errorf("expected the newest shipment to be kept")
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"turvo-app/types"
+)
+
+// zipPlace is the city, state and position of a US 5-digit zip code
+type zipPlace struct {
+	City  string
+	State string
+	Geo   types.GeoPoint
+}
+
+var (
+	zipPlacesMu sync.RWMutex
+	zipPlaces   map[string]zipPlace
+)
+
+// LoadZipCodes loads the zip5 dataset used to infer city, state and position
+// from US zip codes. The file is the GeoNames US postal code export
+// (US.txt): tab-separated country, postal code, place name, state name,
+// state code, county and community columns, then latitude and longitude.
+// Without it, only the state is inferred, from the bundled zip3 prefixes.
+func LoadZipCodes(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open zip code dataset %s: %w", path, err)
+	}
+	defer file.Close()
+
+	places, err := parseZipCodes(bufio.NewScanner(file))
+	if err != nil {
+		return fmt.Errorf("failed to read zip code dataset %s: %w", path, err)
+	}
+	zipPlacesMu.Lock()
+	zipPlaces = places
+	zipPlacesMu.Unlock()
+	fmt.Printf("DEBUG: Loaded %d zip codes from %s\n", len(places), path)
+	return nil
+}
+
+// parseZipCodes reads GeoNames postal code rows, keeping US 5-digit zips.
+// The first row seen for a zip wins, as GeoNames lists the primary city first.
+func parseZipCodes(scanner *bufio.Scanner) (map[string]zipPlace, error) {
+	places := make(map[string]zipPlace)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 11 || fields[0] != CountryUS || len(fields[1]) != 5 {
+			continue
+		}
+		if _, seen := places[fields[1]]; seen {
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(fields[9], 64)
+		lng, lngErr := strconv.ParseFloat(fields[10], 64)
+		if latErr != nil || lngErr != nil {
+			continue
+		}
+		places[fields[1]] = zipPlace{
+			City:  normalizeCase(fields[2]),
+			State: strings.ToUpper(strings.TrimSpace(fields[4])),
+			Geo:   types.GeoPoint{Lat: lat, Lng: lng},
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+// lookupZip returns the place of a US zip or ZIP+4 from the zip5 dataset
+func lookupZip(zip string) (zipPlace, bool) {
+	zip = knownValue(zip)
+	if len(zip) < 5 {
+		return zipPlace{}, false
+	}
+	zipPlacesMu.RLock()
+	defer zipPlacesMu.RUnlock()
+	place, ok := zipPlaces[zip[:5]]
+	return place, ok
+}
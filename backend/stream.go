@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// streamHeartbeat keeps idle streams open through proxies
+const streamHeartbeat = 15 * time.Second
+
+// streamLoads pushes the tenant's load events as Server-Sent Events. Clients
+// can filter by customer and a comma-separated status list and resume with
+// Last-Event-ID (or lastEventId, since EventSource cannot set headers on the
+// first request). Carrier cost and margin are masked for callers without rates:read.
+func streamLoads(c *gin.Context, loadStream *services.LoadStream, access *services.AccessControl) {
+	mask := !canSeeRates(c, access)
+	filter := types.LoadStreamFilter{
+		TenantID: tenantFor(c).Tenant.ID,
+		Customer: strings.TrimSpace(c.Query("customer")),
+	}
+	for _, status := range strings.Split(c.Query("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("lastEventId")
+	}
+	resumeFrom, err := strconv.ParseInt(lastEventID, 10, 64)
+	resume := err == nil
+
+	subscription, missed, reset, latestID := loadStream.Subscribe(filter, resumeFrom, resume)
+	defer loadStream.Unsubscribe(subscription)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: 3000\n\n")
+	if reset {
+		writeStreamEvent(c.Writer, latestID, types.LoadStreamResetEvent, gin.H{}, false)
+	}
+	for _, event := range missed {
+		writeStreamEvent(c.Writer, event.Sequence, event.Event.Type, event.Event, mask)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and resumes
+				return
+			}
+			writeStreamEvent(c.Writer, event.Sequence, event.Event.Type, event.Event, mask)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprintf(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeStreamEvent writes one SSE event with its ID, type and JSON data,
+// optionally with carrier cost and margin removed
+func writeStreamEvent(w io.Writer, id int64, eventType string, data interface{}, mask bool) {
+	payload, err := json.Marshal(data)
+	if err == nil && mask {
+		payload, err = services.MaskRates(payload)
+	}
+	if err != nil {
+		fmt.Printf("DEBUG: Failed to encode stream event: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, eventType, payload)
+}
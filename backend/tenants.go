@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// tenantKey is the Gin context key holding the caller's *services.TenantServices
+const tenantKey = "tenant"
+
+// resolveTenant attaches the services of the caller's tenant, named by its
+// API key or token, answering 403 when the tenant is not served here
+func resolveTenant(registry *services.TenantRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, _ := currentIdentity(c)
+		tenant, err := registry.Resolve(identity)
+		if err != nil {
+			fmt.Printf("DEBUG: %s rejected on %s %s: %v\n", identity.User(), c.Request.Method, c.Request.URL.Path, err)
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Forbidden: " + err.Error(),
+			})
+			return
+		}
+		c.Set(tenantKey, tenant)
+		c.Next()
+	}
+}
+
+// tenantFor returns the caller's tenant attached by resolveTenant
+func tenantFor(c *gin.Context) *services.TenantServices {
+	return c.MustGet(tenantKey).(*services.TenantServices)
+}
+
+// receiveTenantTurvoWebhook accepts Turvo callbacks for the tenant named in
+// the path, or for the default tenant when the path names none
+func receiveTenantTurvoWebhook(c *gin.Context, registry *services.TenantRegistry) {
+	tenant, err := registry.Resolve(types.Identity{TenantID: c.Param("tenant")})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	receiveTurvoWebhook(c, tenant.TurvoWebhooks)
+}
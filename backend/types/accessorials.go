@@ -0,0 +1,28 @@
+package types
+
+// Stops an accessorial service can apply to
+const (
+	AccessorialStopPickup   = "pickup"
+	AccessorialStopDelivery = "delivery"
+	AccessorialStopBoth     = "both"
+)
+
+// AccessorialCode maps a Specifications flag to a Turvo stop service and,
+// when ChargeUsd is set, a billable customer order line item. ChargeUsd
+// defaults to 0, so nothing is billed unless an amount is configured.
+// X12Code overrides the flag's default EDI 210 charge code.
+type AccessorialCode struct {
+	Flag      string    `json:"flag"`
+	Stop      string    `json:"stop"`
+	Service   TurvoCode `json:"service"`
+	Charge    TurvoCode `json:"charge"`
+	ChargeUsd float64   `json:"chargeUsd"`
+	X12Code   string    `json:"x12Code,omitempty"`
+}
+
+// AccessorialCharge is a billable accessorial on a load
+type AccessorialCharge struct {
+	Flag      string    `json:"flag"`
+	Code      TurvoCode `json:"code"`
+	AmountUsd float64   `json:"amountUsd"`
+}
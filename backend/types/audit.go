@@ -0,0 +1,75 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditLoadCreate         = "load.create"
+	AuditLoadDispatch       = "load.dispatch"
+	AuditRateConSent        = "load.ratecon_sent"
+	AuditLoadStatusChange   = "load.status_change"
+	AuditEDI990             = "edi.990"
+	AuditEDI214             = "edi.214"
+	AuditEDI210             = "edi.210"
+	AuditWebhookSubscribe   = "webhook.subscribe"
+	AuditWebhookUnsubscribe = "webhook.unsubscribe"
+	AuditWebhookReplay      = "webhook.replay"
+	AuditAuthRejected       = "auth.rejected"
+)
+
+// Audit outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeDenied  = "denied"
+	AuditOutcomeFailed  = "failed"
+)
+
+// AuditEntry records one write: who made it, for which tenant, the redacted
+// request, the Turvo calls it made and how it ended
+type AuditEntry struct {
+	ID         string           `json:"id"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Actor      string           `json:"actor"`
+	AuthMethod string           `json:"authMethod,omitempty"`
+	TenantID   string           `json:"tenantId"`
+	Action     string           `json:"action"`
+	Method     string           `json:"method"`
+	Endpoint   string           `json:"endpoint"`
+	ClientIP   string           `json:"clientIp,omitempty"`
+	ResourceID string           `json:"resourceId,omitempty"`
+	Request    json.RawMessage  `json:"request,omitempty"`
+	Turvo      []AuditTurvoCall `json:"turvo,omitempty"`
+	Outcome    string           `json:"outcome"`
+	StatusCode int              `json:"statusCode"`
+	Error      string           `json:"error,omitempty"`
+	DurationMs int64            `json:"durationMs"`
+}
+
+// AuditTurvoCall summarizes one request made to Turvo. Bodies are not kept;
+// failures keep the start of the response.
+type AuditTurvoCall struct {
+	Method        string `json:"method"`
+	Path          string `json:"path"`
+	RequestBytes  int64  `json:"requestBytes"`
+	StatusCode    int    `json:"statusCode,omitempty"`
+	ResponseBytes int    `json:"responseBytes"`
+	Summary       string `json:"summary,omitempty"`
+	Error         string `json:"error,omitempty"`
+	DurationMs    int64  `json:"durationMs"`
+}
+
+// AuditFilter selects audit entries for one tenant. Empty fields match
+// every entry.
+type AuditFilter struct {
+	TenantID   string
+	Actor      string
+	Action     string
+	Outcome    string
+	ResourceID string
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
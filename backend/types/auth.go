@@ -0,0 +1,51 @@
+package types
+
+// Authentication methods recorded on an identity
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+	AuthMethodNone   = "none"
+)
+
+// Identity is the authenticated caller attached to a request
+type Identity struct {
+	Subject  string   `json:"subject"`
+	Name     string   `json:"name,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Method   string   `json:"method"`
+	Roles    []string `json:"roles,omitempty"`
+	TenantID string   `json:"tenantId,omitempty"`
+}
+
+// User names the caller for audit trails and overrides, preferring the email
+func (i Identity) User() string {
+	if i.Email != "" {
+		return i.Email
+	}
+	return i.Subject
+}
+
+// APIKey is a machine client's key. Only the hex SHA-256 of the key is stored.
+type APIKey struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	KeyHash  string   `json:"keyHash"`
+	Roles    []string `json:"roles,omitempty"`
+	TenantID string   `json:"tenantId,omitempty"`
+	Disabled bool     `json:"disabled"`
+}
+
+// JSONWebKeySet is a JWKS document of RS256 signing keys
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey is an RSA public key in JWK form
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
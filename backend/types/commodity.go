@@ -0,0 +1,31 @@
+package types
+
+// Commodity is one line of freight on a load. Dimensions are per handling
+// unit in inches and weight is the line's total in pounds.
+type Commodity struct {
+	Description       string  `json:"description"`
+	PieceCount        int     `json:"pieceCount"`
+	HandlingUnit      string  `json:"handlingUnit"`
+	HandlingUnitCount int     `json:"handlingUnitCount"`
+	WeightLbs         float64 `json:"weightLbs"`
+	LengthIn          float64 `json:"lengthIn"`
+	WidthIn           float64 `json:"widthIn"`
+	HeightIn          float64 `json:"heightIn"`
+	FreightClass      string  `json:"freightClass"`
+	NMFC              string  `json:"nmfc"`
+	Stackable         bool    `json:"stackable"`
+	Hazmat            *Hazmat `json:"hazmat,omitempty"`
+}
+
+// Hazmat holds the dangerous goods details for a commodity. Placard is the
+// placard name and defaults from the hazard class when one is required.
+type Hazmat struct {
+	UNNumber           string `json:"unNumber"`
+	ProperShippingName string `json:"properShippingName"`
+	HazardClass        string `json:"hazardClass"`
+	PackingGroup       string `json:"packingGroup"`
+	EmergencyContact   string `json:"emergencyContact"`
+	EmergencyPhone     string `json:"emergencyPhone"`
+	PlacardRequired    bool   `json:"placardRequired"`
+	Placard            string `json:"placard"`
+}
@@ -0,0 +1,30 @@
+package types
+
+// DispatchRequest is the body of POST /api/loads/:id/dispatch. The carrier
+// replaces the load's carrier; rate fields update the carrier rate when set.
+// Override is required to dispatch a carrier that fails vetting.
+type DispatchRequest struct {
+	Carrier          Carrier          `json:"carrier" binding:"required"`
+	CarrierRateType  string           `json:"carrierRateType"`
+	CarrierLhRateUsd float64          `json:"carrierLhRateUsd"`
+	CarrierNumHours  float64          `json:"carrierNumHours"`
+	Override         *VettingOverride `json:"override"`
+}
+
+// DispatchResult describes the carrier order assigned in Turvo
+type DispatchResult struct {
+	ShipmentID      string             `json:"shipmentId"`
+	CarrierID       int                `json:"carrierId"`
+	Drivers         []DispatchedDriver `json:"drivers"`
+	TractorNumber   string             `json:"tractorNumber"`
+	TrailerNumber   string             `json:"trailerNumber"`
+	CarrierTotalUsd float64            `json:"carrierTotalUsd"`
+}
+
+// DispatchedDriver is a driver assigned to a carrier order
+type DispatchedDriver struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Created bool   `json:"created"`
+}
@@ -0,0 +1,26 @@
+package types
+
+// BOLTemplate customizes the bill of lading printed for specific customers
+type BOLTemplate struct {
+	ID              string   `json:"id"`
+	Customers       []string `json:"customers"`
+	Title           string   `json:"title"`
+	CompanyName     string   `json:"companyName"`
+	CompanyAddress  string   `json:"companyAddress"`
+	CompanyPhone    string   `json:"companyPhone"`
+	Instructions    string   `json:"instructions"`
+	Terms           string   `json:"terms"`
+	HideBillTo      bool     `json:"hideBillTo"`
+	SignatureLabels []string `json:"signatureLabels"`
+}
+
+// BrokerProfile holds the broker details and terms printed on rate confirmations
+type BrokerProfile struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Phone    string `json:"phone"`
+	Email    string `json:"email"`
+	MCNumber string `json:"mcNumber"`
+	Terms    string `json:"terms"`
+}
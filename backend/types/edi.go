@@ -0,0 +1,66 @@
+package types
+
+import "time"
+
+// EDIPartner holds the ISA/GS envelope settings for one trading partner
+type EDIPartner struct {
+	ID                   string `json:"id"`
+	Name                 string `json:"name"`
+	ISASenderQualifier   string `json:"isaSenderQualifier"`
+	ISASenderID          string `json:"isaSenderId"`
+	ISAReceiverQualifier string `json:"isaReceiverQualifier"`
+	ISAReceiverID        string `json:"isaReceiverId"`
+	GSSenderCode         string `json:"gsSenderCode"`
+	GSReceiverCode       string `json:"gsReceiverCode"`
+	SCAC                 string `json:"scac"`
+	UsageIndicator       string `json:"usageIndicator"`
+	ElementSeparator     string `json:"elementSeparator"`
+	SegmentTerminator    string `json:"segmentTerminator"`
+	ComponentSeparator   string `json:"componentSeparator"`
+}
+
+// EDIMessage is a generated X12 interchange
+type EDIMessage struct {
+	PartnerID      string    `json:"partnerId"`
+	TransactionSet string    `json:"transactionSet"`
+	ControlNumber  int       `json:"controlNumber"`
+	ShipmentID     string    `json:"shipmentId"`
+	StatusCode     string    `json:"statusCode,omitempty"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// EDI990Request represents the request body for generating a 990 response
+type EDI990Request struct {
+	PartnerID string `json:"partnerId" binding:"required"`
+	Accept    bool   `json:"accept"`
+	Reason    string `json:"reason"`
+}
+
+// EDI214Request represents the request body for generating 214 status messages
+type EDI214Request struct {
+	PartnerID string `json:"partnerId" binding:"required"`
+}
+
+// EDIInvoiceCharge is one billed charge on a 210 freight invoice
+type EDIInvoiceCharge struct {
+	ChargeCode    string  `json:"chargeCode"`
+	Description   string  `json:"description"`
+	Quantity      float64 `json:"quantity"`
+	RateUsd       float64 `json:"rateUsd"`
+	RateQualifier string  `json:"rateQualifier"`
+	AmountUsd     float64 `json:"amountUsd"`
+}
+
+// EDIInvoice summarizes a generated 210 and how it reconciles against Turvo
+type EDIInvoice struct {
+	InvoiceNumber string             `json:"invoiceNumber"`
+	ShipmentID    string             `json:"shipmentId"`
+	BillTo        string             `json:"billTo"`
+	Charges       []EDIInvoiceCharge `json:"charges"`
+	TotalUsd      float64            `json:"totalUsd"`
+	TurvoTotalUsd float64            `json:"turvoTotalUsd"`
+	Valid         bool               `json:"valid"`
+	Issues        []string           `json:"issues"`
+	Message       *EDIMessage        `json:"message"`
+}
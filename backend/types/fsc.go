@@ -0,0 +1,45 @@
+package types
+
+import "time"
+
+// Fuel surcharge schedule methods
+const (
+	FSCMethodPercent = "percent"
+	FSCMethodPerMile = "per_mile"
+)
+
+// FSCSchedule maps diesel price bands to a fuel surcharge for a set of customers
+type FSCSchedule struct {
+	ID        string    `json:"id"`
+	Customers []string  `json:"customers"`
+	Default   bool      `json:"default"`
+	Method    string    `json:"method"`
+	Bands     []FSCBand `json:"bands"`
+}
+
+// FSCBand is one diesel price range in a fuel surcharge schedule. Value is a
+// percent of linehaul for percent schedules and cents per mile for per-mile
+// schedules. A zero MaxPriceUsd leaves the band open-ended.
+type FSCBand struct {
+	MinPriceUsd float64 `json:"minPriceUsd"`
+	MaxPriceUsd float64 `json:"maxPriceUsd"`
+	Value       float64 `json:"value"`
+}
+
+// DieselPrice is one weekly observation of the DOE diesel price index
+type DieselPrice struct {
+	Date     time.Time `json:"date"`
+	PriceUsd float64   `json:"priceUsd"`
+}
+
+// FSCQuote is the fuel surcharge that applies to a customer on a given date
+type FSCQuote struct {
+	Customer       string    `json:"customer"`
+	Date           time.Time `json:"date"`
+	ScheduleID     string    `json:"scheduleId"`
+	Method         string    `json:"method"`
+	DieselPriceUsd float64   `json:"dieselPriceUsd"`
+	PriceDate      time.Time `json:"priceDate"`
+	FSCPercent     float64   `json:"fscPercent"`
+	FSCPerMile     float64   `json:"fscPerMile"`
+}
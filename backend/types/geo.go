@@ -0,0 +1,7 @@
+package types
+
+// GeoPoint is a latitude/longitude pair in decimal degrees
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
@@ -14,6 +14,18 @@ type Load struct {
 	Carrier           Carrier        `json:"carrier"`
 	RateData          RateData       `json:"rateData"`
 	Specifications    Specifications `json:"specifications"`
+	Mode              string         `json:"mode"`
+	ServiceType       string         `json:"serviceType"`
+	Equipment         Equipment      `json:"equipment"`
+	Commodities       []Commodity    `json:"commodities"`
+}
+
+// Equipment describes the trailer a load requires. Reefer temperatures come
+// from Specifications.MinTempFahrenheit and MaxTempFahrenheit, which are
+// null when no range is given so that a 0F range can be set.
+type Equipment struct {
+	Type            string `json:"type"`
+	TrailerLengthFt int    `json:"trailerLengthFt"`
 }
 
 // Customer represents the customer object in Drumkit format
@@ -30,6 +42,8 @@ type Customer struct {
 	Phone         string `json:"phone"`
 	Email         string `json:"email"`
 	RefNumber     string `json:"refNumber"`
+
+	Geo *GeoPoint `json:"geo,omitempty"`
 }
 
 // BillTo represents the billTo object in Drumkit format
@@ -45,6 +59,8 @@ type BillTo struct {
 	Contact       string `json:"contact"`
 	Phone         string `json:"phone"`
 	Email         string `json:"email"`
+
+	Geo *GeoPoint `json:"geo,omitempty"`
 }
 
 // Pickup represents the pickup object in Drumkit format
@@ -67,6 +83,16 @@ type Pickup struct {
 	ApptNote      string    `json:"apptNote"`
 	Timezone      string    `json:"timezone"`
 	WarehouseID   string    `json:"warehouseId"`
+
+	// Geo is a stop's position, sent on its Turvo location. When the client
+	// sends none, it is filled from the zip5 dataset for US zips.
+	Geo *GeoPoint `json:"geo,omitempty"`
+
+	// Appointment window and scheduling; an empty SchedulingType means "appointment"
+	ApptWindowStart time.Time `json:"apptWindowStart"`
+	ApptWindowEnd   time.Time `json:"apptWindowEnd"`
+	SchedulingType  string    `json:"schedulingType"`
+	ApptFlexMinutes int       `json:"apptFlexMinutes"`
 }
 
 // Consignee represents the consignee object in Drumkit format
@@ -89,6 +115,14 @@ type Consignee struct {
 	ApptNote      string    `json:"apptNote"`
 	Timezone      string    `json:"timezone"`
 	WarehouseID   string    `json:"warehouseId"`
+
+	Geo *GeoPoint `json:"geo,omitempty"`
+
+	// Appointment window and scheduling; an empty SchedulingType means "appointment"
+	ApptWindowStart time.Time `json:"apptWindowStart"`
+	ApptWindowEnd   time.Time `json:"apptWindowEnd"`
+	SchedulingType  string    `json:"schedulingType"`
+	ApptFlexMinutes int       `json:"apptFlexMinutes"`
 }
 
 // Carrier represents the carrier object in Drumkit format
@@ -147,8 +181,8 @@ type Specifications struct {
 	PONums             string  `json:"poNums"`
 	Operator           string  `json:"operator"`
 	RouteMiles         float64 `json:"routeMiles"`
-	MinTempFahrenheit  float64 `json:"minTempFahrenheit"`
-	MaxTempFahrenheit  float64 `json:"maxTempFahrenheit"`
+	MinTempFahrenheit  *float64 `json:"minTempFahrenheit"`
+	MaxTempFahrenheit  *float64 `json:"maxTempFahrenheit"`
 	LiftgatePickup     bool    `json:"liftgatePickup"`
 	LiftgateDelivery   bool    `json:"liftgateDelivery"`
 	InsidePickup       bool    `json:"insidePickup"`
@@ -162,6 +196,16 @@ type Specifications struct {
 	Seal               bool    `json:"seal"`
 	CustomBonded       bool    `json:"customBonded"`
 	Labor              bool    `json:"labor"`
+
+	CubicFeet  float64 `json:"cubicFeet"`
+	DensityPcf float64 `json:"densityPcf"`
+	LinearFeet float64 `json:"linearFeet"`
+
+	ResidentialPickup     bool `json:"residentialPickup"`
+	ResidentialDelivery   bool `json:"residentialDelivery"`
+	LimitedAccessPickup   bool `json:"limitedAccessPickup"`
+	LimitedAccessDelivery bool `json:"limitedAccessDelivery"`
+	DeliveryNotification  bool `json:"deliveryNotification"`
 }
 
 // CreateLoadRequest represents the request body for creating a new load
@@ -176,4 +220,8 @@ type CreateLoadRequest struct {
 	Carrier           Carrier        `json:"carrier"`
 	RateData          RateData       `json:"rateData"`
 	Specifications    Specifications `json:"specifications"`
+	Mode              string         `json:"mode"`
+	ServiceType       string         `json:"serviceType"`
+	Equipment         Equipment      `json:"equipment"`
+	Commodities       []Commodity    `json:"commodities"`
 } 
\ No newline at end of file
@@ -0,0 +1,19 @@
+package types
+
+// LoadStreamResetEvent tells a stream client to reload its list because the
+// events it missed are no longer buffered
+const LoadStreamResetEvent = "reset"
+
+// LoadStreamFilter limits a load stream to one tenant's loads, optionally for
+// one customer and a set of statuses. Empty customer and statuses match every load.
+type LoadStreamFilter struct {
+	TenantID string
+	Customer string
+	Statuses []string
+}
+
+// StreamedLoadEvent is a load event with its position in the stream
+type StreamedLoadEvent struct {
+	Sequence int64
+	Event    WebhookEvent
+}
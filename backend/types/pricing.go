@@ -0,0 +1,30 @@
+package types
+
+// PricingRequest represents the request body for previewing load pricing
+type PricingRequest struct {
+	RateData   RateData `json:"rateData"`
+	RouteMiles float64  `json:"routeMiles"`
+	Customer   string   `json:"customer"`
+	Date       string   `json:"date"`
+
+	// Specifications, when given, adds billable accessorial charges
+	Specifications *Specifications `json:"specifications,omitempty"`
+}
+
+// PricingResult holds the computed customer and carrier totals for a load
+type PricingResult struct {
+	RouteMiles          float64 `json:"routeMiles"`
+	CustomerRateType    string  `json:"customerRateType"`
+	CustomerQuantity    float64 `json:"customerQuantity"`
+	CustomerLinehaulUsd float64 `json:"customerLinehaulUsd"`
+	FuelSurchargeUsd    float64 `json:"fuelSurchargeUsd"`
+	AccessorialsUsd     float64 `json:"accessorialsUsd"`
+	CustomerTotalUsd    float64 `json:"customerTotalUsd"`
+	CarrierRateType     string  `json:"carrierRateType"`
+	CarrierQuantity     float64 `json:"carrierQuantity"`
+	CarrierTotalUsd     float64 `json:"carrierTotalUsd"`
+	NetProfitUsd        float64 `json:"netProfitUsd"`
+	ProfitPercent       float64 `json:"profitPercent"`
+
+	Accessorials []AccessorialCharge `json:"accessorials,omitempty"`
+}
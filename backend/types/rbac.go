@@ -0,0 +1,23 @@
+package types
+
+// Permissions checked on API routes
+const (
+	PermLoadsRead       = "loads:read"
+	PermLoadsWrite      = "loads:write"
+	PermLoadsDispatch   = "loads:dispatch"
+	PermRatesRead       = "rates:read"
+	PermRatesWrite      = "rates:write"
+	PermEDISend         = "edi:send"
+	PermVettingOverride = "vetting:override"
+	PermWebhooksManage  = "webhooks:manage"
+	PermAuditRead       = "audit:read"
+	PermAll             = "*"
+)
+
+// Built-in roles
+const (
+	RoleViewer     = "viewer"
+	RoleDispatcher = "dispatcher"
+	RoleFinance    = "finance"
+	RoleAdmin      = "admin"
+)
@@ -0,0 +1,39 @@
+package types
+
+// DefaultTenantID names the single tenant built from the environment when no tenants file is configured
+const DefaultTenantID = "default"
+
+// Tenant is a brokerage served by this deployment, with its own Turvo
+// account, defaults, EDI identity, documents, fuel schedules and vetting
+// overrides. An empty base URL, OAuth scope or type, or default falls back
+// to the deployment's; credentials and the brokerage's own files never do.
+type Tenant struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	TurvoBaseURL      string `json:"turvoBaseUrl"`
+	TurvoClientID     string `json:"turvoClientId"`
+	TurvoClientSecret string `json:"turvoClientSecret"`
+	TurvoUsername     string `json:"turvoUsername"`
+	TurvoPassword     string `json:"turvoPassword"`
+	TurvoOAuthScope   string `json:"turvoOAuthScope"`
+	TurvoOAuthType    string `json:"turvoOAuthType"`
+	TurvoXApiKey      string `json:"turvoXApiKey"`
+
+	TurvoWebhookSecret string `json:"turvoWebhookSecret"`
+
+	DefaultTimezone  string    `json:"defaultTimezone"`
+	DefaultStatus    TurvoCode `json:"defaultStatus"`
+	AccessorialsFile string    `json:"accessorialsFile"`
+	TurvoCodesFile   string    `json:"turvoCodesFile"`
+
+	EDIPartnersFile       string `json:"ediPartnersFile"`
+	EDIControlNumbersFile string `json:"ediControlNumbersFile"`
+	EDIOutboxFile         string `json:"ediOutboxFile"`
+
+	BOLTemplatesFile   string `json:"bolTemplatesFile"`
+	RateConBrokersFile string `json:"rateConBrokersFile"`
+	FSCSchedulesFile   string `json:"fscSchedulesFile"`
+
+	VettingOverrideUsers []string `json:"vettingOverrideUsers"`
+}
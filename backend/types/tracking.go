@@ -0,0 +1,20 @@
+package types
+
+import "time"
+
+// Shipment change types detected between two observations of a Turvo shipment
+const (
+	ChangeStatus       = "status_changed"
+	ChangeStopArrived  = "stop_arrived"
+	ChangeStopDeparted = "stop_departed"
+)
+
+// ShipmentChange describes a single tracked change on a Turvo shipment
+type ShipmentChange struct {
+	ShipmentID     string            `json:"shipmentId"`
+	Type           string            `json:"type"`
+	Status         TurvoStatus       `json:"status"`
+	PreviousStatus TurvoStatus       `json:"previousStatus"`
+	Stop           *TurvoGlobalRoute `json:"stop,omitempty"`
+	OccurredAt     time.Time         `json:"occurredAt"`
+}
@@ -98,12 +98,15 @@ type TurvoGlobalRoute struct {
 	FragmentDistance           TurvoDistance             `json:"fragmentDistance,omitempty"`
 	Distance                   TurvoDistance             `json:"distance,omitempty"`
 	StopLevelFragmentDistance  int                       `json:"stop_level_fragment_distance,omitempty"`
+	Arrival                    *TurvoDate                `json:"arrival,omitempty"`
+	Departed                   *TurvoDate                `json:"departed,omitempty"`
 }
 
 // TurvoLocation represents a location in Turvo format
 type TurvoLocation struct {
 	ID int `json:"id,omitempty"`
 	// For creation, we might need address fields
+	Name         string  `json:"name,omitempty"`
 	AddressLine1 string  `json:"addressLine1,omitempty"`
 	AddressLine2 string  `json:"addressLine2,omitempty"`
 	City         string  `json:"city,omitempty"`
@@ -113,6 +116,8 @@ type TurvoLocation struct {
 	ContactName  string  `json:"contactName,omitempty"`
 	Phone        string  `json:"phone,omitempty"`
 	Email        string  `json:"email,omitempty"`
+	Lat          float64 `json:"lat,omitempty"`
+	Lng          float64 `json:"lng,omitempty"`
 }
 
 // TurvoLayoverTime represents layover time
@@ -223,6 +228,9 @@ type TurvoItem struct {
 	StackDimensionsLimit TurvoStackDimensions `json:"stackDimensionsLimit,omitempty"`
 	LoadBearingCapacity  TurvoLoadBearing    `json:"loadBearingCapacity,omitempty"`
 	MaxStackCount        int                 `json:"maxStackCount,omitempty"`
+
+	GrossWeight float64   `json:"grossWeight,omitempty"`
+	WeightUnits TurvoCode `json:"weightUnits,omitempty"`
 }
 
 // TurvoDimensions represents dimensions
@@ -286,6 +294,14 @@ type TurvoCarrierOrder struct {
 	CarrierOrderSourceID int           `json:"carrierOrderSourceId"`
 	Carrier              TurvoCarrier  `json:"carrier"`
 	Drivers              []TurvoDriver `json:"drivers,omitempty"`
+
+	ID            int         `json:"id,omitempty"`
+	Operation     int         `json:"_operation,omitempty"`
+	Costs         *TurvoCosts `json:"costs,omitempty"`
+	TractorNumber string      `json:"tractorNumber,omitempty"`
+	TrailerNumber string      `json:"trailerNumber,omitempty"`
+
+	ExternalIDs []TurvoExternalID `json:"externalIds,omitempty"`
 }
 
 // TurvoCarrier represents a carrier
@@ -311,6 +327,8 @@ type TurvoShipmentResponse struct {
 
 // TurvoShipment represents a shipment from Turvo's GET /shipments API
 type TurvoShipment struct {
+	ID         int    `json:"id,omitempty"`
+	CustomID   string `json:"customId,omitempty"`
 	ShipmentID string `json:"shipmentId"`
 	Status     TurvoStatus `json:"status"`
 	Lane       TurvoLane `json:"lane"`
@@ -320,6 +338,14 @@ type TurvoShipment struct {
 	StartDate  TurvoDate `json:"startDate"`
 	EndDate    TurvoDate `json:"endDate"`
 	LTLShipment bool `json:"ltlShipment"`
+	Equipment  []TurvoEquipment `json:"equipment,omitempty"`
+	ModeInfo   []TurvoModeInfo `json:"modeInfo,omitempty"`
+}
+
+// TurvoShipmentDetailsResponse represents the response from GET /shipments/:id
+type TurvoShipmentDetailsResponse struct {
+	Status  string        `json:"Status"`
+	Details TurvoShipment `json:"details"`
 }
 
 // TurvoShipmentsResponse represents the response from GET /shipments
@@ -366,6 +392,7 @@ type TurvoShipmentData struct {
 	Updated       string `json:"updated"`
 	LastUpdatedOn string `json:"lastUpdatedOn"`
 	CreatedDate   string `json:"createdDate"`
+	LTLShipment   bool   `json:"ltlShipment"`
 }
 
 // TurvoPagination represents pagination information
@@ -374,4 +401,65 @@ type TurvoPagination struct {
 	PageSize           int  `json:"pageSize"`
 	TotalRecordsInPage int  `json:"totalRecordsInPage"`
 	MoreAvailable      bool `json:"moreAvailable"`
-}
\ No newline at end of file
+}
+
+// TurvoShipmentUpdate is the body of PUT /shipments/:id for the parts of a
+// shipment this API changes after creation
+type TurvoShipmentUpdate struct {
+	CarrierOrder []TurvoCarrierOrder `json:"carrierOrder,omitempty"`
+}
+
+// TurvoDriverProfile represents a driver record from Turvo's drivers API
+type TurvoDriverProfile struct {
+	ID      int          `json:"id,omitempty"`
+	Name    string       `json:"name"`
+	Phone   string       `json:"phone,omitempty"`
+	Carrier TurvoCarrier `json:"carrier"`
+}
+
+// TurvoDriversResponse represents the response from GET /drivers/list
+type TurvoDriversResponse struct {
+	Status  string `json:"Status"`
+	Details struct {
+		Drivers []TurvoDriverProfile `json:"drivers"`
+	} `json:"details"`
+}
+
+// TurvoDriverResponse represents the response from POST /drivers
+type TurvoDriverResponse struct {
+	Status  string             `json:"Status"`
+	Details TurvoDriverProfile `json:"details"`
+}
+
+// TurvoLocationsResponse represents the response from GET /locations/list
+type TurvoLocationsResponse struct {
+	Status  string `json:"Status"`
+	Details struct {
+		Locations []TurvoLocation `json:"locations"`
+	} `json:"details"`
+}
+
+// TurvoLocationResponse represents the response from POST /locations
+type TurvoLocationResponse struct {
+	Status  string        `json:"Status"`
+	Details TurvoLocation `json:"details"`
+}
+
+// Turvo webhook event types for shipments
+const (
+	TurvoEventShipmentCreated       = "SHIPMENT_CREATED"
+	TurvoEventShipmentUpdated       = "SHIPMENT_UPDATED"
+	TurvoEventShipmentStatusUpdated = "SHIPMENT_STATUS_UPDATED"
+	TurvoEventShipmentDeleted       = "SHIPMENT_DELETED"
+)
+
+// TurvoWebhookEvent is the body Turvo posts to webhook callbacks. Shipment
+// events carry the shipment as returned by GET /shipments/:id.
+type TurvoWebhookEvent struct {
+	ID          string         `json:"id"`
+	EventType   string         `json:"eventType"`
+	EntityType  string         `json:"entityType"`
+	EntityID    int            `json:"entityId"`
+	CreatedDate string         `json:"createdDate"`
+	Shipment    *TurvoShipment `json:"data,omitempty"`
+}
@@ -0,0 +1,22 @@
+package types
+
+// TurvoCodes are the Turvo codes for load fields whose keys differ between
+// Turvo accounts. Each map is keyed by the canonical value accepted on loads,
+// such as "reefer", "ltl" or "77.5"; trailer sizes are keyed by length in feet.
+type TurvoCodes struct {
+	Equipment       map[string]TurvoCode `json:"equipment"`
+	TrailerSizes    map[int]TurvoCode    `json:"trailerSizes"`
+	TemperatureUnit TurvoCode            `json:"temperatureUnit"`
+	DimensionUnit   TurvoCode            `json:"dimensionUnit"`
+	WeightUnit      TurvoCode            `json:"weightUnit"`
+	HandlingUnits   map[string]TurvoCode `json:"handlingUnits"`
+	FreightClasses  map[string]TurvoCode `json:"freightClasses"`
+	Modes           map[string]TurvoCode `json:"modes"`
+	ServiceTypes    map[string]TurvoCode `json:"serviceTypes"`
+	Scheduling      map[string]TurvoCode `json:"scheduling"`
+	FuelSurcharge   TurvoCode            `json:"fuelSurcharge"`
+
+	// RateConfirmationSent is the carrier order external ID type that
+	// records when the rate confirmation was sent to the carrier
+	RateConfirmationSent TurvoCode `json:"rateConfirmationSent"`
+}
@@ -0,0 +1,21 @@
+package types
+
+import "strings"
+
+// ValidationError describes an invalid field by its JSON path
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects the validation failures for a request
+type ValidationErrors []ValidationError
+
+// Error joins the failures into a single message
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Field+": "+err.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,50 @@
+package types
+
+import "time"
+
+// Carrier vetting outcomes. Only a failed vetting blocks dispatch.
+const (
+	VettingStatusPass = "pass"
+	VettingStatusWarn = "warn"
+	VettingStatusFail = "fail"
+)
+
+// CarrierComplianceRecord is a carrier's authority, insurance and safety data
+// as reported by a compliance provider. Dates are YYYY-MM-DD.
+type CarrierComplianceRecord struct {
+	MCNumber              string  `json:"mcNumber"`
+	DOTNumber             string  `json:"dotNumber"`
+	LegalName             string  `json:"legalName"`
+	AuthorityStatus       string  `json:"authorityStatus"`
+	InsuranceExpiresOn    string  `json:"insuranceExpiresOn"`
+	LiabilityInsuranceUsd float64 `json:"liabilityInsuranceUsd"`
+	CargoInsuranceUsd     float64 `json:"cargoInsuranceUsd"`
+	SafetyRating          string  `json:"safetyRating"`
+	OutOfService          bool    `json:"outOfService"`
+	UpdatedOn             string  `json:"updatedOn"`
+}
+
+// VettingFinding is one problem found while vetting a carrier
+type VettingFinding struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// VettingResult is the outcome of vetting a carrier
+type VettingResult struct {
+	MCNumber  string                   `json:"mcNumber"`
+	DOTNumber string                   `json:"dotNumber"`
+	Provider  string                   `json:"provider"`
+	Status    string                   `json:"status"`
+	Findings  []VettingFinding         `json:"findings"`
+	Record    *CarrierComplianceRecord `json:"record,omitempty"`
+	CheckedAt time.Time                `json:"checkedAt"`
+}
+
+// VettingOverride acknowledges a failed vetting so a carrier can be dispatched anyway
+type VettingOverride struct {
+	User         string `json:"user"`
+	Reason       string `json:"reason"`
+	Acknowledged bool   `json:"acknowledged"`
+}
@@ -0,0 +1,64 @@
+package types
+
+import "time"
+
+// Load lifecycle events delivered to webhook subscribers
+const (
+	EventLoadCreated       = "load.created"
+	EventLoadUpdated       = "load.updated"
+	EventLoadStatusChanged = "load.status_changed"
+	EventLoadDelivered     = "load.delivered"
+	EventLoadStopArrived   = "load.stop_arrived"
+	EventLoadStopDeparted  = "load.stop_departed"
+)
+
+// WebhookEventAll subscribes to every event type
+const WebhookEventAll = "*"
+
+// WebhookSubscription is a subscriber URL and the events it receives from
+// its tenant. The secret signs payloads and is only returned when the
+// subscription is created.
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenantId"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookSubscriptionRequest registers a webhook subscriber. A secret is
+// generated when none is given.
+type WebhookSubscriptionRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+	Secret string   `json:"secret"`
+}
+
+// WebhookEvent is the payload posted to subscribers
+type WebhookEvent struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	TenantID       string    `json:"tenantId"`
+	ShipmentID     string    `json:"shipmentId"`
+	Status         string    `json:"status,omitempty"`
+	PreviousStatus string    `json:"previousStatus,omitempty"`
+	StopType       string    `json:"stopType,omitempty"`
+	StopName       string    `json:"stopName,omitempty"`
+	OccurredAt     time.Time `json:"occurredAt"`
+	Load           *Load     `json:"load,omitempty"`
+}
+
+// WebhookDelivery tracks the attempts to deliver one event to one subscriber
+type WebhookDelivery struct {
+	ID             string       `json:"id"`
+	SubscriptionID string       `json:"subscriptionId"`
+	URL            string       `json:"url"`
+	Event          WebhookEvent `json:"event"`
+	Attempts       int          `json:"attempts"`
+	LastStatusCode int          `json:"lastStatusCode,omitempty"`
+	LastError      string       `json:"lastError,omitempty"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	NextAttemptAt  time.Time    `json:"nextAttemptAt,omitempty"`
+	DeadLetteredAt time.Time    `json:"deadLetteredAt,omitempty"`
+}
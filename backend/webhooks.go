@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"turvo-app/services"
+	"turvo-app/types"
+)
+
+// getWebhookSubscriptions lists the tenant's webhook subscribers without their secrets
+func getWebhookSubscriptions(c *gin.Context, webhookService *services.WebhookService) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhookService.Subscriptions(tenantFor(c).Tenant.ID),
+	})
+}
+
+// createWebhookSubscription registers a subscriber to the tenant's events and
+// returns its signing secret
+func createWebhookSubscription(c *gin.Context, webhookService *services.WebhookService) {
+	var req types.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	subscription, err := webhookService.Subscribe(tenantFor(c).Tenant.ID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    subscription,
+		"message": "Store the secret now; it is not returned again",
+	})
+}
+
+// deleteWebhookSubscription removes one of the tenant's subscribers
+func deleteWebhookSubscription(c *gin.Context, webhookService *services.WebhookService) {
+	if err := webhookService.Unsubscribe(tenantFor(c).Tenant.ID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// getWebhookDeadLetters lists the tenant's deliveries that exhausted their retries
+func getWebhookDeadLetters(c *gin.Context, webhookService *services.WebhookService) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhookService.DeadLetters(tenantFor(c).Tenant.ID),
+	})
+}
+
+// replayWebhookDeadLetter redelivers a dead-lettered event in the background
+func replayWebhookDeadLetter(c *gin.Context, webhookService *services.WebhookService) {
+	delivery, err := webhookService.Replay(tenantFor(c).Tenant.ID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    delivery,
+		"message": "Webhook delivery queued",
+	})
+}
+
+// maxTurvoWebhookBytes caps the size of a Turvo callback body
+const maxTurvoWebhookBytes = 5 << 20
+
+// receiveTurvoWebhook accepts Turvo's shipment event callbacks. Redelivered
+// events are acknowledged without being processed again.
+func receiveTurvoWebhook(c *gin.Context, receiver *services.TurvoWebhookReceiver) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxTurvoWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to read request body: " + err.Error(),
+		})
+		return
+	}
+
+	event, duplicate, err := receiver.Receive(body, c.GetHeader(services.TurvoSignatureHeader))
+	if err != nil {
+		fmt.Printf("DEBUG: Rejected Turvo webhook: %v\n", err)
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, services.ErrTurvoWebhookNotConfigured):
+			status = http.StatusServiceUnavailable
+		case errors.Is(err, services.ErrTurvoWebhookSignature):
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"id":        event.ID,
+		"duplicate": duplicate,
+	})
+}
+
+// listenForTurvoEvents keeps a tenant's stored loads in step with its Turvo
+// shipment events, audits status changes, and publishes outbound webhooks
+// and EDI 214s for the changes they carry
+func listenForTurvoEvents(tenant *services.TenantServices, webhookService *services.WebhookService, auditLog *services.AuditLog) {
+	receiver, turvoService, loadStore := tenant.TurvoWebhooks, tenant.Turvo, tenant.Loads
+	receiver.Listen(func(event types.TurvoWebhookEvent) {
+		if event.Shipment == nil || event.EventType == types.TurvoEventShipmentDeleted {
+			return
+		}
+		load := convertTurvoToDrumkit(*event.Shipment, turvoService)
+		previousStatus := ""
+		if stored, ok := loadStore.Get(load.ExternalTMSLoadID); ok && load.Status != "" {
+			previousStatus = stored.Status
+			stored.Status = load.Status
+			loadStore.Save(stored)
+		}
+		if event.EventType == types.TurvoEventShipmentStatusUpdated || (previousStatus != "" && previousStatus != load.Status) {
+			request, _ := json.Marshal(gin.H{"eventId": event.ID, "eventType": event.EventType, "status": load.Status, "previousStatus": previousStatus})
+			auditLog.Record(types.AuditEntry{
+				Actor:      "turvo",
+				TenantID:   tenant.Tenant.ID,
+				Action:     types.AuditLoadStatusChange,
+				Method:     http.MethodPost,
+				Endpoint:   "/api/webhooks/turvo/" + tenant.Tenant.ID,
+				ResourceID: load.ExternalTMSLoadID,
+				Request:    request,
+				Outcome:    types.AuditOutcomeSuccess,
+				StatusCode: http.StatusOK,
+			})
+		}
+	})
+	receiver.Listen(func(event types.TurvoWebhookEvent) {
+		if event.Shipment == nil || event.EventType == types.TurvoEventShipmentDeleted {
+			return
+		}
+		webhookService.ObserveShipment(tenant.Tenant.ID, *event.Shipment, convertTurvoToDrumkit(*event.Shipment, turvoService))
+		reportEDIStatus(tenant, *event.Shipment, auditLog)
+	})
+}
+
+// pollTurvoChanges reads the first page of a tenant's shipments from Turvo
+// on an interval so changes made in Turvo reach webhook subscribers and EDI
+// partners without a client listing loads
+func pollTurvoChanges(tenant *services.TenantServices, webhookService *services.WebhookService, auditLog *services.AuditLog, interval time.Duration) {
+	turvoService := tenant.Turvo
+	for {
+		shipments, _, err := turvoService.GetShipments(0)
+		if err != nil {
+			fmt.Printf("DEBUG: Webhook poll for tenant %s failed: %v\n", tenant.Tenant.ID, err)
+		}
+		for _, shipment := range shipments {
+			webhookService.ObserveShipment(tenant.Tenant.ID, shipment, convertTurvoToDrumkit(shipment, turvoService))
+			reportEDIStatus(tenant, shipment, auditLog)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -1959,12 +1959,12 @@ const CreateLoadForm: React.FC<CreateLoadFormProps> = ({ onLoadCreated }) => {
                 <input
                   type="number"
                   step="0.1"
-                  value={formData.specifications.minTempFahrenheit}
+                  value={formData.specifications.minTempFahrenheit ?? ''}
                   onChange={(e) =>
                     handleInputChange(
                       'specifications',
                       'minTempFahrenheit',
-                      parseFloat(e.target.value) || 0
+                      e.target.value === '' ? null : parseFloat(e.target.value)
                     )
                   }
                   className="mt-1 block w-full border-gray-300 rounded-md shadow-sm focus:ring-blue-500 focus:border-blue-500 sm:text-sm"
@@ -1978,12 +1978,12 @@ const CreateLoadForm: React.FC<CreateLoadFormProps> = ({ onLoadCreated }) => {
                 <input
                   type="number"
                   step="0.1"
-                  value={formData.specifications.maxTempFahrenheit}
+                  value={formData.specifications.maxTempFahrenheit ?? ''}
                   onChange={(e) =>
                     handleInputChange(
                       'specifications',
                       'maxTempFahrenheit',
-                      parseFloat(e.target.value) || 0
+                      e.target.value === '' ? null : parseFloat(e.target.value)
                     )
                   }
                   className="mt-1 block w-full border-gray-300 rounded-md shadow-sm focus:ring-blue-500 focus:border-blue-500 sm:text-sm"
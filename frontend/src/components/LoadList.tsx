@@ -1,5 +1,5 @@
 import React, { useEffect, useState } from 'react';
-import { Load } from '../types';
+import { Load, LoadEvent } from '../types';
 import { loadService } from '../services/api';
 import LoadDetails from './LoadDetails';
 
@@ -16,6 +16,38 @@ const LoadList: React.FC = () => {
     fetchLoads();
   }, []);
 
+  // Apply live events in place; a reset means events were missed, so reload
+  useEffect(() => {
+    return loadService.streamLoads(applyLoadEvent, () => fetchLoads());
+  }, []);
+
+  const applyLoadEvent = (event: LoadEvent) => {
+    const updated = event.load;
+    if (!updated) {
+      return;
+    }
+    setLoads((prev) => {
+      const index = prev.findIndex(
+        (load) => load.externalTMSLoadID === event.shipmentId
+      );
+      if (index === -1) {
+        return event.type === 'load.created' ? [updated, ...prev] : prev;
+      }
+      const next = [...prev];
+      next[index] = {
+        ...prev[index],
+        ...updated,
+        status: event.status || updated.status,
+      };
+      return next;
+    });
+    setSelectedLoad((selected) =>
+      selected && selected.externalTMSLoadID === event.shipmentId
+        ? { ...selected, ...updated }
+        : selected
+    );
+  };
+
   const fetchLoads = async (page: number = 0, append: boolean = false) => {
     try {
       if (page === 0) {
@@ -1,5 +1,5 @@
 import axios from 'axios';
-import { Load, CreateLoadRequest, ApiResponse } from '../types';
+import { Load, CreateLoadRequest, ApiResponse, LoadEvent } from '../types';
 
 const API_BASE_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080';
 
@@ -10,6 +10,28 @@ const api = axios.create({
   },
 });
 
+// The API requires a JWT; it is read from localStorage ('authToken'). Tokens
+// are never baked into the build, where anyone could read them from the bundle.
+export const getAuthToken = (): string | null => localStorage.getItem('authToken');
+
+api.interceptors.request.use((config) => {
+  const token = getAuthToken();
+  if (token) {
+    config.headers.Authorization = `Bearer ${token}`;
+  }
+  return config;
+});
+
+api.interceptors.response.use(
+  (response) => response,
+  (error) => {
+    if (error.response?.status === 401) {
+      console.error('API request was not authenticated; check the auth token');
+    }
+    return Promise.reject(error);
+  }
+);
+
 export const loadService = {
   // Get all loads
   getLoads: async (page: number = 0): Promise<ApiResponse<Load[]>> => {
@@ -36,6 +58,44 @@ export const loadService = {
     }
   },
 
+  // Subscribe to live load events; returns a function that closes the stream.
+  // onReset is called when events were missed and the list should be reloaded.
+  streamLoads: (
+    onEvent: (event: LoadEvent) => void,
+    onReset: () => void,
+    filters: { customer?: string; status?: string } = {}
+  ): (() => void) => {
+    const params = new URLSearchParams();
+    if (filters.customer) params.set('customer', filters.customer);
+    if (filters.status) params.set('status', filters.status);
+    // EventSource cannot send headers, so the token goes in the query string
+    const token = getAuthToken();
+    if (token) params.set('access_token', token);
+    const query = params.toString();
+    const source = new EventSource(
+      `${API_BASE_URL}/api/loads/stream${query ? `?${query}` : ''}`
+    );
+
+    const handle = (message: MessageEvent) => {
+      try {
+        onEvent(JSON.parse(message.data) as LoadEvent);
+      } catch (error) {
+        console.error('Error parsing load event:', error);
+      }
+    };
+    [
+      'load.created',
+      'load.updated',
+      'load.status_changed',
+      'load.delivered',
+      'load.stop_arrived',
+      'load.stop_departed',
+    ].forEach((type) => source.addEventListener(type, handle as EventListener));
+    source.addEventListener('reset', onReset);
+
+    return () => source.close();
+  },
+
   // Get shipment details
   getShipmentDetails: async (shipmentId: string): Promise<ApiResponse<any>> => {
     try {
@@ -10,6 +10,10 @@ export interface Load {
   carrier?: Carrier;
   rateData?: RateData;
   specifications?: Specifications;
+  mode?: string;
+  serviceType?: string;
+  equipment?: Equipment;
+  commodities?: Commodity[];
 
   // Legacy format (for backward compatibility)
   id?: string;
@@ -31,6 +35,41 @@ export interface CreateLoadRequest {
   carrier: Carrier;
   rateData: RateData;
   specifications: Specifications;
+  mode?: string;
+  serviceType?: string;
+  equipment?: Equipment;
+  commodities?: Commodity[];
+}
+
+export interface Equipment {
+  type: string;
+  trailerLengthFt: number;
+}
+
+export interface Commodity {
+  description: string;
+  pieceCount: number;
+  handlingUnit: string;
+  handlingUnitCount: number;
+  weightLbs: number;
+  lengthIn: number;
+  widthIn: number;
+  heightIn: number;
+  freightClass: string;
+  nmfc: string;
+  stackable: boolean;
+  hazmat?: Hazmat;
+}
+
+export interface Hazmat {
+  unNumber: string;
+  properShippingName: string;
+  hazardClass: string;
+  packingGroup: string;
+  emergencyContact: string;
+  emergencyPhone: string;
+  placardRequired: boolean;
+  placard: string;
 }
 
 export interface Customer {
@@ -81,6 +120,10 @@ export interface Pickup {
   apptNote: string;
   timezone: string;
   warehouseId: string;
+  apptWindowStart?: string;
+  apptWindowEnd?: string;
+  schedulingType?: 'appointment' | 'fcfs' | 'open';
+  apptFlexMinutes?: number;
 }
 
 export interface Consignee {
@@ -102,6 +145,10 @@ export interface Consignee {
   apptNote: string;
   timezone: string;
   warehouseId: string;
+  apptWindowStart?: string;
+  apptWindowEnd?: string;
+  schedulingType?: 'appointment' | 'fcfs' | 'open';
+  apptFlexMinutes?: number;
 }
 
 export interface Carrier {
@@ -157,8 +204,8 @@ export interface Specifications {
   poNums: string;
   operator: string;
   routeMiles: number;
-  minTempFahrenheit: number;
-  maxTempFahrenheit: number;
+  minTempFahrenheit: number | null;
+  maxTempFahrenheit: number | null;
   liftgatePickup: boolean;
   liftgateDelivery: boolean;
   insidePickup: boolean;
@@ -172,6 +219,14 @@ export interface Specifications {
   seal: boolean;
   customBonded: boolean;
   labor: boolean;
+  cubicFeet?: number;
+  densityPcf?: number;
+  linearFeet?: number;
+  residentialPickup?: boolean;
+  residentialDelivery?: boolean;
+  limitedAccessPickup?: boolean;
+  limitedAccessDelivery?: boolean;
+  deliveryNotification?: boolean;
 }
 
 export interface ApiResponse<T> {
@@ -187,3 +242,15 @@ export interface ApiResponse<T> {
     moreAvailable: boolean;
   };
 }
+
+export interface LoadEvent {
+  id: string;
+  type: string;
+  shipmentId: string;
+  status?: string;
+  previousStatus?: string;
+  stopType?: string;
+  stopName?: string;
+  occurredAt: string;
+  load?: Load;
+}